@@ -0,0 +1,135 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// CheckFunc reports a dependency as healthy by returning nil.
+type CheckFunc func(ctx context.Context) error
+
+type Result struct {
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+	// Critical checks fail readiness; non-critical ones are only reported.
+	Critical bool `json:"critical"`
+}
+
+type Report struct {
+	Status string            `json:"status"`
+	Reason string            `json:"reason,omitempty"`
+	Checks map[string]Result `json:"checks"`
+}
+
+func (r Report) OK() bool { return r.Status == StatusOK }
+
+// Registry holds the readiness checks. Checks run concurrently on every
+// readiness probe, each bounded by the registry timeout.
+type Registry struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks map[string]check
+
+	shuttingDown atomic.Bool
+}
+
+type check struct {
+	fn       CheckFunc
+	critical bool
+}
+
+func NewRegistry(timeout time.Duration) *Registry {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	return &Registry{timeout: timeout, checks: map[string]check{}}
+}
+
+// Register adds (or replaces) a named check that fails readiness.
+func (r *Registry) Register(name string, fn CheckFunc) {
+	r.register(name, check{fn: fn, critical: true})
+}
+
+// RegisterNonCritical adds (or replaces) a named check that is reported in
+// /readyz details but doesn't fail readiness (e.g. telemetry export).
+func (r *Registry) RegisterNonCritical(name string, fn CheckFunc) {
+	r.register(name, check{fn: fn})
+}
+
+func (r *Registry) register(name string, c check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = c
+}
+
+// SetShuttingDown makes readiness fail from now on, so load balancers stop
+// routing to this instance before the listener closes.
+func (r *Registry) SetShuttingDown() {
+	r.shuttingDown.Store(true)
+}
+
+func (r *Registry) Ready(ctx context.Context) Report {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.checks))
+	for name := range r.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	checks := make([]check, len(names))
+	for i, name := range names {
+		checks[i] = r.checks[name]
+	}
+	r.mu.RUnlock()
+
+	results := make([]Result, len(names))
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = r.run(ctx, checks[i])
+		}(i)
+	}
+	wg.Wait()
+
+	rep := Report{Status: StatusOK, Checks: make(map[string]Result, len(names))}
+	for i, name := range names {
+		rep.Checks[name] = results[i]
+		if results[i].Critical && results[i].Status != StatusOK {
+			rep.Status = StatusFail
+		}
+	}
+	if r.shuttingDown.Load() {
+		rep.Status = StatusFail
+		rep.Reason = "shutting down"
+	}
+	return rep
+}
+
+func (r *Registry) run(ctx context.Context, c check) Result {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.fn(ctx)
+	res := Result{
+		Status:     StatusOK,
+		DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+		Critical:   c.critical,
+	}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+	return res
+}
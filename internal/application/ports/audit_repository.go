@@ -0,0 +1,12 @@
+package ports
+
+import (
+	"context"
+
+	"webhookd/internal/domain/audit"
+)
+
+type AuditRepository interface {
+	Append(ctx context.Context, e audit.Entry) error
+	Query(ctx context.Context, f audit.Filter) ([]audit.Entry, error)
+}
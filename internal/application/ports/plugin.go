@@ -0,0 +1,37 @@
+package ports
+
+import (
+	"context"
+
+	"webhookd/internal/domain/plugin"
+	"webhookd/internal/domain/webhook"
+)
+
+// PluginRepository stores plugin modules.
+type PluginRepository interface {
+	// Put adds or replaces the plugin named p.Name.
+	Put(ctx context.Context, p plugin.Plugin, module []byte) error
+	Get(ctx context.Context, name string) (plugin.Plugin, []byte, bool, error)
+	// List returns the plugins ordered by name.
+	List(ctx context.Context) ([]plugin.Plugin, error)
+	Delete(ctx context.Context, name string) (bool, error)
+}
+
+// PluginRuntime compiles plugin modules.
+type PluginRuntime interface {
+	// Compile fails with a description of the problem when module isn't a
+	// plugin the runtime can run.
+	Compile(ctx context.Context, module []byte) (PluginModule, error)
+}
+
+// PluginModule is a compiled plugin; safe for concurrent use. Each run gets
+// a fresh instance, sandboxed: no network or files, and bounded memory and
+// run time.
+type PluginModule interface {
+	// Run computes the response to req; errors say why the plugin failed.
+	// It fails with webhook.ErrPluginBusy rather than wait when the runtime
+	// runs as many instances as it may.
+	Run(ctx context.Context, req webhook.TemplateData) (webhook.ScriptResult, error)
+	// Close releases the compiled module.
+	Close(ctx context.Context) error
+}
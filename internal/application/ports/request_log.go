@@ -0,0 +1,16 @@
+package ports
+
+import (
+	"context"
+
+	"webhookd/internal/domain/webhook"
+)
+
+// RequestLog keeps the most recent captured invocations per hook.
+type RequestLog interface {
+	Append(ctx context.Context, r webhook.Request) error
+	// List returns captured requests for a hook, oldest first; limit <= 0 returns all retained.
+	List(ctx context.Context, id webhook.ID, limit int) ([]webhook.Request, error)
+	// Forget drops everything captured for a hook (e.g. after it was deleted).
+	Forget(ctx context.Context, id webhook.ID) error
+}
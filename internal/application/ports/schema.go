@@ -0,0 +1,17 @@
+package ports
+
+import "webhookd/internal/domain/webhook"
+
+// SchemaCompiler compiles the JSON Schemas of hooks' request validation.
+type SchemaCompiler interface {
+	// Compile fails when schema isn't a valid JSON Schema, or refers to
+	// other documents.
+	Compile(schema []byte) (Schema, error)
+}
+
+// Schema is a compiled JSON Schema; safe for concurrent use.
+type Schema interface {
+	// Validate checks a JSON document; documents that aren't JSON are
+	// invalid.
+	Validate(doc []byte) webhook.ValidationResult
+}
@@ -0,0 +1,22 @@
+package ports
+
+import (
+	"context"
+
+	"webhookd/internal/domain/webhook"
+)
+
+// ScriptEngine compiles the scripts of hooks' responses.
+type ScriptEngine interface {
+	// Compile fails with a description of the problem when source isn't a
+	// valid script.
+	Compile(source string) (Script, error)
+}
+
+// Script is a compiled script; safe for concurrent use. Scripts run
+// sandboxed: they can't reach the network or files, and their run time and
+// the work they do are bounded.
+type Script interface {
+	// Run computes the response to req; errors say why the script failed.
+	Run(ctx context.Context, req webhook.TemplateData) (webhook.ScriptResult, error)
+}
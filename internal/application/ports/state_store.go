@@ -0,0 +1,22 @@
+package ports
+
+import (
+	"context"
+
+	"webhookd/internal/domain/webhook"
+)
+
+// StateStore keeps the key-value stores templates of hooks read and write
+// (see webhook.Store), one per hook.
+type StateStore interface {
+	Get(ctx context.Context, id webhook.ID, key string) (string, bool, error)
+	// Put fails with webhook.ErrStoreFull when the value is over
+	// webhook.MaxStoreValue, or the store would grow past webhook.MaxStoreKeys
+	// or webhook.MaxStoreBytes.
+	Put(ctx context.Context, id webhook.ID, key, value string) error
+	Delete(ctx context.Context, id webhook.ID, key string) error
+	// List returns a copy of a hook's store; empty when it has none.
+	List(ctx context.Context, id webhook.ID) (map[string]string, error)
+	// Reset empties a hook's store.
+	Reset(ctx context.Context, id webhook.ID) error
+}
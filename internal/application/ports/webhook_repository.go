@@ -8,9 +8,20 @@ import (
 )
 
 type WebhookRepository interface {
+	// Create fails with webhook.ErrIDExists when the ID is taken.
 	Create(ctx context.Context, h *webhook.Hook) error
 	Get(ctx context.Context, id webhook.ID) (*webhook.Hook, bool, error)
+	// Update replaces the stored hook with the same ID.
+	Update(ctx context.Context, h *webhook.Hook) (*webhook.Hook, bool, error)
+	// Delete removes the hook and returns what was stored.
+	Delete(ctx context.Context, id webhook.ID) (*webhook.Hook, bool, error)
 	Deactivate(ctx context.Context, id webhook.ID) (*webhook.Hook, bool, error)
 	Touch(ctx context.Context, id webhook.ID, now time.Time) (*webhook.Hook, bool, error)
 	List(ctx context.Context) (map[webhook.ID]*webhook.Hook, error)
 }
+
+// Pinger is implemented by repositories that can report whether their backing
+// store is reachable (used by the readiness check).
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
@@ -0,0 +1,23 @@
+package webhooks
+
+import "context"
+
+// Actor identifies who performed a management operation, for the audit log.
+type Actor struct {
+	Subject  string // token subject, "anonymous" without a token
+	SourceIP string
+}
+
+type actorKey struct{}
+
+func WithActor(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, a)
+}
+
+func actorFrom(ctx context.Context) Actor {
+	a, _ := ctx.Value(actorKey{}).(Actor)
+	if a.Subject == "" {
+		a.Subject = "anonymous"
+	}
+	return a
+}
@@ -0,0 +1,125 @@
+package webhooks
+
+import (
+	"container/list"
+	"sync"
+
+	"webhookd/internal/domain/webhook"
+)
+
+const (
+	// maxDeliveries bounds how many CloudEvents are remembered for proxies
+	// that dedupe (webhook.Proxy.Dedupe); the oldest are forgotten first.
+	maxDeliveries = 10_000
+	// maxDeliveryBytes bounds the size of the responses kept for them.
+	maxDeliveryBytes = 64 << 20
+)
+
+type deliveryKey struct {
+	proxy      webhook.ID
+	source, id string
+}
+
+// delivery is a CloudEvent a proxy forwarded, with the response it got
+// once there is one.
+type delivery struct {
+	key      deliveryKey
+	response *webhook.Response
+	size     int
+}
+
+// deliveries remembers the CloudEvents proxies forwarded.
+type deliveries struct {
+	mu    sync.Mutex
+	seen  map[deliveryKey]*list.Element
+	order list.List // of *delivery, oldest first
+	size  int       // bytes of the responses kept
+}
+
+// ClaimDelivery reports whether e is the first delivery of its event (by
+// source and id) to proxy, and remembers it if so. A delivery that fails to
+// be forwarded should be released, so that a retry is forwarded; one that
+// is forwarded should be completed with its response.
+func (s *Service) ClaimDelivery(proxy webhook.ID, e webhook.CloudEvent) (first bool) {
+	k := deliveryKey{proxy: proxy, source: e.Source, id: e.ID}
+	d := &s.deliveries
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.seen[k]; ok {
+		return false
+	}
+	if d.seen == nil {
+		d.seen = map[deliveryKey]*list.Element{}
+	}
+	for d.order.Len() >= maxDeliveries {
+		d.remove(d.order.Front())
+	}
+	d.seen[k] = d.order.PushBack(&delivery{key: k})
+	return true
+}
+
+// CompleteDelivery keeps r as the response to a delivery ClaimDelivery
+// remembered, for DeliveryResponse to answer repeats with.
+func (s *Service) CompleteDelivery(proxy webhook.ID, e webhook.CloudEvent, r webhook.Response) {
+	k := deliveryKey{proxy: proxy, source: e.Source, id: e.ID}
+	d := &s.deliveries
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	el, ok := d.seen[k]
+	if !ok {
+		return
+	}
+	r = r.Clone()
+	dl := el.Value.(*delivery)
+	d.size -= dl.size
+	dl.response, dl.size = &r, responseSize(r)
+	d.size += dl.size
+	for d.size > maxDeliveryBytes && d.order.Len() > 0 {
+		d.remove(d.order.Front())
+	}
+}
+
+// DeliveryResponse returns the response the first delivery of e to proxy
+// got; ok is false while it has none, or once the delivery is forgotten.
+func (s *Service) DeliveryResponse(proxy webhook.ID, e webhook.CloudEvent) (_ webhook.Response, ok bool) {
+	k := deliveryKey{proxy: proxy, source: e.Source, id: e.ID}
+	d := &s.deliveries
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	el, ok := d.seen[k]
+	if !ok || el.Value.(*delivery).response == nil {
+		return webhook.Response{}, false
+	}
+	return el.Value.(*delivery).response.Clone(), true
+}
+
+// ReleaseDelivery forgets a delivery ClaimDelivery remembered.
+func (s *Service) ReleaseDelivery(proxy webhook.ID, e webhook.CloudEvent) {
+	k := deliveryKey{proxy: proxy, source: e.Source, id: e.ID}
+	d := &s.deliveries
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if el, ok := d.seen[k]; ok {
+		d.remove(el)
+	}
+}
+
+func (d *deliveries) remove(el *list.Element) {
+	dl := d.order.Remove(el).(*delivery)
+	delete(d.seen, dl.key)
+	d.size -= dl.size
+}
+
+func responseSize(r webhook.Response) int {
+	n := len(r.Body) + len(r.BodyBase64)
+	for k, v := range r.Headers {
+		n += len(k) + len(v)
+	}
+	for k, vs := range r.HeaderValues {
+		n += len(k)
+		for _, v := range vs {
+			n += len(v)
+		}
+	}
+	return n
+}
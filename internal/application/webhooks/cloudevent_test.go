@@ -0,0 +1,67 @@
+package webhooks
+
+import (
+	"strconv"
+	"testing"
+
+	"webhookd/internal/domain/webhook"
+	"webhookd/internal/infrastructure/repository/memory"
+)
+
+func TestDeliveries(t *testing.T) {
+	s := NewService(memory.NewWebhooksRepo())
+	event := webhook.CloudEvent{ID: "1", Source: "/billing"}
+	ok := webhook.Response{Status: 200, Body: "done"}
+
+	if !s.ClaimDelivery("bus", event) {
+		t.Fatal("first delivery not claimed")
+	}
+	if s.ClaimDelivery("bus", event) {
+		t.Fatal("repeated delivery claimed")
+	}
+	if _, found := s.DeliveryResponse("bus", event); found {
+		t.Fatal("response before the first delivery completed")
+	}
+	s.CompleteDelivery("bus", event, ok)
+	if r, found := s.DeliveryResponse("bus", event); !found || r.Body != "done" || r.Status != 200 {
+		t.Fatalf("DeliveryResponse = %+v, %v; want the first response", r, found)
+	}
+
+	tests := []struct {
+		name  string
+		proxy webhook.ID
+		event webhook.CloudEvent
+		first bool
+	}{
+		{name: "same event", proxy: "bus", event: event},
+		{name: "same id from another source", proxy: "bus", event: webhook.CloudEvent{ID: "1", Source: "/orders"}, first: true},
+		{name: "another id", proxy: "bus", event: webhook.CloudEvent{ID: "2", Source: "/billing"}, first: true},
+		{name: "another proxy", proxy: "other", event: event, first: true},
+	}
+	for _, tt := range tests {
+		if got := s.ClaimDelivery(tt.proxy, tt.event); got != tt.first {
+			t.Errorf("%s: ClaimDelivery = %v, want %v", tt.name, got, tt.first)
+		}
+	}
+
+	// A released delivery is forwarded again.
+	failed := webhook.CloudEvent{ID: "3", Source: "/billing"}
+	s.ClaimDelivery("bus", failed)
+	s.ReleaseDelivery("bus", failed)
+	if !s.ClaimDelivery("bus", failed) {
+		t.Fatal("released delivery not claimed again")
+	}
+}
+
+func TestDeliveriesForgetOldest(t *testing.T) {
+	s := NewService(memory.NewWebhooksRepo())
+	for i := range maxDeliveries + 1 {
+		s.ClaimDelivery("bus", webhook.CloudEvent{ID: strconv.Itoa(i), Source: "/s"})
+	}
+	if !s.ClaimDelivery("bus", webhook.CloudEvent{ID: "0", Source: "/s"}) {
+		t.Fatal("oldest delivery still remembered past maxDeliveries")
+	}
+	if s.ClaimDelivery("bus", webhook.CloudEvent{ID: strconv.Itoa(maxDeliveries), Source: "/s"}) {
+		t.Fatal("newest delivery forgotten")
+	}
+}
@@ -0,0 +1,69 @@
+package webhooks
+
+import (
+	"sync"
+
+	"webhookd/internal/domain/webhook"
+)
+
+// feedBuffer is how many requests a subscriber may lag behind before further
+// requests are dropped for it; publishing never blocks an invocation.
+const feedBuffer = 64
+
+// feed fans captured requests out to live subscribers of a hook.
+type feed struct {
+	mu   sync.Mutex
+	subs map[webhook.ID]map[chan webhook.Request]struct{}
+}
+
+func newFeed() *feed {
+	return &feed{subs: map[webhook.ID]map[chan webhook.Request]struct{}{}}
+}
+
+func (f *feed) subscribe(id webhook.ID) (<-chan webhook.Request, func()) {
+	ch := make(chan webhook.Request, feedBuffer)
+
+	f.mu.Lock()
+	if f.subs[id] == nil {
+		f.subs[id] = map[chan webhook.Request]struct{}{}
+	}
+	f.subs[id][ch] = struct{}{}
+	f.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if _, ok := f.subs[id][ch]; !ok {
+				return
+			}
+			delete(f.subs[id], ch)
+			if len(f.subs[id]) == 0 {
+				delete(f.subs, id)
+			}
+			close(ch)
+		})
+	}
+}
+
+func (f *feed) publish(r webhook.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.subs[r.HookID] {
+		select {
+		case ch <- r.Clone():
+		default:
+		}
+	}
+}
+
+// closeHook ends all subscriptions of a hook (it was deleted).
+func (f *feed) closeHook(id webhook.ID) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.subs[id] {
+		close(ch)
+	}
+	delete(f.subs, id)
+}
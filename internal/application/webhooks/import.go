@@ -0,0 +1,117 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"webhookd/internal/domain/audit"
+	"webhookd/internal/domain/webhook"
+)
+
+// Outcomes of importing a hook.
+const (
+	ImportCreated   = "created"
+	ImportUpdated   = "updated"
+	ImportUnchanged = "unchanged"
+	// ImportExists means the ID is taken and replace was not requested.
+	ImportExists = "exists"
+	ImportFailed = "failed"
+)
+
+// ImportResult is the outcome of importing one hook; Err is set when it
+// failed.
+type ImportResult struct {
+	ID     webhook.ID
+	Status string
+	Err    error
+}
+
+// Import creates hooks from specs, e.g. generated from an API description.
+// Hooks whose ID is taken are left alone, unless replace is set: then their
+// method and responses are replaced and they are reactivated; managed hooks
+// are never replaced. With dryRun, nothing is written but the results say
+// what would happen. Each spec is applied on its own.
+func (s *Service) Import(ctx context.Context, specs []HookSpec, replace, dryRun bool) (_ []ImportResult, err error) {
+	ctx, span := tracer.Start(ctx, "webhooks.Service.Import")
+	defer func() { endSpan(span, err) }()
+	span.SetAttributes(attribute.Int("webhookd.import.hooks", len(specs)), attribute.Bool("webhookd.import.dry_run", dryRun))
+
+	if s.repo == nil {
+		return nil, errors.New("repo is nil")
+	}
+	out := make([]ImportResult, 0, len(specs))
+	for _, spec := range specs {
+		status, err := s.importHook(ctx, spec, replace, dryRun)
+		if err != nil {
+			status = ImportFailed
+		}
+		out = append(out, ImportResult{ID: spec.ID, Status: status, Err: err})
+	}
+	return out, nil
+}
+
+func (s *Service) importHook(ctx context.Context, spec HookSpec, replace, dryRun bool) (string, error) {
+	before, ok, err := s.repo.Get(ctx, spec.ID)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		h, err := webhook.New(spec.ID, spec.Method, spec.Body, spec.Headers, s.now())
+		if err != nil {
+			return "", err
+		}
+		if err := applyResponses(h, spec); err != nil {
+			return "", err
+		}
+		if dryRun {
+			return ImportCreated, nil
+		}
+		if err := s.repo.Create(ctx, h); err != nil {
+			if errors.Is(err, webhook.ErrIDExists) && !replace {
+				return ImportExists, nil
+			}
+			return "", err
+		}
+		return ImportCreated, s.record(ctx, audit.ActionCreate, h.ID, nil, h)
+	}
+
+	if !replace {
+		return ImportExists, nil
+	}
+	if before.Managed {
+		return "", webhook.ErrManaged
+	}
+	h := before.Clone()
+	if err := h.SetMethod(spec.Method); err != nil {
+		return "", err
+	}
+	if err := applyResponses(h, spec); err != nil {
+		return "", err
+	}
+	h.Active = true
+	if h.Method == before.Method && h.Response.Equal(before.Response) &&
+		webhook.EqualResponses(h.Responses, before.Responses) && before.Active {
+		return ImportUnchanged, nil
+	}
+	if dryRun {
+		return ImportUpdated, nil
+	}
+	after, ok, err := s.repo.Update(ctx, h)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", errors.New("deleted while importing")
+	}
+	return ImportUpdated, s.record(ctx, audit.ActionUpdate, h.ID, before, after)
+}
+
+// applyResponses sets the responses of spec on h.
+func applyResponses(h *webhook.Hook, spec HookSpec) error {
+	if err := h.SetResponse(spec.Response); err != nil {
+		return err
+	}
+	return h.SetResponses(spec.Responses)
+}
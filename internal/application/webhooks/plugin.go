@@ -0,0 +1,265 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"webhookd/internal/application/ports"
+	"webhookd/internal/domain/plugin"
+	"webhookd/internal/domain/webhook"
+)
+
+// WithPlugins enables plugins (webhook.Response.Plugin), kept in repo and
+// run by rt. Without it, hooks with plugins are refused.
+func WithPlugins(repo ports.PluginRepository, rt ports.PluginRuntime) Option {
+	return func(s *Service) { s.plugins, s.pluginRuntime = repo, rt }
+}
+
+var errNoPlugins = errors.New("plugins are not available")
+
+// moduleCache keeps the compiled module of each plugin, so modules are
+// compiled when uploaded rather than on every request.
+type moduleCache struct {
+	mu sync.Mutex
+	m  map[string]*cachedModule
+}
+
+// cachedModule counts the runs using a module, so that a replaced module is
+// closed only once the last of them is done.
+type cachedModule struct {
+	ports.PluginModule
+	runs    int
+	evicted bool
+}
+
+// PutPlugin compiles module and stores it as plugin name, replacing the
+// plugin of that name; hooks using it run the new module from then on.
+// created is false when a plugin was replaced.
+func (s *Service) PutPlugin(ctx context.Context, name string, module []byte) (_ plugin.Plugin, created bool, err error) {
+	ctx, span := tracer.Start(ctx, "webhooks.Service.PutPlugin")
+	defer func() { endSpan(span, err) }()
+	span.SetAttributes(attribute.String("webhookd.plugin.name", name), attribute.Int("webhookd.plugin.size", len(module)))
+
+	if s.plugins == nil {
+		return plugin.Plugin{}, false, errNoPlugins
+	}
+	if err := plugin.ValidateName(name); err != nil {
+		return plugin.Plugin{}, false, err
+	}
+	if len(module) > plugin.MaxModuleSize {
+		return plugin.Plugin{}, false, fmt.Errorf("%w: larger than %d bytes", plugin.ErrInvalidModule, plugin.MaxModuleSize)
+	}
+	compiled, err := s.pluginRuntime.Compile(ctx, module)
+	if err != nil {
+		return plugin.Plugin{}, false, fmt.Errorf("%w: %v", plugin.ErrInvalidModule, err)
+	}
+	sum := sha256.Sum256(module)
+	p := plugin.Plugin{Name: name, Size: len(module), SHA256: hex.EncodeToString(sum[:]), UploadedAt: s.now()}
+
+	_, _, exists, err := s.plugins.Get(ctx, name)
+	if err != nil {
+		_ = compiled.Close(ctx)
+		return plugin.Plugin{}, false, err
+	}
+	if err := s.plugins.Put(ctx, p, module); err != nil {
+		_ = compiled.Close(ctx)
+		return plugin.Plugin{}, false, err
+	}
+	s.cacheModule(ctx, name, compiled)
+	return p, !exists, nil
+}
+
+// Plugins lists the plugins by name.
+func (s *Service) Plugins(ctx context.Context) (_ []plugin.Plugin, err error) {
+	ctx, span := tracer.Start(ctx, "webhooks.Service.Plugins")
+	defer func() { endSpan(span, err) }()
+
+	if s.plugins == nil {
+		return []plugin.Plugin{}, nil
+	}
+	return s.plugins.List(ctx)
+}
+
+// Plugin returns plugin name; ok is false when there is none.
+func (s *Service) Plugin(ctx context.Context, name string) (_ plugin.Plugin, ok bool, err error) {
+	ctx, span := tracer.Start(ctx, "webhooks.Service.Plugin")
+	defer func() { endSpan(span, err) }()
+	span.SetAttributes(attribute.String("webhookd.plugin.name", name))
+
+	if s.plugins == nil {
+		return plugin.Plugin{}, false, nil
+	}
+	p, _, ok, err := s.plugins.Get(ctx, name)
+	return p, ok, err
+}
+
+// DeletePlugin removes plugin name; ok is false when there is none. Plugins
+// hooks use are kept, with plugin.ErrInUse naming the hooks.
+func (s *Service) DeletePlugin(ctx context.Context, name string) (ok bool, err error) {
+	ctx, span := tracer.Start(ctx, "webhooks.Service.DeletePlugin")
+	defer func() { endSpan(span, err) }()
+	span.SetAttributes(attribute.String("webhookd.plugin.name", name))
+
+	if s.plugins == nil {
+		return false, nil
+	}
+	hooks, err := s.repo.List(ctx)
+	if err != nil {
+		return false, err
+	}
+	var users []string
+	for id, h := range hooks {
+		if usesPlugin(h, name) {
+			users = append(users, string(id))
+		}
+	}
+	if len(users) > 0 {
+		slices.Sort(users)
+		return true, fmt.Errorf("%w by %s", plugin.ErrInUse, strings.Join(users, ", "))
+	}
+	if ok, err = s.plugins.Delete(ctx, name); err != nil || !ok {
+		return ok, err
+	}
+	s.cacheModule(ctx, name, nil)
+	return true, nil
+}
+
+func usesPlugin(h *webhook.Hook, name string) bool {
+	if h.Response.Plugin == name {
+		return true
+	}
+	for _, r := range h.Responses {
+		if r.Plugin == name {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheModule replaces the cached module of plugin name; nil removes it. The
+// module it replaces is closed once no run uses it.
+func (s *Service) cacheModule(ctx context.Context, name string, m ports.PluginModule) {
+	s.modules.mu.Lock()
+	defer s.modules.mu.Unlock()
+	if old, ok := s.modules.m[name]; ok {
+		old.evicted = true
+		if old.runs == 0 {
+			_ = old.Close(ctx)
+		}
+		delete(s.modules.m, name)
+	}
+	if m == nil {
+		return
+	}
+	if s.modules.m == nil {
+		s.modules.m = map[string]*cachedModule{}
+	}
+	s.modules.m[name] = &cachedModule{PluginModule: m}
+}
+
+// acquireModule returns the compiled module of plugin name for a run,
+// compiling it if it isn't cached. Pass it to releaseModule when the run is
+// done.
+func (s *Service) acquireModule(ctx context.Context, name string) (*cachedModule, error) {
+	s.modules.mu.Lock()
+	defer s.modules.mu.Unlock()
+	if m, ok := s.modules.m[name]; ok {
+		m.runs++
+		return m, nil
+	}
+	_, module, ok, err := s.plugins.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("plugin %q not found", name)
+	}
+	compiled, err := s.pluginRuntime.Compile(ctx, module)
+	if err != nil {
+		return nil, err
+	}
+	if s.modules.m == nil {
+		s.modules.m = map[string]*cachedModule{}
+	}
+	m := &cachedModule{PluginModule: compiled, runs: 1}
+	s.modules.m[name] = m
+	return m, nil
+}
+
+// releaseModule ends a run of m, closing m if it was replaced meanwhile and
+// this was its last run.
+func (s *Service) releaseModule(ctx context.Context, m *cachedModule) {
+	s.modules.mu.Lock()
+	defer s.modules.mu.Unlock()
+	if m.runs--; m.runs == 0 && m.evicted {
+		_ = m.Close(ctx)
+	}
+}
+
+// checkPlugins refuses responses of h naming plugins that aren't there.
+func (s *Service) checkPlugins(ctx context.Context, h *webhook.Hook) error {
+	check := func(r webhook.Response) error {
+		if r.Plugin == "" {
+			return nil
+		}
+		if s.plugins == nil {
+			return fmt.Errorf("%w: %v", webhook.ErrInvalidPlugin, errNoPlugins)
+		}
+		_, _, ok, err := s.plugins.Get(ctx, r.Plugin)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%w: plugin %q not found", webhook.ErrInvalidPlugin, r.Plugin)
+		}
+		return nil
+	}
+	if err := check(h.Response); err != nil {
+		return err
+	}
+	for m, r := range h.Responses {
+		if err := check(r); err != nil {
+			return fmt.Errorf("%s: %w", m, err)
+		}
+	}
+	return nil
+}
+
+// RunPlugin runs the plugin of r, a response of h, for the request in data
+// and returns the response it computed; responses without a plugin are
+// returned as they are. Failures wrap webhook.ErrPluginFailed.
+func (s *Service) RunPlugin(ctx context.Context, h *webhook.Hook, r webhook.Response, data webhook.TemplateData) (_ webhook.Response, err error) {
+	if r.Plugin == "" {
+		return r, nil
+	}
+	ctx, span := startHookSpan(ctx, "webhooks.Service.RunPlugin", h.ID)
+	defer func() { endSpan(span, err) }()
+	span.SetAttributes(attribute.String("webhookd.plugin.name", r.Plugin))
+
+	if s.plugins == nil {
+		return webhook.Response{}, fmt.Errorf("%w: %w", webhook.ErrPluginFailed, errNoPlugins)
+	}
+	module, err := s.acquireModule(ctx, r.Plugin)
+	if err != nil {
+		return webhook.Response{}, fmt.Errorf("%w: %w", webhook.ErrPluginFailed, err)
+	}
+	// Closing uses a context of its own: ctx may be done by then.
+	defer s.releaseModule(context.WithoutCancel(ctx), module)
+	res, err := module.Run(ctx, data)
+	if err != nil {
+		return webhook.Response{}, fmt.Errorf("%w: %w", webhook.ErrPluginFailed, err)
+	}
+	out, err := r.ApplyScript(res)
+	if err != nil {
+		return webhook.Response{}, fmt.Errorf("%w: %w", webhook.ErrPluginFailed, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,117 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"webhookd/internal/domain/audit"
+	"webhookd/internal/domain/webhook"
+)
+
+// RecorderActor is the audit actor of hooks saved by recording proxies.
+const RecorderActor = "recorder"
+
+// ErrNotRecorded is returned by Record when the target hook exists but wasn't
+// recorded by the proxy, so it is left alone.
+var ErrNotRecorded = errors.New("hook exists and was not recorded by this proxy")
+
+// ProxyFor finds the active recording proxy in charge of id: the closest of
+// id's parents with proxy settings in record mode. sub is the path below it
+// ("v1/charges" for "stripe/v1/charges" under "stripe"). A proxy's own ID is
+// not proxied; requests to it are served as usual.
+func (s *Service) ProxyFor(ctx context.Context, id webhook.ID) (_ *webhook.Hook, sub string, ok bool, err error) {
+	ctx, span := startHookSpan(ctx, "webhooks.Service.ProxyFor", id)
+	defer func() { endSpan(span, err) }()
+
+	for _, parent := range id.Parents() {
+		h, found, err := s.repo.Get(ctx, parent)
+		if err != nil {
+			return nil, "", false, err
+		}
+		if !found || h.Proxy == nil {
+			continue
+		}
+		if !h.Active || !h.Proxy.Recording() {
+			return nil, "", false, nil
+		}
+		return h, string(id[len(parent)+1:]), true, nil
+	}
+	return nil, "", false, nil
+}
+
+// Record saves r as the response of the hook at sub below proxy to method
+// (upper case): the hook is created if missing, or updated if proxy recorded
+// it before. Other hooks are left alone (ErrNotRecorded).
+func (s *Service) Record(ctx context.Context, proxy webhook.ID, sub, method string, r webhook.Response) (err error) {
+	id := proxy + "/" + webhook.ID(sub)
+	ctx, span := startHookSpan(ctx, "webhooks.Service.Record", id)
+	defer func() { endSpan(span, err) }()
+	span.SetAttributes(attribute.String("webhookd.proxy.id", string(proxy)))
+	ctx = WithActor(ctx, Actor{Subject: RecorderActor})
+
+	// A concurrent recording may create the hook first; then update it.
+	for range 2 {
+		before, ok, err := s.repo.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			err = s.createRecorded(ctx, proxy, id, method, r)
+			if errors.Is(err, webhook.ErrIDExists) {
+				continue
+			}
+			return err
+		}
+		return s.updateRecorded(ctx, before, proxy, method, r)
+	}
+	return fmt.Errorf("record %s: %w", id, webhook.ErrIDExists)
+}
+
+func (s *Service) createRecorded(ctx context.Context, proxy, id webhook.ID, method string, r webhook.Response) error {
+	h, err := webhook.New(id, method, "", nil, s.now())
+	if err != nil {
+		return err
+	}
+	if err := h.SetResponse(r); err != nil {
+		return err
+	}
+	h.RecordedFrom = proxy
+	if err := s.repo.Create(ctx, h); err != nil {
+		return err
+	}
+	return s.record(ctx, audit.ActionCreate, id, nil, h)
+}
+
+func (s *Service) updateRecorded(ctx context.Context, before *webhook.Hook, proxy webhook.ID, method string, r webhook.Response) error {
+	if before.RecordedFrom != proxy || before.Managed {
+		return ErrNotRecorded
+	}
+	h := before.Clone()
+	if current, ok := h.ResponseFor(method); ok && current.Equal(r) && h.Active {
+		return nil
+	}
+	if h.Method == method {
+		if err := h.SetResponse(r); err != nil {
+			return err
+		}
+	} else {
+		responses := maps.Clone(h.Responses)
+		if responses == nil {
+			responses = map[string]webhook.Response{}
+		}
+		responses[method] = r
+		if err := h.SetResponses(responses); err != nil {
+			return err
+		}
+	}
+	h.Active = true
+	after, ok, err := s.repo.Update(ctx, h)
+	if err != nil || !ok {
+		return err
+	}
+	return s.record(ctx, audit.ActionUpdate, h.ID, before, after)
+}
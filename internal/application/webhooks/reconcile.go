@@ -0,0 +1,180 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"webhookd/internal/domain/audit"
+	"webhookd/internal/domain/webhook"
+)
+
+// HookSpec is the desired state of a managed hook.
+type HookSpec struct {
+	ID     webhook.ID
+	Method string
+	webhook.Response
+	Responses  map[string]webhook.Response
+	Chaos      *webhook.Chaos
+	Proxy      *webhook.Proxy
+	Validation *webhook.RequestValidation
+}
+
+// ReconcileResult lists what Reconcile changed.
+type ReconcileResult struct {
+	Created     []webhook.ID
+	Updated     []webhook.ID
+	Deactivated []webhook.ID
+}
+
+// Changed reports whether anything was created, updated or deactivated.
+func (r ReconcileResult) Changed() bool {
+	return len(r.Created)+len(r.Updated)+len(r.Deactivated) > 0
+}
+
+// ConfigActor is the audit actor of changes made by Reconcile.
+const ConfigActor = "config"
+
+// Reconcile makes the managed hooks match specs: missing hooks are created,
+// ones that differ are updated (and reactivated), and managed hooks no longer
+// listed are deactivated. Hooks are marked managed, so the API refuses to
+// change them; a hook created through the API with a listed ID is taken
+// over. Counters and captured requests are kept. Each spec is applied on
+// its own; the errors of the ones that failed are returned together.
+func (s *Service) Reconcile(ctx context.Context, specs []HookSpec) (_ ReconcileResult, err error) {
+	ctx, span := tracer.Start(ctx, "webhooks.Service.Reconcile")
+	defer func() { endSpan(span, err) }()
+
+	var res ReconcileResult
+	if s.repo == nil {
+		return res, errors.New("repo is nil")
+	}
+	ctx = WithActor(ctx, Actor{Subject: ConfigActor})
+
+	existing, err := s.repo.List(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	var errs []error
+	wanted := make(map[webhook.ID]bool, len(specs))
+	for _, spec := range specs {
+		wanted[spec.ID] = true
+		before := existing[spec.ID]
+		if before == nil {
+			if err := s.createManaged(ctx, spec); err != nil {
+				errs = append(errs, fmt.Errorf("hook %s: %w", spec.ID, err))
+				continue
+			}
+			res.Created = append(res.Created, spec.ID)
+			continue
+		}
+		changed, err := s.updateManaged(ctx, before, spec)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("hook %s: %w", spec.ID, err))
+			continue
+		}
+		if changed {
+			res.Updated = append(res.Updated, spec.ID)
+		}
+	}
+
+	// Map order is random; keep results (and audit entries) stable.
+	for _, id := range slices.Sorted(maps.Keys(existing)) {
+		h := existing[id]
+		if !h.Managed || !h.Active || wanted[id] {
+			continue
+		}
+		after, ok, err := s.repo.Deactivate(ctx, id)
+		if err == nil && ok {
+			err = s.record(ctx, audit.ActionDeactivate, id, h, after)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("hook %s: %w", id, err))
+			continue
+		}
+		s.scripts.forget(id)
+		res.Deactivated = append(res.Deactivated, id)
+	}
+	return res, errors.Join(errs...)
+}
+
+func (s *Service) createManaged(ctx context.Context, spec HookSpec) error {
+	h, err := webhook.New(spec.ID, spec.Method, spec.Body, spec.Headers, s.now())
+	if err != nil {
+		return err
+	}
+	if err := h.SetResponse(spec.Response); err != nil {
+		return err
+	}
+	if err := h.SetResponses(spec.Responses); err != nil {
+		return err
+	}
+	if err := h.SetChaos(spec.Chaos); err != nil {
+		return err
+	}
+	if err := h.SetProxy(spec.Proxy); err != nil {
+		return err
+	}
+	if err := s.setValidation(h, spec.Validation); err != nil {
+		return err
+	}
+	if err := s.compileScripts(h); err != nil {
+		return err
+	}
+	if err := s.checkPlugins(ctx, h); err != nil {
+		return err
+	}
+	h.Managed = true
+	if err := s.repo.Create(ctx, h); err != nil {
+		return err
+	}
+	return s.record(ctx, audit.ActionCreate, h.ID, nil, h)
+}
+
+func (s *Service) updateManaged(ctx context.Context, before *webhook.Hook, spec HookSpec) (bool, error) {
+	h := before.Clone()
+	if err := h.SetMethod(spec.Method); err != nil {
+		return false, err
+	}
+	if err := h.SetResponse(spec.Response); err != nil {
+		return false, err
+	}
+	if err := h.SetResponses(spec.Responses); err != nil {
+		return false, err
+	}
+	if err := h.SetChaos(spec.Chaos); err != nil {
+		return false, err
+	}
+	if err := h.SetProxy(spec.Proxy); err != nil {
+		return false, err
+	}
+	if err := s.setValidation(h, spec.Validation); err != nil {
+		return false, err
+	}
+	if err := s.compileScripts(h); err != nil {
+		return false, err
+	}
+	if err := s.checkPlugins(ctx, h); err != nil {
+		return false, err
+	}
+	h.Managed = true
+	h.Active = true
+	if h.Method == before.Method && h.Response.Equal(before.Response) &&
+		webhook.EqualResponses(h.Responses, before.Responses) && webhook.EqualChaos(h.Chaos, before.Chaos) &&
+		webhook.EqualProxy(h.Proxy, before.Proxy) && webhook.EqualValidation(h.Validation, before.Validation) &&
+		before.Managed && before.Active {
+		return false, nil
+	}
+	after, ok, err := s.repo.Update(ctx, h)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		// Deleted since we listed; nothing to update anymore.
+		return false, nil
+	}
+	return true, s.record(ctx, audit.ActionUpdate, h.ID, before, after)
+}
@@ -0,0 +1,110 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"webhookd/internal/domain/audit"
+	"webhookd/internal/domain/webhook"
+	"webhookd/internal/infrastructure/repository/memory"
+)
+
+func spec(id webhook.ID, body string) HookSpec {
+	return HookSpec{ID: id, Method: "GET", Response: webhook.Response{Body: body}}
+}
+
+func TestReconcile(t *testing.T) {
+	ctx := context.Background()
+	auditLog := memory.NewAuditRepo()
+	s := NewService(memory.NewWebhooksRepo(), WithAuditLog(auditLog))
+	if _, err := s.Create(ctx, CreateParams{ID: "taken", Method: "GET", Body: "api"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	steps := []struct {
+		name  string
+		specs []HookSpec
+		want  ReconcileResult
+	}{
+		{
+			name:  "create and take over",
+			specs: []HookSpec{spec("a", "a1"), spec("b", "b1"), spec("taken", "config")},
+			want:  ReconcileResult{Created: []webhook.ID{"a", "b"}, Updated: []webhook.ID{"taken"}},
+		},
+		{
+			name:  "unchanged",
+			specs: []HookSpec{spec("a", "a1"), spec("b", "b1"), spec("taken", "config")},
+		},
+		{
+			name:  "update and deactivate",
+			specs: []HookSpec{spec("a", "a2"), spec("taken", "config")},
+			want:  ReconcileResult{Updated: []webhook.ID{"a"}, Deactivated: []webhook.ID{"b"}},
+		},
+		{
+			name:  "deactivated stay deactivated",
+			specs: []HookSpec{spec("a", "a2"), spec("taken", "config")},
+		},
+		{
+			name:  "reactivate",
+			specs: []HookSpec{spec("a", "a2"), spec("b", "b1"), spec("taken", "config")},
+			want:  ReconcileResult{Updated: []webhook.ID{"b"}},
+		},
+	}
+	for _, step := range steps {
+		res, err := s.Reconcile(ctx, step.specs)
+		if err != nil {
+			t.Fatalf("%s: Reconcile: %v", step.name, err)
+		}
+		if !slices.Equal(res.Created, step.want.Created) || !slices.Equal(res.Updated, step.want.Updated) || !slices.Equal(res.Deactivated, step.want.Deactivated) {
+			t.Fatalf("%s: got %+v, want %+v", step.name, res, step.want)
+		}
+		if res.Changed() != (len(step.want.Created)+len(step.want.Updated)+len(step.want.Deactivated) > 0) {
+			t.Fatalf("%s: Changed() = %v", step.name, res.Changed())
+		}
+		for _, sp := range step.specs {
+			h, ok, err := s.Get(ctx, sp.ID)
+			if err != nil || !ok {
+				t.Fatalf("%s: Get %s: %v, %v", step.name, sp.ID, ok, err)
+			}
+			if h.Response.Body != sp.Body || !h.Managed || !h.Active {
+				t.Fatalf("%s: hook %s: body %q, managed %v, active %v", step.name, sp.ID, h.Response.Body, h.Managed, h.Active)
+			}
+		}
+	}
+
+	body := "api"
+	if _, _, err := s.Update(ctx, "a", UpdateParams{Body: &body}); !errors.Is(err, webhook.ErrManaged) {
+		t.Fatalf("Update of a managed hook: got %v, want ErrManaged", err)
+	}
+
+	entries, err := auditLog.Query(ctx, audit.Filter{Actor: ConfigActor})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, string(e.Action)+" "+string(e.HookID))
+	}
+	want := []string{"create a", "create b", "update taken", "update a", "deactivate b", "update b"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("audit log: got %v, want %v", got, want)
+	}
+}
+
+func TestReconcileReportsFailedSpecs(t *testing.T) {
+	ctx := context.Background()
+	s := NewService(memory.NewWebhooksRepo())
+
+	res, err := s.Reconcile(ctx, []HookSpec{spec("ok", "x"), {ID: "bad", Method: "BREW"}})
+	if err == nil {
+		t.Fatal("Reconcile: want an error for the invalid spec")
+	}
+	if !slices.Equal(res.Created, []webhook.ID{"ok"}) {
+		t.Fatalf("created %v; want the valid spec applied", res.Created)
+	}
+	if _, ok, _ := s.Get(ctx, "bad"); ok {
+		t.Fatal("invalid spec was created")
+	}
+}
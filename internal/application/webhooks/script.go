@@ -0,0 +1,110 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"webhookd/internal/application/ports"
+	"webhookd/internal/domain/webhook"
+)
+
+// WithScriptEngine enables scripted responses (webhook.Response.Script).
+// Without it, hooks with scripts are refused.
+func WithScriptEngine(e ports.ScriptEngine) Option {
+	return func(s *Service) { s.engine = e }
+}
+
+// maxCachedScripts bounds the number of hooks in the script cache; it is
+// emptied when full.
+const maxCachedScripts = 1000
+
+// scriptCache keeps compiled scripts by hook and source, so they are compiled
+// once rather than on every request, and dropped with the hook.
+type scriptCache struct {
+	mu sync.Mutex
+	m  map[webhook.ID]map[string]ports.Script
+}
+
+// compileScripts compiles the scripts of h's responses, so that invalid ones
+// are refused when the hook is saved. The scripts h no longer uses are
+// dropped from the cache.
+func (s *Service) compileScripts(h *webhook.Hook) error {
+	keep := map[string]bool{}
+	if _, err := s.script(h.ID, h.Response.Script); err != nil {
+		return err
+	}
+	keep[h.Response.Script] = true
+	for m, r := range h.Responses {
+		if _, err := s.script(h.ID, r.Script); err != nil {
+			return fmt.Errorf("%s: %w", m, err)
+		}
+		keep[r.Script] = true
+	}
+	s.scripts.mu.Lock()
+	defer s.scripts.mu.Unlock()
+	for source := range s.scripts.m[h.ID] {
+		if !keep[source] {
+			delete(s.scripts.m[h.ID], source)
+		}
+	}
+	return nil
+}
+
+// script returns the compiled script of source, one of id's; nil for "".
+func (s *Service) script(id webhook.ID, source string) (ports.Script, error) {
+	if source == "" {
+		return nil, nil
+	}
+	if s.engine == nil {
+		return nil, fmt.Errorf("%w: scripts are not available", webhook.ErrInvalidScript)
+	}
+	s.scripts.mu.Lock()
+	defer s.scripts.mu.Unlock()
+	if c, ok := s.scripts.m[id][source]; ok {
+		return c, nil
+	}
+	compiled, err := s.engine.Compile(source)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", webhook.ErrInvalidScript, err)
+	}
+	if s.scripts.m == nil || (s.scripts.m[id] == nil && len(s.scripts.m) >= maxCachedScripts) {
+		s.scripts.m = map[webhook.ID]map[string]ports.Script{}
+	}
+	if s.scripts.m[id] == nil {
+		s.scripts.m[id] = map[string]ports.Script{}
+	}
+	s.scripts.m[id][source] = compiled
+	return compiled, nil
+}
+
+func (c *scriptCache) forget(id webhook.ID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.m, id)
+}
+
+// RunScript runs the script of r, a response of h, for the request in data
+// and returns the response it computed; responses without a script are
+// returned as they are. Failures wrap webhook.ErrScriptFailed.
+func (s *Service) RunScript(ctx context.Context, h *webhook.Hook, r webhook.Response, data webhook.TemplateData) (_ webhook.Response, err error) {
+	if r.Script == "" {
+		return r, nil
+	}
+	ctx, span := startHookSpan(ctx, "webhooks.Service.RunScript", h.ID)
+	defer func() { endSpan(span, err) }()
+
+	script, err := s.script(h.ID, r.Script)
+	if err != nil {
+		return webhook.Response{}, fmt.Errorf("%w: %w", webhook.ErrScriptFailed, err)
+	}
+	res, err := script.Run(ctx, data)
+	if err != nil {
+		return webhook.Response{}, fmt.Errorf("%w: %w", webhook.ErrScriptFailed, err)
+	}
+	out, err := r.ApplyScript(res)
+	if err != nil {
+		return webhook.Response{}, fmt.Errorf("%w: %w", webhook.ErrScriptFailed, err)
+	}
+	return out, nil
+}
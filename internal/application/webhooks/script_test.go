@@ -0,0 +1,60 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+
+	"webhookd/internal/application/ports"
+	"webhookd/internal/domain/webhook"
+	"webhookd/internal/infrastructure/repository/memory"
+)
+
+type fakeEngine struct{}
+
+func (fakeEngine) Compile(string) (ports.Script, error) { return fakeScript{}, nil }
+
+type fakeScript struct{}
+
+func (fakeScript) Run(context.Context, webhook.TemplateData) (webhook.ScriptResult, error) {
+	return webhook.ScriptResult{}, nil
+}
+
+func TestScriptCacheDropsGoneHooks(t *testing.T) {
+	ctx := context.Background()
+	s := NewService(memory.NewWebhooksRepo(), WithScriptEngine(fakeEngine{}))
+	cached := func(id webhook.ID) int {
+		s.scripts.mu.Lock()
+		defer s.scripts.mu.Unlock()
+		return len(s.scripts.m[id])
+	}
+
+	if _, err := s.Create(ctx, CreateParams{ID: "api", Method: "GET", Script: "1"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	source := "2"
+	if _, _, err := s.Update(ctx, "api", UpdateParams{Script: &source}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if n := cached("api"); n != 1 {
+		t.Fatalf("after Update: %d scripts cached, want only the current one", n)
+	}
+	if _, _, err := s.Delete(ctx, "api"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if n := cached("api"); n != 0 {
+		t.Fatalf("after Delete: %d scripts cached, want none", n)
+	}
+
+	if _, err := s.Reconcile(ctx, []HookSpec{{ID: "cfg", Method: "GET", Response: webhook.Response{Script: "3"}}}); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if n := cached("cfg"); n != 1 {
+		t.Fatalf("after Reconcile: %d scripts cached, want 1", n)
+	}
+	if _, err := s.Reconcile(ctx, nil); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if n := cached("cfg"); n != 0 {
+		t.Fatalf("after deactivation: %d scripts cached, want none", n)
+	}
+}
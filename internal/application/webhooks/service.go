@@ -3,59 +3,428 @@ package webhooks
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"webhookd/internal/application/ports"
+	"webhookd/internal/domain/audit"
 	"webhookd/internal/domain/webhook"
 )
 
+var tracer = otel.Tracer("webhookd/internal/application/webhooks")
+
 type Service struct {
-	repo ports.WebhookRepository
-	now  func() time.Time
+	repo     ports.WebhookRepository
+	audit    ports.AuditRepository
+	requests ports.RequestLog
+	compiler ports.SchemaCompiler
+	schemas  schemaCache
+	engine   ports.ScriptEngine
+	scripts  scriptCache
+	// plugins and pluginRuntime are set together (WithPlugins).
+	plugins       ports.PluginRepository
+	pluginRuntime ports.PluginRuntime
+	modules       moduleCache
+	deliveries    deliveries
+	state         ports.StateStore
+	feed          *feed
+	now           func() time.Time
+}
+
+type Option func(*Service)
+
+// WithAuditLog records every management operation (create, update,
+// deactivate, delete) in the given repository.
+func WithAuditLog(repo ports.AuditRepository) Option {
+	return func(s *Service) { s.audit = repo }
 }
 
-func NewService(repo ports.WebhookRepository) *Service {
-	return &Service{
+// WithRequestLog captures hook invocations so they can be listed later.
+func WithRequestLog(log ports.RequestLog) Option {
+	return func(s *Service) { s.requests = log }
+}
+
+func NewService(repo ports.WebhookRepository, opts ...Option) *Service {
+	s := &Service{
 		repo: repo,
+		feed: newFeed(),
 		now:  func() time.Time { return time.Now().UTC() },
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 type CreateParams struct {
-	Method  string
-	Body    string
-	Headers map[string]string
+	// ID is a custom ID (see webhook.ID); empty generates a UUID.
+	ID     webhook.ID
+	Method string
+	// Body, BodyBase64 and BodyFile are alternatives; set at most one.
+	Body        string
+	BodyBase64  []byte
+	BodyFile    string
+	ContentType string
+	Headers     map[string]string
+	Status      int // 0 means 200
+	// Template renders Body and the header values (see webhook.Response).
+	Template bool
+	// Script or Plugin computes the response; the other fields are its
+	// defaults.
+	Script string
+	Plugin string
+	// Responses adds methods with their own response (see webhook.Hook).
+	Responses  map[string]webhook.Response
+	Chaos      *webhook.Chaos
+	Proxy      *webhook.Proxy
+	Validation *webhook.RequestValidation
 }
 
-func (s *Service) Create(ctx context.Context, p CreateParams) (*webhook.Hook, error) {
+// UpdateParams holds the fields to change; nil fields are left as they are.
+type UpdateParams struct {
+	Method *string
+	// Setting one of Body, BodyBase64 and BodyFile clears the other two.
+	Body        *string
+	BodyBase64  []byte
+	BodyFile    *string
+	ContentType *string
+	Headers     map[string]string
+	Status      *int
+	Template    *bool
+	Script      *string
+	Plugin      *string
+	// Responses replaces the per-method responses; an empty map clears them.
+	Responses map[string]webhook.Response
+	// Chaos, Proxy and Validation replace those settings; zero settings
+	// remove them.
+	Chaos      *webhook.Chaos
+	Proxy      *webhook.Proxy
+	Validation *webhook.RequestValidation
+}
+
+func (s *Service) Create(ctx context.Context, p CreateParams) (_ *webhook.Hook, err error) {
+	ctx, span := tracer.Start(ctx, "webhooks.Service.Create")
+	defer func() { endSpan(span, err) }()
+
 	if s.repo == nil {
 		return nil, errors.New("repo is nil")
 	}
-	id := webhook.ID(uuid.NewString())
+	id := p.ID
+	if id == "" {
+		id = webhook.ID(uuid.NewString())
+	}
+	span.SetAttributes(attribute.String("webhookd.hook.id", string(id)))
 	h, err := webhook.New(id, p.Method, p.Body, p.Headers, s.now())
 	if err != nil {
 		return nil, err
 	}
+	if err := h.SetResponse(webhook.Response{
+		Body:        p.Body,
+		BodyBase64:  p.BodyBase64,
+		BodyFile:    p.BodyFile,
+		ContentType: p.ContentType,
+		Headers:     p.Headers,
+		Status:      p.Status,
+		Template:    p.Template,
+		Script:      p.Script,
+		Plugin:      p.Plugin,
+	}); err != nil {
+		return nil, err
+	}
+	if err := h.SetResponses(p.Responses); err != nil {
+		return nil, err
+	}
+	if err := h.SetChaos(p.Chaos); err != nil {
+		return nil, err
+	}
+	if err := h.SetProxy(p.Proxy); err != nil {
+		return nil, err
+	}
+	if err := s.setValidation(h, p.Validation); err != nil {
+		return nil, err
+	}
+	if err := s.compileScripts(h); err != nil {
+		return nil, err
+	}
+	if err := s.checkPlugins(ctx, h); err != nil {
+		return nil, err
+	}
 	if err := s.repo.Create(ctx, h); err != nil {
 		return nil, err
 	}
+	if err := s.record(ctx, audit.ActionCreate, id, nil, h); err != nil {
+		return nil, err
+	}
 	return h, nil
 }
 
-func (s *Service) Deactivate(ctx context.Context, id webhook.ID) (*webhook.Hook, bool, error) {
-	return s.repo.Deactivate(ctx, id)
+func (s *Service) Update(ctx context.Context, id webhook.ID, p UpdateParams) (_ *webhook.Hook, _ bool, err error) {
+	ctx, span := startHookSpan(ctx, "webhooks.Service.Update", id)
+	defer func() { endSpan(span, err) }()
+
+	before, ok, err := s.repo.Get(ctx, id)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	if before.Managed {
+		return nil, true, webhook.ErrManaged
+	}
+	h := before.Clone()
+	if p.Method != nil {
+		if err := h.SetMethod(*p.Method); err != nil {
+			return nil, true, err
+		}
+	}
+	r := h.Response
+	switch {
+	case p.Body != nil:
+		r.Body, r.BodyBase64, r.BodyFile = *p.Body, nil, ""
+	case p.BodyBase64 != nil:
+		r.Body, r.BodyBase64, r.BodyFile = "", p.BodyBase64, ""
+	case p.BodyFile != nil:
+		r.Body, r.BodyBase64, r.BodyFile = "", nil, *p.BodyFile
+	}
+	if p.ContentType != nil {
+		r.ContentType = *p.ContentType
+	}
+	if p.Headers != nil {
+		r.Headers = p.Headers
+	}
+	if p.Status != nil {
+		r.Status = *p.Status
+	}
+	if p.Template != nil {
+		r.Template = *p.Template
+	}
+	if p.Script != nil {
+		r.Script = *p.Script
+	}
+	if p.Plugin != nil {
+		r.Plugin = *p.Plugin
+	}
+	if err := h.SetResponse(r); err != nil {
+		return nil, true, err
+	}
+	if p.Responses != nil {
+		if err := h.SetResponses(p.Responses); err != nil {
+			return nil, true, err
+		}
+	}
+	if p.Chaos != nil {
+		if err := h.SetChaos(p.Chaos); err != nil {
+			return nil, true, err
+		}
+	}
+	if p.Proxy != nil {
+		if err := h.SetProxy(p.Proxy); err != nil {
+			return nil, true, err
+		}
+	}
+	if p.Validation != nil {
+		if err := s.setValidation(h, p.Validation); err != nil {
+			return nil, true, err
+		}
+	}
+	if err := s.compileScripts(h); err != nil {
+		return nil, true, err
+	}
+	if err := s.checkPlugins(ctx, h); err != nil {
+		return nil, true, err
+	}
+
+	after, ok, err := s.repo.Update(ctx, h)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	if err := s.record(ctx, audit.ActionUpdate, id, before, after); err != nil {
+		return nil, true, err
+	}
+	return after, true, nil
+}
+
+func (s *Service) Deactivate(ctx context.Context, id webhook.ID) (_ *webhook.Hook, _ bool, err error) {
+	ctx, span := startHookSpan(ctx, "webhooks.Service.Deactivate", id)
+	defer func() { endSpan(span, err) }()
+
+	before, ok, err := s.repo.Get(ctx, id)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	if before.Managed {
+		return nil, true, webhook.ErrManaged
+	}
+	after, ok, err := s.repo.Deactivate(ctx, id)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	if err := s.record(ctx, audit.ActionDeactivate, id, before, after); err != nil {
+		return nil, true, err
+	}
+	return after, true, nil
 }
 
-func (s *Service) Get(ctx context.Context, id webhook.ID) (*webhook.Hook, bool, error) {
+// Delete removes the hook permanently (Deactivate keeps it around).
+func (s *Service) Delete(ctx context.Context, id webhook.ID) (_ *webhook.Hook, _ bool, err error) {
+	ctx, span := startHookSpan(ctx, "webhooks.Service.Delete", id)
+	defer func() { endSpan(span, err) }()
+
+	current, ok, err := s.repo.Get(ctx, id)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	if current.Managed {
+		return nil, true, webhook.ErrManaged
+	}
+	before, ok, err := s.repo.Delete(ctx, id)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	s.feed.closeHook(id)
+	s.schemas.forget(id)
+	s.scripts.forget(id)
+	if s.requests != nil {
+		if err := s.requests.Forget(ctx, id); err != nil {
+			return nil, true, err
+		}
+	}
+	if s.state != nil {
+		if err := s.state.Reset(ctx, id); err != nil {
+			return nil, true, err
+		}
+	}
+	if err := s.record(ctx, audit.ActionDelete, id, before, nil); err != nil {
+		return nil, true, err
+	}
+	return before, true, nil
+}
+
+func (s *Service) Get(ctx context.Context, id webhook.ID) (_ *webhook.Hook, _ bool, err error) {
+	ctx, span := startHookSpan(ctx, "webhooks.Service.Get", id)
+	defer func() { endSpan(span, err) }()
 	return s.repo.Get(ctx, id)
 }
 
-func (s *Service) Touch(ctx context.Context, id webhook.ID) (*webhook.Hook, bool, error) {
+func (s *Service) Touch(ctx context.Context, id webhook.ID) (_ *webhook.Hook, _ bool, err error) {
+	ctx, span := startHookSpan(ctx, "webhooks.Service.Touch", id)
+	defer func() { endSpan(span, err) }()
 	return s.repo.Touch(ctx, id, s.now())
 }
 
-func (s *Service) List(ctx context.Context) (map[webhook.ID]*webhook.Hook, error) {
+func (s *Service) List(ctx context.Context) (_ map[webhook.ID]*webhook.Hook, err error) {
+	ctx, span := tracer.Start(ctx, "webhooks.Service.List")
+	defer func() { endSpan(span, err) }()
 	return s.repo.List(ctx)
 }
+
+// RecordRequest captures an invocation: it is published to live subscribers
+// and appended to the request log, if there is one.
+func (s *Service) RecordRequest(ctx context.Context, r webhook.Request) (err error) {
+	ctx, span := startHookSpan(ctx, "webhooks.Service.RecordRequest", r.HookID)
+	defer func() { endSpan(span, err) }()
+	if r.ID == "" {
+		r.ID = uuid.NewString()
+	}
+	if r.Received.IsZero() {
+		r.Received = s.now()
+	}
+	s.feed.publish(r)
+	if s.requests == nil {
+		return nil
+	}
+	return s.requests.Append(ctx, r)
+}
+
+// Subscribe streams requests captured for a hook from now on. The channel is
+// closed by cancel, or when the hook is deleted. Slow subscribers miss
+// requests rather than holding up invocations.
+func (s *Service) Subscribe(id webhook.ID) (_ <-chan webhook.Request, cancel func()) {
+	return s.feed.subscribe(id)
+}
+
+// Requests lists captured invocations of a hook, oldest first.
+func (s *Service) Requests(ctx context.Context, id webhook.ID, limit int) (_ []webhook.Request, err error) {
+	ctx, span := startHookSpan(ctx, "webhooks.Service.Requests", id)
+	defer func() { endSpan(span, err) }()
+	if s.requests == nil {
+		return []webhook.Request{}, nil
+	}
+	return s.requests.List(ctx, id, limit)
+}
+
+// CountActive reports how many hooks are currently active (used for the metrics gauge).
+func (s *Service) CountActive(ctx context.Context) (int, error) {
+	hooks, err := s.repo.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, h := range hooks {
+		if h.Active {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Ping checks the repository backing store (readiness check). Repositories
+// that don't implement ports.Pinger are assumed healthy.
+func (s *Service) Ping(ctx context.Context) error {
+	if s.repo == nil {
+		return errors.New("repo is nil")
+	}
+	if p, ok := s.repo.(ports.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// AuditLog returns the matching audit entries; empty when auditing is off.
+func (s *Service) AuditLog(ctx context.Context, f audit.Filter) (_ []audit.Entry, err error) {
+	ctx, span := tracer.Start(ctx, "webhooks.Service.AuditLog")
+	defer func() { endSpan(span, err) }()
+	if s.audit == nil {
+		return []audit.Entry{}, nil
+	}
+	return s.audit.Query(ctx, f)
+}
+
+// record appends an audit entry. The mutation has already happened, so a
+// failure here is surfaced to the caller rather than silently dropped.
+func (s *Service) record(ctx context.Context, action audit.Action, id webhook.ID, before, after *webhook.Hook) error {
+	if s.audit == nil {
+		return nil
+	}
+	actor := actorFrom(ctx)
+	err := s.audit.Append(ctx, audit.Entry{
+		ID:       uuid.NewString(),
+		Time:     s.now(),
+		Action:   action,
+		HookID:   id,
+		Actor:    actor.Subject,
+		SourceIP: actor.SourceIP,
+		Before:   before,
+		After:    after,
+	})
+	if err != nil {
+		return fmt.Errorf("audit %s %s: %w", action, id, err)
+	}
+	return nil
+}
+
+func startHookSpan(ctx context.Context, name string, id webhook.ID) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attribute.String("webhookd.hook.id", string(id))))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,94 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+
+	"webhookd/internal/application/ports"
+	"webhookd/internal/domain/webhook"
+)
+
+// WithStateStore keeps the key-value stores of templated responses (see
+// webhook.Response.Render). Without it, templates can't use the kv functions.
+func WithStateStore(store ports.StateStore) Option {
+	return func(s *Service) { s.state = store }
+}
+
+var errNoStateStore = errors.New("no key-value store configured")
+
+// Render renders r, a response of h, for the request in data; responses
+// that aren't templates are returned as they are.
+func (s *Service) Render(ctx context.Context, h *webhook.Hook, r webhook.Response, data webhook.TemplateData) (_ webhook.Response, err error) {
+	if !r.Template {
+		return r, nil
+	}
+	ctx, span := startHookSpan(ctx, "webhooks.Service.Render", h.ID)
+	defer func() { endSpan(span, err) }()
+	return r.Render(data, hookStore{ctx: ctx, store: s.state, id: h.ID})
+}
+
+// State returns the key-value store of hook id; ok is false when there is
+// no such hook.
+func (s *Service) State(ctx context.Context, id webhook.ID) (_ map[string]string, ok bool, err error) {
+	ctx, span := startHookSpan(ctx, "webhooks.Service.State", id)
+	defer func() { endSpan(span, err) }()
+
+	if _, ok, err := s.repo.Get(ctx, id); err != nil || !ok {
+		return nil, ok, err
+	}
+	if s.state == nil {
+		return map[string]string{}, true, nil
+	}
+	state, err := s.state.List(ctx, id)
+	return state, err == nil, err
+}
+
+// ResetState empties the key-value store of hook id; ok is false when there
+// is no such hook.
+func (s *Service) ResetState(ctx context.Context, id webhook.ID) (ok bool, err error) {
+	ctx, span := startHookSpan(ctx, "webhooks.Service.ResetState", id)
+	defer func() { endSpan(span, err) }()
+
+	if _, ok, err := s.repo.Get(ctx, id); err != nil || !ok {
+		return ok, err
+	}
+	if s.state == nil {
+		return true, nil
+	}
+	return true, s.state.Reset(ctx, id)
+}
+
+// hookStore is the webhook.Store of one hook.
+type hookStore struct {
+	ctx   context.Context
+	store ports.StateStore
+	id    webhook.ID
+}
+
+func (h hookStore) Get(key string) (string, bool, error) {
+	if h.store == nil {
+		return "", false, errNoStateStore
+	}
+	return h.store.Get(h.ctx, h.id, key)
+}
+
+func (h hookStore) Put(key, value string) error {
+	if h.store == nil {
+		return errNoStateStore
+	}
+	return h.store.Put(h.ctx, h.id, key, value)
+}
+
+func (h hookStore) Delete(key string) error {
+	if h.store == nil {
+		return errNoStateStore
+	}
+	return h.store.Delete(h.ctx, h.id, key)
+}
+
+func (h hookStore) All() (map[string]string, error) {
+	if h.store == nil {
+		return nil, errNoStateStore
+	}
+	return h.store.List(h.ctx, h.id)
+}
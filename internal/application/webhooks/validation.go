@@ -0,0 +1,86 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"webhookd/internal/application/ports"
+	"webhookd/internal/domain/webhook"
+)
+
+// WithSchemaCompiler enables request validation (webhook.RequestValidation).
+// Without it, hooks with a schema are refused.
+func WithSchemaCompiler(c ports.SchemaCompiler) Option {
+	return func(s *Service) { s.compiler = c }
+}
+
+// schemaCache keeps the compiled schema of each hook, so it is compiled once
+// rather than on every request.
+type schemaCache struct {
+	mu sync.Mutex
+	m  map[webhook.ID]cachedSchema
+}
+
+type cachedSchema struct {
+	raw    []byte
+	schema ports.Schema
+}
+
+// setValidation sets v on h, compiling its schema so that invalid ones are
+// refused when the hook is saved.
+func (s *Service) setValidation(h *webhook.Hook, v *webhook.RequestValidation) error {
+	if err := h.SetValidation(v); err != nil {
+		return err
+	}
+	if h.Validation == nil {
+		return nil
+	}
+	_, err := s.schema(h.ID, h.Validation.Schema)
+	return err
+}
+
+// schema returns the compiled schema of hook id.
+func (s *Service) schema(id webhook.ID, raw []byte) (ports.Schema, error) {
+	if s.compiler == nil {
+		return nil, fmt.Errorf("%w: request validation is not available", webhook.ErrInvalidValidation)
+	}
+	s.schemas.mu.Lock()
+	defer s.schemas.mu.Unlock()
+	if c, ok := s.schemas.m[id]; ok && bytes.Equal(c.raw, raw) {
+		return c.schema, nil
+	}
+	compiled, err := s.compiler.Compile(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: schema: %v", webhook.ErrInvalidValidation, err)
+	}
+	if s.schemas.m == nil {
+		s.schemas.m = map[webhook.ID]cachedSchema{}
+	}
+	s.schemas.m[id] = cachedSchema{raw: bytes.Clone(raw), schema: compiled}
+	return compiled, nil
+}
+
+func (c *schemaCache) forget(id webhook.ID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.m, id)
+}
+
+// ValidateRequest checks a request body against h's schema; the result is
+// nil when h has none.
+func (s *Service) ValidateRequest(ctx context.Context, h *webhook.Hook, body []byte) (_ *webhook.ValidationResult, err error) {
+	if h.Validation == nil {
+		return nil, nil
+	}
+	_, span := startHookSpan(ctx, "webhooks.Service.ValidateRequest", h.ID)
+	defer func() { endSpan(span, err) }()
+
+	schema, err := s.schema(h.ID, h.Validation.Schema)
+	if err != nil {
+		return nil, err
+	}
+	res := schema.Validate(body)
+	return &res, nil
+}
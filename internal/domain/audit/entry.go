@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"time"
+
+	"webhookd/internal/domain/webhook"
+)
+
+type Action string
+
+const (
+	ActionCreate     Action = "create"
+	ActionUpdate     Action = "update"
+	ActionDeactivate Action = "deactivate"
+	ActionDelete     Action = "delete"
+)
+
+func (a Action) Valid() bool {
+	switch a {
+	case ActionCreate, ActionUpdate, ActionDeactivate, ActionDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// Entry records one management operation on a hook. Before is nil for
+// creates, After is nil for deletes.
+type Entry struct {
+	ID       string        `json:"id"`
+	Time     time.Time     `json:"time"`
+	Action   Action        `json:"action"`
+	HookID   webhook.ID    `json:"hook_id"`
+	Actor    string        `json:"actor"`
+	SourceIP string        `json:"source_ip"`
+	Before   *webhook.Hook `json:"before,omitempty"`
+	After    *webhook.Hook `json:"after,omitempty"`
+}
+
+// Filter narrows audit queries; zero fields match everything. Results are
+// ordered oldest first; Limit keeps the most recent entries.
+type Filter struct {
+	HookID webhook.ID
+	Actor  string
+	Action Action
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+func (f Filter) Matches(e Entry) bool {
+	if f.HookID != "" && e.HookID != f.HookID {
+		return false
+	}
+	if f.Actor != "" && e.Actor != f.Actor {
+		return false
+	}
+	if f.Action != "" && e.Action != f.Action {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.Time.Before(f.Until) {
+		return false
+	}
+	return true
+}
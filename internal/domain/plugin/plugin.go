@@ -0,0 +1,47 @@
+// Package plugin describes WebAssembly plugins: modules hooks hand requests
+// to, for response logic written in any language that compiles to WASI.
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+var (
+	ErrInvalidName   = errors.New("invalid plugin name")
+	ErrInvalidModule = errors.New("invalid plugin module")
+	// ErrInUse is returned when deleting a plugin hooks still use.
+	ErrInUse = errors.New("plugin in use")
+)
+
+const (
+	MaxNameLength = 100
+	// MaxModuleSize bounds the size of a module.
+	MaxModuleSize = 32 << 20
+)
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Plugin describes a stored module.
+type Plugin struct {
+	Name string `json:"name"`
+	// Size of the module in bytes.
+	Size       int       `json:"size"`
+	SHA256     string    `json:"sha256" doc:"Hex SHA-256 of the module"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+// ValidateName checks that name is a slug such as "pricing" or "orders-v2".
+func ValidateName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("%w: empty", ErrInvalidName)
+	case len(name) > MaxNameLength:
+		return fmt.Errorf("%w: longer than %d characters", ErrInvalidName, MaxNameLength)
+	case !namePattern.MatchString(name):
+		return fmt.Errorf("%w: %q must start with a letter or digit and contain only letters, digits, '.', '_' and '-'", ErrInvalidName, name)
+	}
+	return nil
+}
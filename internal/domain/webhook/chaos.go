@@ -0,0 +1,234 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChaosHeader is the request header that turns chaos on or off for one
+// invocation, or replaces the hook's settings (see ParseChaos).
+const ChaosHeader = "X-Webhookd-Chaos"
+
+var ErrInvalidChaos = errors.New("invalid chaos settings")
+
+// Chaos injects latency and faults into a hook's responses, to test how
+// clients cope with slow or failing endpoints. Rates are probabilities
+// between 0 and 1, rolled on every invocation.
+type Chaos struct {
+	DelayMS    int `json:"delay_ms,omitempty" minimum:"0" doc:"Delay responses by this many milliseconds"`
+	DelayMaxMS int `json:"delay_max_ms,omitempty" minimum:"0" doc:"Delay by a random duration between delay_ms and this instead"`
+
+	ErrorRate   float64 `json:"error_rate,omitempty" minimum:"0" maximum:"1" doc:"Probability of answering error_status instead of the response"`
+	ErrorStatus int     `json:"error_status,omitempty" doc:"Status of injected errors (default 500)"`
+	AbortRate   float64 `json:"abort_rate,omitempty" minimum:"0" maximum:"1" doc:"Probability of resetting the connection without a response"`
+	// A truncated response announces the full Content-Length, sends
+	// TruncateBytes of the body (half when unset; 0 sends none; at most the
+	// whole body) and closes the connection.
+	TruncateRate  float64 `json:"truncate_rate,omitempty" minimum:"0" maximum:"1" doc:"Probability of cutting the body short and closing the connection"`
+	TruncateBytes *int    `json:"truncate_bytes,omitempty" minimum:"0" doc:"Bytes of the body sent before a truncated response is cut (default half)"`
+
+	DripBytes      int `json:"drip_bytes,omitempty" minimum:"0" doc:"Stream the body in chunks of this many bytes"`
+	DripIntervalMS int `json:"drip_interval_ms,omitempty" minimum:"0" doc:"Pause between drip chunks in milliseconds (default 100)"`
+
+	// HeaderOnly applies the settings only to requests sent with
+	// "X-Webhookd-Chaos: on", so a shared hook misbehaves for targeted tests
+	// alone.
+	HeaderOnly bool `json:"header_only,omitempty" doc:"Apply only to requests sent with X-Webhookd-Chaos: on"`
+}
+
+// Validate checks ranges.
+func (c Chaos) Validate() error {
+	if c.DelayMS < 0 || c.DelayMaxMS < 0 || c.DripBytes < 0 || c.DripIntervalMS < 0 || (c.TruncateBytes != nil && *c.TruncateBytes < 0) {
+		return fmt.Errorf("%w: durations and sizes must be >= 0", ErrInvalidChaos)
+	}
+	if c.DelayMaxMS != 0 && c.DelayMaxMS < c.DelayMS {
+		return fmt.Errorf("%w: delay_max_ms must be >= delay_ms", ErrInvalidChaos)
+	}
+	for _, r := range []float64{c.ErrorRate, c.AbortRate, c.TruncateRate} {
+		if r < 0 || r > 1 {
+			return fmt.Errorf("%w: rates must be between 0 and 1", ErrInvalidChaos)
+		}
+	}
+	if c.ErrorStatus != 0 && (c.ErrorStatus < 400 || c.ErrorStatus > 599) {
+		return fmt.Errorf("%w: error_status must be between 400 and 599", ErrInvalidChaos)
+	}
+	return nil
+}
+
+// IsZero reports whether c injects nothing.
+func (c Chaos) IsZero() bool {
+	return c.Equal(Chaos{})
+}
+
+// Equal compares c and o by value.
+func (c Chaos) Equal(o Chaos) bool {
+	a, b := c.TruncateBytes, o.TruncateBytes
+	if (a == nil) != (b == nil) || (a != nil && *a != *b) {
+		return false
+	}
+	c.TruncateBytes, o.TruncateBytes = nil, nil
+	return c == o
+}
+
+// clone copies c, so that the copy shares no memory with it.
+func (c Chaos) clone() Chaos {
+	if c.TruncateBytes != nil {
+		n := *c.TruncateBytes
+		c.TruncateBytes = &n
+	}
+	return c
+}
+
+// SetChaos replaces the hook's chaos settings; nil or zero settings remove
+// them.
+func (h *Hook) SetChaos(c *Chaos) error {
+	if c == nil || c.IsZero() {
+		h.Chaos = nil
+		return nil
+	}
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	cc := c.clone()
+	h.Chaos = &cc
+	return nil
+}
+
+// EqualChaos compares two optional chaos settings.
+func EqualChaos(a, b *Chaos) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// Fault is the outcome of rolling a hook's chaos settings for one invocation.
+type Fault struct {
+	Delay time.Duration
+	// At most one of ErrorStatus, Abort and Truncate is set.
+	ErrorStatus int
+	Abort       bool
+	Truncate    bool
+	// TruncateBytes is how much of the body a truncated response sends; -1
+	// means half.
+	TruncateBytes int
+	DripBytes     int
+	DripInterval  time.Duration
+}
+
+// Kind names the fault for spans and metrics: abort, error, truncate, drip,
+// delay, or "" when nothing is injected.
+func (f Fault) Kind() string {
+	switch {
+	case f.Abort:
+		return "abort"
+	case f.ErrorStatus != 0:
+		return "error"
+	case f.Truncate:
+		return "truncate"
+	case f.DripBytes > 0:
+		return "drip"
+	case f.Delay > 0:
+		return "delay"
+	default:
+		return ""
+	}
+}
+
+// Roll decides the faults of one invocation.
+func (c Chaos) Roll() Fault {
+	f := Fault{Delay: time.Duration(c.DelayMS) * time.Millisecond}
+	if c.DelayMaxMS > c.DelayMS {
+		f.Delay += time.Duration(rand.N(c.DelayMaxMS-c.DelayMS+1)) * time.Millisecond
+	}
+	switch {
+	case hit(c.AbortRate):
+		f.Abort = true
+		return f
+	case hit(c.ErrorRate):
+		f.ErrorStatus = c.ErrorStatus
+		if f.ErrorStatus == 0 {
+			f.ErrorStatus = http.StatusInternalServerError
+		}
+		return f
+	case hit(c.TruncateRate):
+		f.Truncate = true
+		f.TruncateBytes = -1
+		if c.TruncateBytes != nil {
+			f.TruncateBytes = *c.TruncateBytes
+		}
+	}
+	if c.DripBytes > 0 {
+		f.DripBytes = c.DripBytes
+		f.DripInterval = time.Duration(c.DripIntervalMS) * time.Millisecond
+		if f.DripInterval == 0 {
+			f.DripInterval = 100 * time.Millisecond
+		}
+	}
+	return f
+}
+
+func hit(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate
+}
+
+// ParseChaos reads chaos settings from the value of ChaosHeader: a comma
+// separated list of key=value pairs named like the JSON fields, e.g.
+// "delay_ms=200,error_rate=0.5,error_status=503". Rates default to 1, so
+// "abort_rate" alone always aborts; header_only defaults to true.
+func ParseChaos(s string) (Chaos, error) {
+	var c Chaos
+	for part := range strings.SplitSeq(s, ",") {
+		k, v, hasValue := strings.Cut(strings.TrimSpace(part), "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if k == "" {
+			continue
+		}
+		var err error
+		switch k {
+		case "delay_ms":
+			c.DelayMS, err = strconv.Atoi(v)
+		case "delay_max_ms":
+			c.DelayMaxMS, err = strconv.Atoi(v)
+		case "error_status":
+			c.ErrorStatus, err = strconv.Atoi(v)
+		case "truncate_bytes":
+			var n int
+			n, err = strconv.Atoi(v)
+			c.TruncateBytes = &n
+		case "drip_bytes":
+			c.DripBytes, err = strconv.Atoi(v)
+		case "drip_interval_ms":
+			c.DripIntervalMS, err = strconv.Atoi(v)
+		case "error_rate", "abort_rate", "truncate_rate":
+			rate := 1.0
+			if hasValue {
+				rate, err = strconv.ParseFloat(v, 64)
+			}
+			switch k {
+			case "error_rate":
+				c.ErrorRate = rate
+			case "abort_rate":
+				c.AbortRate = rate
+			default:
+				c.TruncateRate = rate
+			}
+		case "header_only":
+			c.HeaderOnly = true
+			if hasValue {
+				c.HeaderOnly, err = strconv.ParseBool(v)
+			}
+		default:
+			return Chaos{}, fmt.Errorf("%w: unknown key %q", ErrInvalidChaos, k)
+		}
+		if err != nil {
+			return Chaos{}, fmt.Errorf("%w: %s: %v", ErrInvalidChaos, k, err)
+		}
+	}
+	return c, c.Validate()
+}
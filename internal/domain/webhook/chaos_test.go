@@ -0,0 +1,92 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func intPtr(n int) *int { return &n }
+
+func TestParseChaos(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    Chaos
+		wantErr bool
+	}{
+		{header: "", want: Chaos{}},
+		{header: "delay_ms=200, delay_max_ms=400", want: Chaos{DelayMS: 200, DelayMaxMS: 400}},
+		{header: "error_rate=0.5,error_status=503", want: Chaos{ErrorRate: 0.5, ErrorStatus: 503}},
+		{header: "abort_rate", want: Chaos{AbortRate: 1}},
+		{header: "truncate_rate,truncate_bytes=0", want: Chaos{TruncateRate: 1, TruncateBytes: intPtr(0)}},
+		{header: "drip_bytes=10,drip_interval_ms=5", want: Chaos{DripBytes: 10, DripIntervalMS: 5}},
+		{header: "header_only", want: Chaos{HeaderOnly: true}},
+		{header: "header_only=false,abort_rate=0", want: Chaos{}},
+		{header: ",,delay_ms=1,", want: Chaos{DelayMS: 1}},
+		{header: "delay=1", wantErr: true},
+		{header: "delay_ms=soon", wantErr: true},
+		{header: "delay_ms", wantErr: true},
+		{header: "error_rate=2", wantErr: true},
+		{header: "error_status=302", wantErr: true},
+		{header: "delay_ms=10,delay_max_ms=5", wantErr: true},
+		{header: "truncate_bytes=-1", wantErr: true},
+		{header: "header_only=maybe", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseChaos(tt.header)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidChaos) {
+				t.Errorf("ParseChaos(%q) error = %v, want ErrInvalidChaos", tt.header, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseChaos(%q): %v", tt.header, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseChaos(%q) = %+v, want %+v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestChaosRoll(t *testing.T) {
+	tests := []struct {
+		name  string
+		chaos Chaos
+		want  Fault
+		kind  string
+	}{
+		{name: "nothing", chaos: Chaos{}, want: Fault{}},
+		{name: "delay", chaos: Chaos{DelayMS: 20}, want: Fault{Delay: 20 * time.Millisecond}, kind: "delay"},
+		{name: "abort wins", chaos: Chaos{AbortRate: 1, ErrorRate: 1, DripBytes: 1}, want: Fault{Abort: true}, kind: "abort"},
+		{name: "error default status", chaos: Chaos{ErrorRate: 1}, want: Fault{ErrorStatus: http.StatusInternalServerError}, kind: "error"},
+		{name: "error status", chaos: Chaos{ErrorRate: 1, ErrorStatus: 429}, want: Fault{ErrorStatus: 429}, kind: "error"},
+		{name: "truncate half", chaos: Chaos{TruncateRate: 1}, want: Fault{Truncate: true, TruncateBytes: -1}, kind: "truncate"},
+		{name: "truncate bytes", chaos: Chaos{TruncateRate: 1, TruncateBytes: intPtr(0)}, want: Fault{Truncate: true}, kind: "truncate"},
+		{name: "drip default interval", chaos: Chaos{DripBytes: 4}, want: Fault{DripBytes: 4, DripInterval: 100 * time.Millisecond}, kind: "drip"},
+		{name: "drip", chaos: Chaos{DripBytes: 4, DripIntervalMS: 5}, want: Fault{DripBytes: 4, DripInterval: 5 * time.Millisecond}, kind: "drip"},
+		{name: "zero rates", chaos: Chaos{HeaderOnly: true}, want: Fault{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.chaos.Roll()
+			if got != tt.want {
+				t.Fatalf("Roll() = %+v, want %+v", got, tt.want)
+			}
+			if got.Kind() != tt.kind {
+				t.Fatalf("Kind() = %q, want %q", got.Kind(), tt.kind)
+			}
+		})
+	}
+}
+
+func TestChaosRollDelayRange(t *testing.T) {
+	c := Chaos{DelayMS: 10, DelayMaxMS: 20}
+	for range 100 {
+		if d := c.Roll().Delay; d < 10*time.Millisecond || d > 20*time.Millisecond {
+			t.Fatalf("Delay = %v, want between 10ms and 20ms", d)
+		}
+	}
+}
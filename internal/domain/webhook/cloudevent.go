@@ -0,0 +1,142 @@
+package webhook
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// CloudEvents content modes: binary keeps the body as the event data and
+// puts the attributes in ce-* headers; structured sends the whole event as
+// an application/cloudevents+json body.
+const (
+	CloudEventsBinary     = "binary"
+	CloudEventsStructured = "structured"
+)
+
+// CloudEventsSpecVersion is the CloudEvents version of the events proxies
+// wrap requests in.
+const CloudEventsSpecVersion = "1.0"
+
+// CloudEventsMediaType is the content type of structured mode events.
+const CloudEventsMediaType = "application/cloudevents+json"
+
+// CloudEvent holds the context attributes of a request carrying a
+// CloudEvent (https://cloudevents.io), in either content mode. source and
+// id identify an event: deliveries of the same event share them.
+type CloudEvent struct {
+	Mode            string `json:"mode" enum:"binary,structured" doc:"Content mode the event arrived in"`
+	SpecVersion     string `json:"specversion" example:"1.0"`
+	ID              string `json:"id" example:"A234-1234-1234"`
+	Source          string `json:"source" example:"/billing/invoices"`
+	Type            string `json:"type" example:"com.example.invoice.paid"`
+	Subject         string `json:"subject,omitempty"`
+	Time            string `json:"time,omitempty"`
+	DataContentType string `json:"datacontenttype,omitempty"`
+}
+
+// ParseCloudEvent returns the CloudEvent a request carries: in binary mode,
+// the ce-specversion, ce-id, ce-source and ce-type headers; in structured
+// mode, an application/cloudevents+json body with those attributes. It
+// returns nil for other requests, including events missing one of the
+// required attributes and batches.
+func ParseCloudEvent(header http.Header, body []byte) *CloudEvent {
+	if header.Get("Ce-Specversion") != "" {
+		e := &CloudEvent{
+			Mode:            CloudEventsBinary,
+			SpecVersion:     header.Get("Ce-Specversion"),
+			ID:              header.Get("Ce-Id"),
+			Source:          header.Get("Ce-Source"),
+			Type:            header.Get("Ce-Type"),
+			Subject:         header.Get("Ce-Subject"),
+			Time:            header.Get("Ce-Time"),
+			DataContentType: header.Get("Content-Type"),
+		}
+		return e.complete()
+	}
+	if mt, _, err := mime.ParseMediaType(header.Get("Content-Type")); err != nil || mt != CloudEventsMediaType {
+		return nil
+	}
+	var e CloudEvent
+	if json.Unmarshal(body, &e) != nil {
+		return nil
+	}
+	e.Mode = CloudEventsStructured
+	return e.complete()
+}
+
+// complete returns e when it has the required attributes, nil otherwise.
+func (e *CloudEvent) complete() *CloudEvent {
+	if e.SpecVersion == "" || e.ID == "" || e.Source == "" || e.Type == "" {
+		return nil
+	}
+	return e
+}
+
+// Binary returns the headers that carry e in binary mode. The body is left
+// as it is, with its own Content-Type.
+func (e CloudEvent) Binary() map[string]string {
+	h := map[string]string{
+		"Ce-Specversion": e.SpecVersion,
+		"Ce-Id":          e.ID,
+		"Ce-Source":      e.Source,
+		"Ce-Type":        e.Type,
+	}
+	if e.Subject != "" {
+		h["Ce-Subject"] = e.Subject
+	}
+	if e.Time != "" {
+		h["Ce-Time"] = e.Time
+	}
+	return h
+}
+
+// Structured returns e as a structured mode body, with data as its data:
+// embedded as JSON when it is JSON, as a string when it is other text, and
+// as data_base64 otherwise. Send it as CloudEventsMediaType.
+func (e CloudEvent) Structured(data []byte) ([]byte, error) {
+	out := struct {
+		SpecVersion     string          `json:"specversion"`
+		ID              string          `json:"id"`
+		Source          string          `json:"source"`
+		Type            string          `json:"type"`
+		Subject         string          `json:"subject,omitempty"`
+		Time            string          `json:"time,omitempty"`
+		DataContentType string          `json:"datacontenttype,omitempty"`
+		Data            json.RawMessage `json:"data,omitempty"`
+		DataBase64      []byte          `json:"data_base64,omitempty"`
+	}{
+		SpecVersion: e.SpecVersion, ID: e.ID, Source: e.Source, Type: e.Type,
+		Subject: e.Subject, Time: e.Time, DataContentType: e.DataContentType,
+	}
+	switch {
+	case len(data) == 0:
+	case isJSONType(e.DataContentType) && json.Valid(data):
+		out.Data = data
+	case utf8.Valid(data):
+		out.Data, _ = json.Marshal(string(data))
+	default:
+		out.DataBase64 = data
+	}
+	return json.Marshal(out)
+}
+
+// isJSONType reports whether a content type is JSON (application/json or a
+// +json type); an empty one counts, as CloudEvents assume JSON data then.
+func isJSONType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mt, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mt == "application/json" || strings.HasSuffix(mt, "+json"))
+}
+
+func (e *CloudEvent) clone() *CloudEvent {
+	if e == nil {
+		return nil
+	}
+	c := *e
+	return &c
+}
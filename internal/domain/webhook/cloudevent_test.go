@@ -0,0 +1,76 @@
+package webhook
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParseCloudEvent(t *testing.T) {
+	binary := func(extra ...string) http.Header {
+		h := http.Header{
+			"Ce-Specversion": {"1.0"},
+			"Ce-Id":          {"A1"},
+			"Ce-Source":      {"/billing"},
+			"Ce-Type":        {"invoice.paid"},
+			"Content-Type":   {"application/json"},
+		}
+		for i := 0; i+1 < len(extra); i += 2 {
+			if extra[i+1] == "" {
+				h.Del(extra[i])
+			} else {
+				h.Set(extra[i], extra[i+1])
+			}
+		}
+		return h
+	}
+	structured := http.Header{"Content-Type": {"application/cloudevents+json; charset=utf-8"}}
+
+	tests := []struct {
+		name   string
+		header http.Header
+		body   string
+		want   *CloudEvent
+	}{
+		{
+			name: "binary", header: binary("Ce-Subject", "inv-1", "Ce-Time", "2026-01-02T03:04:05Z"), body: `{"amount":1}`,
+			want: &CloudEvent{
+				Mode: CloudEventsBinary, SpecVersion: "1.0", ID: "A1", Source: "/billing", Type: "invoice.paid",
+				Subject: "inv-1", Time: "2026-01-02T03:04:05Z", DataContentType: "application/json",
+			},
+		},
+		{name: "binary without id", header: binary("Ce-Id", "")},
+		{name: "binary without source", header: binary("Ce-Source", "")},
+		{name: "binary without type", header: binary("Ce-Type", "")},
+		{
+			name: "structured", header: structured,
+			body: `{"specversion":"1.0","id":"B2","source":"/orders","type":"order.created","subject":"o-1","datacontenttype":"application/json","data":{"x":1}}`,
+			want: &CloudEvent{
+				Mode: CloudEventsStructured, SpecVersion: "1.0", ID: "B2", Source: "/orders", Type: "order.created",
+				Subject: "o-1", DataContentType: "application/json",
+			},
+		},
+		{name: "structured without specversion", header: structured, body: `{"id":"B2","source":"/orders","type":"order.created"}`},
+		{name: "structured without id", header: structured, body: `{"specversion":"1.0","source":"/orders","type":"order.created"}`},
+		{name: "structured batch", header: structured, body: `[{"specversion":"1.0","id":"B2","source":"/orders","type":"order.created"}]`},
+		{name: "structured invalid json", header: structured, body: `{`},
+		{
+			name:   "structured body as plain json",
+			header: http.Header{"Content-Type": {"application/json"}},
+			body:   `{"specversion":"1.0","id":"B2","source":"/orders","type":"order.created"}`,
+		},
+		{name: "no event", header: http.Header{"Content-Type": {"text/plain"}}, body: "hi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseCloudEvent(tt.header, []byte(tt.body))
+			switch {
+			case tt.want == nil && got != nil:
+				t.Fatalf("got %+v, want no event", *got)
+			case tt.want != nil && got == nil:
+				t.Fatal("got no event")
+			case tt.want != nil && *got != *tt.want:
+				t.Fatalf("got %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
@@ -2,36 +2,101 @@ package webhook
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"regexp"
 	"strings"
 	"time"
 )
 
+// ID identifies a hook and is its path below /v1/hooks/. Generated IDs are
+// UUIDs; custom ones are slugs of one or more "/"-separated segments, e.g.
+// "github/org-events".
 type ID string
 
+const (
+	MaxIDLength   = 200
+	MaxIDSegments = 8
+)
+
+var idSegment = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._~-]*$`)
+
+// Validate checks that id is usable as a hook path.
+func (id ID) Validate() error {
+	if id == "" {
+		return fmt.Errorf("%w: empty", ErrInvalidID)
+	}
+	if len(id) > MaxIDLength {
+		return fmt.Errorf("%w: longer than %d characters", ErrInvalidID, MaxIDLength)
+	}
+	segments := strings.Split(string(id), "/")
+	if len(segments) > MaxIDSegments {
+		return fmt.Errorf("%w: more than %d segments", ErrInvalidID, MaxIDSegments)
+	}
+	for _, seg := range segments {
+		if !idSegment.MatchString(seg) {
+			return fmt.Errorf("%w: segment %q must start with a letter or digit and contain only letters, digits, '.', '_', '~' and '-'", ErrInvalidID, seg)
+		}
+	}
+	return nil
+}
+
+var (
+	ErrUnsupportedMethod = errors.New("unsupported method")
+	ErrInvalidStatus     = errors.New("status must be between 100 and 599")
+	ErrInvalidID         = errors.New("invalid hook id")
+	ErrInvalidBody       = errors.New("invalid body")
+	// ErrIDExists is returned by repositories when creating a hook whose ID
+	// is taken.
+	ErrIDExists = errors.New("hook id already exists")
+	// ErrManaged is returned when changing a hook declared in the config
+	// file through the API; edit the file instead.
+	ErrManaged = errors.New("hook is managed by the config file")
+)
+
 type Hook struct {
-	ID      ID
-	Method  string
-	Body    string
-	Headers map[string]string
-
-	Active   bool
-	Counter  int64
-	LastCall time.Time
-	Created  time.Time
+	ID     ID     `json:"id"`
+	Method string `json:"method"`
+	// Response is what the hook answers to Method.
+	Response
+	// Responses accepts more methods than Method, each with its own response
+	// (see ResponseFor).
+	Responses map[string]Response `json:"responses,omitempty"`
+	// Chaos injects latency and faults into invocations; nil for none.
+	Chaos *Chaos `json:"chaos,omitempty"`
+	// Proxy makes the hook a recording proxy (see Proxy); RecordedFrom is
+	// set on the hooks it saved.
+	Proxy        *Proxy `json:"proxy,omitempty"`
+	RecordedFrom ID     `json:"recorded_from,omitempty"`
+	// Validation checks request bodies against a JSON Schema; nil for none.
+	Validation *RequestValidation `json:"validation,omitempty"`
+	// Managed hooks are declared in the config file and reconciled from it;
+	// the API refuses to change them.
+	Managed bool `json:"managed,omitempty"`
+
+	Active   bool      `json:"active"`
+	Counter  int64     `json:"counter"`
+	LastCall time.Time `json:"last_call"`
+	Created  time.Time `json:"created"`
 }
 
 func New(id ID, method, body string, headers map[string]string, now time.Time) (*Hook, error) {
+	if err := id.Validate(); err != nil {
+		return nil, err
+	}
 	m := normalizeMethod(method)
 	if !isAllowedMethod(m) {
-		return nil, errors.New("unsupported method")
+		return nil, ErrUnsupportedMethod
 	}
 
 	h := &Hook{
-		ID:       id,
-		Method:   m,
-		Body:     body,
-		Headers:  cloneHeaders(headers),
+		ID:     id,
+		Method: m,
+		Response: Response{
+			Body:    body,
+			Headers: cloneHeaders(headers),
+			Status:  http.StatusOK,
+		},
 		Active:   true,
 		Counter:  0,
 		LastCall: time.Unix(0, 0).UTC(),
@@ -40,6 +105,31 @@ func New(id ID, method, body string, headers map[string]string, now time.Time) (
 	return h, nil
 }
 
+func (h *Hook) SetMethod(method string) error {
+	m := normalizeMethod(method)
+	if !isAllowedMethod(m) {
+		return ErrUnsupportedMethod
+	}
+	h.Method = m
+	return nil
+}
+
+// SetStatus sets the response status; 0 means 200.
+func (h *Hook) SetStatus(status int) error {
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if status < 100 || status > 599 {
+		return ErrInvalidStatus
+	}
+	h.Status = status
+	return nil
+}
+
+func (h *Hook) SetHeaders(headers map[string]string) {
+	h.Headers = cloneHeaders(headers)
+}
+
 func (h *Hook) Deactivate() {
 	h.Active = false
 }
@@ -50,7 +140,8 @@ func (h *Hook) Touch(now time.Time) {
 }
 
 func (h *Hook) MatchesMethod(method string) bool {
-	return strings.EqualFold(h.Method, normalizeMethod(method))
+	_, ok := h.ResponseFor(method)
+	return ok
 }
 
 func normalizeMethod(m string) string {
@@ -63,13 +154,33 @@ func normalizeMethod(m string) string {
 
 func isAllowedMethod(m string) bool {
 	switch m {
-	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+	case MethodAny, http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
 		return true
 	default:
 		return false
 	}
 }
 
+// Clone returns a deep copy, e.g. for before/after snapshots.
+func (h *Hook) Clone() *Hook {
+	if h == nil {
+		return nil
+	}
+	c := *h
+	c.Response = h.Response.Clone()
+	c.Responses = cloneResponses(h.Responses)
+	if h.Chaos != nil {
+		chaos := h.Chaos.clone()
+		c.Chaos = &chaos
+	}
+	if h.Proxy != nil {
+		proxy := *h.Proxy
+		c.Proxy = &proxy
+	}
+	c.Validation = h.Validation.clone()
+	return &c
+}
+
 func cloneHeaders(in map[string]string) map[string]string {
 	if in == nil {
 		return map[string]string{}
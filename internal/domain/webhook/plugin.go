@@ -0,0 +1,30 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidPlugin is returned when a response names a plugin that
+	// isn't there, or can't use one.
+	ErrInvalidPlugin = errors.New("invalid plugin settings")
+	// ErrPluginFailed is returned when a plugin fails while serving a
+	// request, or returns something that isn't a response.
+	ErrPluginFailed = errors.New("plugin failed")
+	// ErrPluginBusy is returned when as many plugin instances are running
+	// as the runtime allows.
+	ErrPluginBusy = errors.New("too many plugins running")
+)
+
+// validatePlugin checks that nothing else computes the body of a response
+// served by a plugin.
+func (r Response) validatePlugin() error {
+	switch {
+	case r.Script != "" || r.Template:
+		return fmt.Errorf("%w: a response is either a plugin, a script or a template", ErrInvalidPlugin)
+	case len(r.BodyBase64) > 0 || r.BodyFile != "":
+		return fmt.Errorf("%w: plugins can't be combined with body_base64 or body_file", ErrInvalidPlugin)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// Proxy modes.
+const (
+	// ProxyRecord forwards requests to the upstream and saves each response
+	// as a hook below the proxy hook.
+	ProxyRecord = "record"
+	// ProxyReplay serves the saved hooks without contacting the upstream.
+	ProxyReplay = "replay"
+)
+
+var (
+	ErrInvalidProxy = errors.New("invalid proxy settings")
+	// ErrUpstreamNotAllowed is returned for proxy upstreams that aren't in
+	// the allowlist (Upstreams).
+	ErrUpstreamNotAllowed = errors.New("proxy upstream not allowed")
+)
+
+// Proxy turns a hook into a recording proxy for an upstream API. A request
+// to /v1/hooks/{id}/v1/charges is forwarded to {upstream}/v1/charges in
+// record mode, and the response is saved as the hook "{id}/v1/charges"
+// (RecordedFrom id), answering that method. In replay mode those hooks are
+// served like any other, so recordings match on method and path only.
+type Proxy struct {
+	Upstream string `json:"upstream,omitempty" doc:"Base URL requests are forwarded to in record mode" example:"https://api.example.com"`
+	Mode     string `json:"mode,omitempty" enum:"record,replay" doc:"record forwards and saves responses; replay serves the saved ones (default record)"`
+	// CloudEvents wraps forwarded requests that aren't CloudEvents in one,
+	// in this content mode; EventType is the type of those events.
+	CloudEvents string `json:"cloudevents,omitempty" enum:"binary,structured" doc:"Forward requests that aren't CloudEvents wrapped in one, in this content mode"`
+	EventType   string `json:"event_type,omitempty" doc:"Type of the events requests are wrapped in (default webhookd.request)" example:"com.example.order"`
+	// Dedupe forwards each CloudEvent once, by source and id.
+	Dedupe bool `json:"dedupe,omitempty" doc:"Forward each CloudEvent (by source and id) once; repeated deliveries get the response the first one got"`
+}
+
+// DefaultEventType is the type of the events proxies wrap requests in when
+// Proxy.EventType is empty.
+const DefaultEventType = "webhookd.request"
+
+// Validate checks the mode and that record mode has an http(s) upstream.
+func (p Proxy) Validate() error {
+	switch p.Mode {
+	case "", ProxyRecord, ProxyReplay:
+	default:
+		return fmt.Errorf("%w: mode must be %s or %s", ErrInvalidProxy, ProxyRecord, ProxyReplay)
+	}
+	switch p.CloudEvents {
+	case "", CloudEventsBinary, CloudEventsStructured:
+	default:
+		return fmt.Errorf("%w: cloudevents must be %s or %s", ErrInvalidProxy, CloudEventsBinary, CloudEventsStructured)
+	}
+	if p.EventType != "" && p.CloudEvents == "" {
+		return fmt.Errorf("%w: event_type requires cloudevents", ErrInvalidProxy)
+	}
+	if p.Upstream == "" {
+		if p.Recording() {
+			return fmt.Errorf("%w: upstream is required in record mode", ErrInvalidProxy)
+		}
+		return nil
+	}
+	u, err := url.Parse(p.Upstream)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: upstream must be an http(s) URL", ErrInvalidProxy)
+	}
+	return nil
+}
+
+// Recording reports whether requests are forwarded and recorded.
+func (p Proxy) Recording() bool {
+	return p.Mode == "" || p.Mode == ProxyRecord
+}
+
+// Upstreams is the allowlist of the upstreams recording proxies may forward
+// to, so that they can't be used to reach arbitrary hosts. The zero value
+// allows none.
+type Upstreams struct {
+	hosts    map[string]bool // host names and IPs, any port
+	hostPort map[string]bool // host:port
+	prefixes []netip.Prefix
+}
+
+// ParseUpstreams reads an allowlist. Entries are host names or IP addresses
+// (any port), host:port pairs, or CIDR ranges. Host names match as written:
+// an IP range doesn't allow the names that resolve into it.
+func ParseUpstreams(entries []string) (Upstreams, error) {
+	u := Upstreams{hosts: map[string]bool{}, hostPort: map[string]bool{}}
+	for _, e := range entries {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if strings.Contains(e, "/") {
+			p, err := netip.ParsePrefix(e)
+			if err != nil {
+				return Upstreams{}, fmt.Errorf("%q: %v", e, err)
+			}
+			u.prefixes = append(u.prefixes, p.Masked())
+			continue
+		}
+		if host, port, err := net.SplitHostPort(e); err == nil {
+			if host == "" || port == "" {
+				return Upstreams{}, fmt.Errorf("%q: host and port required", e)
+			}
+			u.hostPort[net.JoinHostPort(host, port)] = true
+			continue
+		}
+		if e == "" || (strings.ContainsAny(e, ":[]") && !isIP(e)) {
+			return Upstreams{}, fmt.Errorf("%q: not a host, host:port or CIDR range", e)
+		}
+		u.hosts[strings.Trim(e, "[]")] = true
+	}
+	return u, nil
+}
+
+func isIP(s string) bool {
+	_, err := netip.ParseAddr(strings.Trim(s, "[]"))
+	return err == nil
+}
+
+// Allows checks the host of the upstream URL against the allowlist.
+func (u Upstreams) Allows(upstream string) error {
+	p, err := url.Parse(upstream)
+	if err != nil || p.Hostname() == "" {
+		return fmt.Errorf("%w: %s", ErrUpstreamNotAllowed, upstream)
+	}
+	port := p.Port()
+	if port == "" {
+		port = "80"
+		if p.Scheme == "https" {
+			port = "443"
+		}
+	}
+	if !u.AllowsAddr(p.Hostname(), port) {
+		return fmt.Errorf("%w: %s is not in server.proxy_upstreams", ErrUpstreamNotAllowed, p.Host)
+	}
+	return nil
+}
+
+// AllowsAddr reports whether proxies may connect to host (a name or IP) on
+// port.
+func (u Upstreams) AllowsAddr(host, port string) bool {
+	host = strings.ToLower(host)
+	if u.hosts[host] || u.hostPort[net.JoinHostPort(host, port)] {
+		return true
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, p := range u.prefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetProxy replaces the hook's proxy settings; nil or zero settings remove
+// them.
+func (h *Hook) SetProxy(p *Proxy) error {
+	if p == nil || *p == (Proxy{}) {
+		h.Proxy = nil
+		return nil
+	}
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	pp := *p
+	pp.Upstream = strings.TrimRight(pp.Upstream, "/")
+	if pp.Mode == "" {
+		pp.Mode = ProxyRecord
+	}
+	h.Proxy = &pp
+	return nil
+}
+
+// EqualProxy compares two optional proxy settings.
+func EqualProxy(a, b *Proxy) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// Parents lists the IDs above id, closest first: "a/b/c" gives "a/b", "a".
+func (id ID) Parents() []ID {
+	var out []ID
+	s := string(id)
+	for {
+		i := strings.LastIndexByte(s, '/')
+		if i < 0 {
+			return out
+		}
+		s = s[:i]
+		out = append(out, ID(s))
+	}
+}
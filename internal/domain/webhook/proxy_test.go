@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseUpstreams(t *testing.T) {
+	tests := []struct {
+		entries []string
+		wantErr bool
+	}{
+		{entries: nil},
+		{entries: []string{"api.example.com", "api.example.com:8443", "10.0.0.0/8", "192.0.2.1", "::1", "[::1]:8080", "fd00::/8"}},
+		{entries: []string{" API.Example.com "}},
+		{entries: []string{""}, wantErr: true},
+		{entries: []string{"10.0.0.0/33"}, wantErr: true},
+		{entries: []string{"example.com/8"}, wantErr: true},
+		{entries: []string{":8080"}, wantErr: true},
+		{entries: []string{"example.com:"}, wantErr: true},
+		{entries: []string{"a:b:c"}, wantErr: true},
+		{entries: []string{"[example.com]"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		_, err := ParseUpstreams(tt.entries)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseUpstreams(%q) error = %v, want error %v", tt.entries, err, tt.wantErr)
+		}
+	}
+}
+
+func TestUpstreamsAllowsAddr(t *testing.T) {
+	allow, err := ParseUpstreams([]string{"api.example.com", "local.test:8080", "10.0.0.0/8", "192.0.2.1", "[::1]:9000", "fd00::/8"})
+	if err != nil {
+		t.Fatalf("ParseUpstreams: %v", err)
+	}
+	empty, err := ParseUpstreams(nil)
+	if err != nil {
+		t.Fatalf("ParseUpstreams: %v", err)
+	}
+	tests := []struct {
+		name  string
+		allow Upstreams
+		host  string
+		port  string
+		want  bool
+	}{
+		{name: "empty allowlist", allow: empty, host: "api.example.com", port: "443"},
+		{name: "zero allowlist", host: "127.0.0.1", port: "80"},
+		{name: "host any port", allow: allow, host: "api.example.com", port: "8443", want: true},
+		{name: "host case", allow: allow, host: "API.example.COM", port: "443", want: true},
+		{name: "subdomain", allow: allow, host: "evil.api.example.com", port: "443"},
+		{name: "host:port", allow: allow, host: "local.test", port: "8080", want: true},
+		{name: "host:port other port", allow: allow, host: "local.test", port: "8081"},
+		{name: "ip", allow: allow, host: "192.0.2.1", port: "22", want: true},
+		{name: "other ip", allow: allow, host: "192.0.2.2", port: "80"},
+		{name: "cidr", allow: allow, host: "10.1.2.3", port: "80", want: true},
+		{name: "ipv4-mapped in cidr", allow: allow, host: "::ffff:10.1.2.3", port: "80", want: true},
+		{name: "outside cidr", allow: allow, host: "11.0.0.1", port: "80"},
+		{name: "ipv6 host:port", allow: allow, host: "::1", port: "9000", want: true},
+		{name: "ipv6 other port", allow: allow, host: "::1", port: "9001"},
+		{name: "ipv6 cidr", allow: allow, host: "fd00::1", port: "443", want: true},
+		{name: "name not matched by cidr", allow: allow, host: "ten.example", port: "80"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.allow.AllowsAddr(tt.host, tt.port); got != tt.want {
+				t.Errorf("AllowsAddr(%q, %q) = %v, want %v", tt.host, tt.port, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpstreamsAllows(t *testing.T) {
+	allow, err := ParseUpstreams([]string{"api.example.com:443", "local.test:80"})
+	if err != nil {
+		t.Fatalf("ParseUpstreams: %v", err)
+	}
+	tests := []struct {
+		upstream string
+		want     bool
+	}{
+		{upstream: "https://api.example.com", want: true},
+		{upstream: "https://api.example.com:443/v1", want: true},
+		{upstream: "http://api.example.com"},
+		{upstream: "http://local.test", want: true},
+		{upstream: "https://local.test"},
+		{upstream: "not a url"},
+	}
+	for _, tt := range tests {
+		err := allow.Allows(tt.upstream)
+		if (err == nil) != tt.want {
+			t.Errorf("Allows(%q) = %v, want allowed %v", tt.upstream, err, tt.want)
+		}
+		if err != nil && !errors.Is(err, ErrUpstreamNotAllowed) {
+			t.Errorf("Allows(%q) = %v, want ErrUpstreamNotAllowed", tt.upstream, err)
+		}
+	}
+}
+
+func TestProxyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxy   Proxy
+		wantErr bool
+	}{
+		{name: "record", proxy: Proxy{Upstream: "https://api.example.com"}},
+		{name: "replay without upstream", proxy: Proxy{Mode: ProxyReplay}},
+		{name: "record without upstream", proxy: Proxy{Mode: ProxyRecord}, wantErr: true},
+		{name: "default mode records", proxy: Proxy{}, wantErr: true},
+		{name: "unknown mode", proxy: Proxy{Mode: "mirror", Upstream: "https://api.example.com"}, wantErr: true},
+		{name: "not http", proxy: Proxy{Upstream: "ftp://api.example.com"}, wantErr: true},
+		{name: "no host", proxy: Proxy{Upstream: "https://"}, wantErr: true},
+		{name: "relative", proxy: Proxy{Upstream: "/v1"}, wantErr: true},
+		{name: "ip literal", proxy: Proxy{Upstream: "http://[::1]:8080"}},
+		{name: "cloudevents", proxy: Proxy{Upstream: "https://a.test", CloudEvents: CloudEventsBinary, EventType: "t"}},
+		{name: "unknown cloudevents mode", proxy: Proxy{Upstream: "https://a.test", CloudEvents: "batch"}, wantErr: true},
+		{name: "event type without cloudevents", proxy: Proxy{Upstream: "https://a.test", EventType: "t"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.proxy.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidProxy) {
+				t.Fatalf("Validate() = %v, want ErrInvalidProxy", err)
+			}
+		})
+	}
+}
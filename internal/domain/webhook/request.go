@@ -0,0 +1,49 @@
+package webhook
+
+import "time"
+
+// MaxLoggedBody bounds the body kept of a captured request; request logs
+// cut longer ones and set BodyTruncated.
+const MaxLoggedBody = 64 << 10
+
+// Request is a captured hook invocation.
+type Request struct {
+	ID      string
+	HookID  ID
+	Method  string
+	Path    string
+	Query   string
+	Headers map[string][]string
+	Body    []byte
+	// BodyTruncated is set when Body holds only the first MaxLoggedBody
+	// bytes of the body.
+	BodyTruncated bool
+	RemoteAddr    string
+	Status        int // status served to the caller; 0 when none was
+	Received      time.Time
+	Duration      time.Duration
+	// Fault is the injected fault (see Fault.Kind) that cut the response
+	// short: abort or truncate.
+	Fault string
+	// Validation is the result of checking the body against the hook's
+	// schema; nil when the hook has none.
+	Validation *ValidationResult
+	// CloudEvent is set when the request carried a CloudEvent.
+	CloudEvent *CloudEvent
+}
+
+func (r Request) Clone() Request {
+	c := r
+	if r.Headers != nil {
+		c.Headers = make(map[string][]string, len(r.Headers))
+		for k, v := range r.Headers {
+			c.Headers[k] = append([]string(nil), v...)
+		}
+	}
+	if r.Body != nil {
+		c.Body = append([]byte(nil), r.Body...)
+	}
+	c.Validation = r.Validation.clone()
+	c.CloudEvent = r.CloudEvent.clone()
+	return c
+}
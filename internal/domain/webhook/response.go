@@ -0,0 +1,192 @@
+package webhook
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"maps"
+	"mime"
+	"net/http"
+	"slices"
+)
+
+// MethodAny accepts every method a hook can be invoked with, as a Method or
+// as a key of Responses.
+const MethodAny = "ANY"
+
+// Methods lists the methods hooks can be invoked with.
+var Methods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions}
+
+// Response is what a hook answers to one method. The body is Body, or the
+// bytes of BodyBase64, or the contents of BodyFile (a path inside the
+// server's body directory); at most one of them is set.
+type Response struct {
+	Body       string `json:"body" required:"false" doc:"Response body"`
+	BodyBase64 []byte `json:"body_base64,omitempty" doc:"Binary response body, base64-encoded; replaces body"`
+	BodyFile   string `json:"body_file,omitempty" doc:"Serve this file from the server's body directory (server.body_dir); replaces body"`
+	// ContentType is sent as Content-Type, overriding Headers.
+	ContentType string            `json:"content_type,omitempty" doc:"Content-Type of the response, overriding headers"`
+	Headers     map[string]string `json:"headers" required:"false" doc:"Response headers"`
+	// HeaderValues are headers sent once per value, like several
+	// Set-Cookie, which can't be joined into one.
+	HeaderValues map[string][]string `json:"header_values,omitempty" doc:"Headers sent once per value, such as several Set-Cookie"`
+	Status       int                 `json:"status" required:"false" doc:"Response status code (default 200)"` // 0 means 200
+	// Template makes Body and the header values Go templates (see Render).
+	Template bool `json:"template,omitempty" doc:"Render body and header values as Go templates, with the request and the webhook's key-value store"`
+	// Script is a CEL expression computing the response from the request;
+	// the other fields are its defaults (see ApplyScript).
+	Script string `json:"script,omitempty" doc:"CEL expression returning the response, e.g. {\"status\": 201, \"body\": request.json}; the other fields are defaults"`
+	// Plugin names a WebAssembly plugin computing the response, like Script.
+	Plugin string `json:"plugin,omitempty" doc:"Name of the WebAssembly plugin computing the response; the other fields are defaults"`
+}
+
+// Validate checks the status, that at most one body is set and that
+// templates parse. Scripts are compiled, and plugins looked up, by the
+// service.
+func (r Response) Validate() error {
+	if r.Status != 0 && (r.Status < 100 || r.Status > 599) {
+		return ErrInvalidStatus
+	}
+	bodies := 0
+	for _, set := range []bool{r.Body != "", len(r.BodyBase64) > 0, r.BodyFile != ""} {
+		if set {
+			bodies++
+		}
+	}
+	if bodies > 1 {
+		return fmt.Errorf("%w: set only one of body, body_base64 and body_file", ErrInvalidBody)
+	}
+	if r.BodyFile != "" && (!fs.ValidPath(r.BodyFile) || r.BodyFile == ".") {
+		return fmt.Errorf("%w: body_file %q must be a relative path inside the body directory", ErrInvalidBody, r.BodyFile)
+	}
+	if r.ContentType != "" {
+		if _, _, err := mime.ParseMediaType(r.ContentType); err != nil {
+			return fmt.Errorf("%w: content_type %q: %v", ErrInvalidBody, r.ContentType, err)
+		}
+	}
+	if r.Plugin != "" {
+		return r.validatePlugin()
+	}
+	if r.Script != "" {
+		return r.validateScript()
+	}
+	if r.Template {
+		return r.validateTemplates()
+	}
+	return nil
+}
+
+// Raw reports whether the body must be sent as is: it is binary, comes from
+// a file or has an explicit content type. Other bodies are plain text, which
+// servers may still quote as a JSON string for old clients.
+func (r Response) Raw() bool {
+	return len(r.BodyBase64) > 0 || r.BodyFile != "" || r.ContentType != ""
+}
+
+// ResponseFor picks the response to an invocation with method: the entry of
+// Responses for that method, else the hook's own Response if it is the
+// hook's Method, else the "ANY" entry, else the hook's own response
+// if Method is "ANY". ok is false when the hook doesn't accept method.
+func (h *Hook) ResponseFor(method string) (_ Response, ok bool) {
+	m := normalizeMethod(method)
+	own := h.Response
+	if r, ok := h.Responses[m]; ok {
+		return r.withDefaults(), true
+	}
+	if h.Method == m {
+		return own.withDefaults(), true
+	}
+	if r, ok := h.Responses[MethodAny]; ok {
+		return r.withDefaults(), true
+	}
+	if h.Method == MethodAny {
+		return own.withDefaults(), true
+	}
+	return Response{}, false
+}
+
+// Allow lists the accepted methods (for the Allow header of a 405).
+func (h *Hook) Allow() []string {
+	var out []string
+	for _, m := range Methods {
+		if h.MatchesMethod(m) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// SetResponses replaces the per-method responses; keys are normalized to
+// upper case. nil or empty clears them.
+func (h *Hook) SetResponses(responses map[string]Response) error {
+	if len(responses) == 0 {
+		h.Responses = nil
+		return nil
+	}
+	out := make(map[string]Response, len(responses))
+	for method, r := range responses {
+		m := normalizeMethod(method)
+		if !isAllowedMethod(m) {
+			return ErrUnsupportedMethod
+		}
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", m, err)
+		}
+		out[m] = r.Clone()
+	}
+	h.Responses = out
+	return nil
+}
+
+// SetResponse replaces the hook's own response; status 0 means 200.
+func (h *Hook) SetResponse(r Response) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	h.Response = r.withDefaults().Clone()
+	return nil
+}
+
+func (r Response) withDefaults() Response {
+	if r.Status == 0 {
+		r.Status = http.StatusOK
+	}
+	return r
+}
+
+// Equal reports whether r and o answer the same.
+func (r Response) Equal(o Response) bool {
+	return r.Body == o.Body && bytes.Equal(r.BodyBase64, o.BodyBase64) && r.BodyFile == o.BodyFile &&
+		r.ContentType == o.ContentType && r.Status == o.Status && maps.Equal(r.Headers, o.Headers) &&
+		maps.EqualFunc(r.HeaderValues, o.HeaderValues, slices.Equal[[]string]) &&
+		r.Template == o.Template && r.Script == o.Script && r.Plugin == o.Plugin
+}
+
+// EqualResponses compares two Responses maps (nil equals empty).
+func EqualResponses(a, b map[string]Response) bool {
+	return maps.EqualFunc(a, b, Response.Equal)
+}
+
+func cloneResponses(in map[string]Response) map[string]Response {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]Response, len(in))
+	for m, r := range in {
+		out[m] = r.Clone()
+	}
+	return out
+}
+
+// Clone returns a deep copy of r.
+func (r Response) Clone() Response {
+	r.Headers = cloneHeaders(r.Headers)
+	if r.HeaderValues != nil {
+		r.HeaderValues = maps.Clone(r.HeaderValues)
+		for k, vs := range r.HeaderValues {
+			r.HeaderValues[k] = slices.Clone(vs)
+		}
+	}
+	r.BodyBase64 = bytes.Clone(r.BodyBase64)
+	return r
+}
@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+)
+
+var (
+	ErrInvalidScript = errors.New("invalid script")
+	// ErrScriptFailed is returned when a script fails while serving a
+	// request, or returns something that isn't a response.
+	ErrScriptFailed = errors.New("script failed")
+)
+
+// MaxScriptLen bounds the source of a script.
+const MaxScriptLen = 64 << 10
+
+// ScriptResult is what a response's script or plugin returns; zero fields
+// leave the response's own.
+type ScriptResult struct {
+	Status int
+	// Headers are set over the response's headers.
+	Headers map[string]string
+	// Body replaces the response body when not nil.
+	Body *string
+	// ContentType applies unless the response or Headers set one, e.g.
+	// application/json for a body the script returned as an object.
+	ContentType string
+}
+
+// validateScript checks what can be checked without compiling the script.
+func (r Response) validateScript() error {
+	switch {
+	case len(r.Script) > MaxScriptLen:
+		return fmt.Errorf("%w: longer than %d bytes", ErrInvalidScript, MaxScriptLen)
+	case r.Template:
+		return fmt.Errorf("%w: a response is either a script or a template", ErrInvalidScript)
+	case len(r.BodyBase64) > 0 || r.BodyFile != "":
+		return fmt.Errorf("%w: scripts can't be combined with body_base64 or body_file", ErrInvalidScript)
+	}
+	return nil
+}
+
+// ApplyScript returns r with the result of its script or plugin.
+func (r Response) ApplyScript(res ScriptResult) (Response, error) {
+	if res.Status != 0 && (res.Status < 100 || res.Status > 599) {
+		return Response{}, fmt.Errorf("status %d: %w", res.Status, ErrInvalidStatus)
+	}
+	if res.Body != nil && len(*res.Body) > maxRendered {
+		return Response{}, fmt.Errorf("body larger than %d bytes", maxRendered)
+	}
+	out := r.Clone()
+	if res.Status != 0 {
+		out.Status = res.Status
+	}
+	for k, v := range res.Headers {
+		// Replace the response's header whatever its case.
+		maps.DeleteFunc(out.Headers, func(o, _ string) bool { return strings.EqualFold(o, k) })
+		if out.Headers == nil {
+			out.Headers = map[string]string{}
+		}
+		out.Headers[k] = v
+	}
+	if res.Body != nil {
+		out.Body = *res.Body
+	}
+	if res.ContentType != "" && out.ContentType == "" && !hasHeader(out.Headers, "Content-Type") {
+		out.ContentType = res.ContentType
+	}
+	return out, nil
+}
+
+func hasHeader(headers map[string]string, name string) bool {
+	for k := range headers {
+		if strings.EqualFold(k, name) {
+			return true
+		}
+	}
+	return false
+}
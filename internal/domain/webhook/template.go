@@ -0,0 +1,205 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+var (
+	ErrInvalidTemplate = errors.New("invalid template")
+	// ErrStoreFull is returned when a put would take a hook's store past
+	// MaxStoreKeys or MaxStoreBytes, or its value is over MaxStoreValue.
+	ErrStoreFull = errors.New("key-value store is full")
+)
+
+const (
+	// MaxStoreKeys bounds the keys of a hook's key-value store.
+	MaxStoreKeys = 10000
+	// MaxStoreValue bounds the size of one value in a key-value store.
+	MaxStoreValue = 64 << 10
+	// MaxStoreBytes bounds the size of a hook's key-value store, keys and
+	// values together.
+	MaxStoreBytes = 16 << 20
+)
+
+// maxRendered caps the size of a rendered body or header.
+const maxRendered = 1 << 20
+
+// Store is a hook's key-value store as templates see it: values put by one
+// invocation are there for the next ones.
+type Store interface {
+	Get(key string) (value string, ok bool, err error)
+	Put(key, value string) error
+	Delete(key string) error
+	All() (map[string]string, error)
+}
+
+// TemplateData is the request templates are rendered with.
+type TemplateData struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   string
+	// JSON is the body decoded as JSON, with numbers as json.Number; nil
+	// when the body isn't JSON.
+	JSON any
+	// CloudEvent is set when the request carries a CloudEvent.
+	CloudEvent *CloudEvent
+}
+
+// NewTemplateData decodes body for the JSON field, and parses the
+// CloudEvent the request carries.
+func NewTemplateData(method, path string, query url.Values, header http.Header, body []byte) TemplateData {
+	d := TemplateData{Method: method, Path: path, Query: query, Header: header, Body: string(body)}
+	d.CloudEvent = ParseCloudEvent(header, body)
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if dec.Decode(&v) == nil && !dec.More() {
+		d.JSON = v
+	}
+	return d
+}
+
+// templateFuncs are the functions templates can call. The kv functions use
+// the hook's store; keys and values that aren't strings are converted with
+// fmt.Sprint and toJSON respectively. status replaces the response status.
+func templateFuncs(store Store, status *int) template.FuncMap {
+	return template.FuncMap{
+		"kvGet": func(key any) (string, error) {
+			v, _, err := store.Get(fmt.Sprint(key))
+			return v, err
+		},
+		"kvHas": func(key any) (bool, error) {
+			_, ok, err := store.Get(fmt.Sprint(key))
+			return ok, err
+		},
+		"kvPut": func(key, value any) (string, error) {
+			s, err := stringValue(value)
+			if err != nil {
+				return "", err
+			}
+			return "", store.Put(fmt.Sprint(key), s)
+		},
+		"kvDelete": func(key any) (string, error) {
+			return "", store.Delete(fmt.Sprint(key))
+		},
+		"kvAll": func() (map[string]string, error) {
+			return store.All()
+		},
+		"toJSON": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"fromJSON": func(s string) (any, error) {
+			dec := json.NewDecoder(strings.NewReader(s))
+			dec.UseNumber()
+			var v any
+			err := dec.Decode(&v)
+			return v, err
+		},
+		"status": func(code int) (string, error) {
+			if code < 100 || code > 599 {
+				return "", fmt.Errorf("status %d: %w", code, ErrInvalidStatus)
+			}
+			*status = code
+			return "", nil
+		},
+	}
+}
+
+func stringValue(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case json.Number:
+		return v.String(), nil
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+func parseTemplate(name, text string, funcs template.FuncMap) (*template.Template, error) {
+	t, err := template.New(name).Option("missingkey=zero").Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return t, nil
+}
+
+// validateTemplates parses the body and header templates of r.
+func (r Response) validateTemplates() error {
+	if len(r.BodyBase64) > 0 || r.BodyFile != "" {
+		return fmt.Errorf("%w: only body can be a template", ErrInvalidTemplate)
+	}
+	funcs := templateFuncs(nil, nil)
+	if _, err := parseTemplate("body", r.Body, funcs); err != nil {
+		return err
+	}
+	for _, k := range slices.Sorted(maps.Keys(r.Headers)) {
+		if _, err := parseTemplate("header "+k, r.Headers[k], funcs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Render executes the templates of r with data, returning the response with
+// the rendered body and headers, and the status a template may have set.
+// r is returned as is unless it is a template.
+func (r Response) Render(data TemplateData, store Store) (Response, error) {
+	if !r.Template {
+		return r, nil
+	}
+	out := r.Clone()
+	funcs := templateFuncs(store, &out.Status)
+	var err error
+	if out.Body, err = execute("body", r.Body, funcs, data); err != nil {
+		return Response{}, err
+	}
+	for _, k := range slices.Sorted(maps.Keys(r.Headers)) {
+		if out.Headers[k], err = execute("header "+k, r.Headers[k], funcs, data); err != nil {
+			return Response{}, err
+		}
+	}
+	return out, nil
+}
+
+func execute(name, text string, funcs template.FuncMap, data TemplateData) (string, error) {
+	t, err := parseTemplate(name, text, funcs)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&limitedWriter{w: &buf, n: maxRendered}, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+var errTooLarge = fmt.Errorf("rendered template larger than %d bytes", maxRendered)
+
+// limitedWriter fails once more than n bytes were written, which stops the
+// template.
+type limitedWriter struct {
+	w *bytes.Buffer
+	n int
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if l.w.Len()+len(p) > l.n {
+		return 0, errTooLarge
+	}
+	return l.w.Write(p)
+}
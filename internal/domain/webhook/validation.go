@@ -0,0 +1,112 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Validation modes.
+const (
+	// ValidationReject answers requests whose body doesn't match the schema
+	// with 422 and the errors.
+	ValidationReject = "reject"
+	// ValidationFlag serves them as usual; the result is only captured.
+	ValidationFlag = "flag"
+)
+
+var ErrInvalidValidation = errors.New("invalid request validation settings")
+
+// RequestValidation checks the bodies of incoming requests against a JSON
+// Schema, so a hook doubles as a contract test of the requests it receives.
+// The result is captured with each request (Request.Validation).
+type RequestValidation struct {
+	Schema json.RawMessage `json:"schema,omitempty" doc:"JSON Schema the request bodies must match (draft 2020-12 unless $schema says otherwise)"`
+	Mode   string          `json:"mode,omitempty" enum:"reject,flag" doc:"reject answers 422 with the errors; flag serves the response and only records the result (default reject)"`
+}
+
+// Validate checks the mode and that the schema is a JSON object or boolean.
+// Whether it is a valid JSON Schema is up to the schema compiler.
+func (v RequestValidation) Validate() error {
+	switch v.Mode {
+	case "", ValidationReject, ValidationFlag:
+	default:
+		return fmt.Errorf("%w: mode must be %s or %s", ErrInvalidValidation, ValidationReject, ValidationFlag)
+	}
+	var schema any
+	if err := json.Unmarshal(v.Schema, &schema); err != nil {
+		return fmt.Errorf("%w: schema: %v", ErrInvalidValidation, err)
+	}
+	switch schema.(type) {
+	case map[string]any, bool:
+		return nil
+	default:
+		return fmt.Errorf("%w: schema must be an object or a boolean", ErrInvalidValidation)
+	}
+}
+
+// Rejects reports whether invalid requests are answered with 422.
+func (v RequestValidation) Rejects() bool {
+	return v.Mode == "" || v.Mode == ValidationReject
+}
+
+// SetValidation replaces the hook's request validation; nil or settings
+// without a schema remove it.
+func (h *Hook) SetValidation(v *RequestValidation) error {
+	if v == nil || len(v.Schema) == 0 {
+		h.Validation = nil
+		return nil
+	}
+	if err := v.Validate(); err != nil {
+		return err
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, v.Schema); err != nil {
+		return fmt.Errorf("%w: schema: %v", ErrInvalidValidation, err)
+	}
+	vv := RequestValidation{Schema: compact.Bytes(), Mode: v.Mode}
+	if vv.Mode == "" {
+		vv.Mode = ValidationReject
+	}
+	h.Validation = &vv
+	return nil
+}
+
+// EqualValidation compares two optional request validations.
+func EqualValidation(a, b *RequestValidation) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Mode == b.Mode && bytes.Equal(a.Schema, b.Schema)
+}
+
+func (v *RequestValidation) clone() *RequestValidation {
+	if v == nil {
+		return nil
+	}
+	return &RequestValidation{Schema: bytes.Clone(v.Schema), Mode: v.Mode}
+}
+
+// ValidationResult is the outcome of validating a request body.
+type ValidationResult struct {
+	Valid  bool              `json:"valid"`
+	Errors []ValidationError `json:"errors,omitempty"`
+}
+
+// ValidationError is a mismatch between a request body and the schema.
+type ValidationError struct {
+	// Location is a JSON Pointer into the body, e.g. /items/0/id; empty for
+	// the body as a whole.
+	Location string `json:"location"`
+	Message  string `json:"message"`
+}
+
+func (r *ValidationResult) clone() *ValidationResult {
+	if r == nil {
+		return nil
+	}
+	c := *r
+	c.Errors = append([]ValidationError(nil), r.Errors...)
+	return &c
+}
@@ -11,10 +11,13 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/golang-jwt/jwt/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type TokenContextKey struct{}
@@ -35,6 +38,10 @@ type Config struct {
 	// Optional overrides
 	HTTPClient *http.Client
 	CacheTTL   time.Duration
+
+	// OnJWKSRefresh is called after every JWKS fetch attempt with its result
+	// (nil on success). Used for metrics; must not block.
+	OnJWKSRefresh func(err error)
 }
 
 func (c Config) Valid() bool {
@@ -42,7 +49,8 @@ func (c Config) Valid() bool {
 }
 
 type Middleware struct {
-	cfg Config
+	// cfg is swapped as a whole by Update; requests read it once.
+	cfg atomic.Pointer[Config]
 
 	mu        sync.RWMutex
 	cachedAt  time.Time
@@ -55,27 +63,49 @@ func New(cfg Config) *Middleware {
 	if ttl == 0 {
 		ttl = 5 * time.Minute
 	}
-	return &Middleware{
-		cfg:       cfg,
+	m := &Middleware{
 		keys:      map[string]*rsa.PublicKey{},
 		cachedTTL: ttl,
 	}
+	m.cfg.Store(&cfg)
+	return m
+}
+
+// Update atomically replaces the configuration (config reload). Requests in
+// flight finish with the config they started with. Cached keys are dropped
+// when the JWKS URL changes.
+func (m *Middleware) Update(cfg Config) {
+	if cfg.OnJWKSRefresh == nil {
+		cfg.OnJWKSRefresh = m.config().OnJWKSRefresh
+	}
+	old := m.cfg.Swap(&cfg)
+	if old.JWKSURL != cfg.JWKSURL {
+		m.mu.Lock()
+		m.keys = map[string]*rsa.PublicKey{}
+		m.cachedAt = time.Time{}
+		m.mu.Unlock()
+	}
+}
+
+func (m *Middleware) config() *Config {
+	return m.cfg.Load()
 }
 
 func (m *Middleware) Huma(api huma.API) func(huma.Context, func(huma.Context)) {
 	return func(hctx huma.Context, next func(huma.Context)) {
-		if !m.cfg.EnableAuthOnOptions && strings.EqualFold(hctx.Method(), http.MethodOptions) {
+		cfg := m.config()
+		if !cfg.EnableAuthOnOptions && strings.EqualFold(hctx.Method(), http.MethodOptions) {
 			next(hctx)
 			return
 		}
 
-		if !m.cfg.Valid() {
+		if !cfg.Valid() {
 			// Config missing: treat as server misconfiguration.
 			writeAuthErr(api, hctx, http.StatusServiceUnavailable, "auth not configured")
 			return
 		}
 
-		raw, err := extractToken(hctx, m.cfg.TokenExtractors)
+		raw, err := extractToken(hctx, cfg.TokenExtractors)
 		if err != nil {
 			writeAuthErr(api, hctx, http.StatusUnauthorized, err.Error())
 			return
@@ -86,7 +116,7 @@ func (m *Middleware) Huma(api huma.API) func(huma.Context, func(huma.Context)) {
 		}
 
 		claims := jwt.MapClaims{}
-		parsed, err := jwt.ParseWithClaims(raw, claims, m.keyFunc(hctx.Context()), jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}))
+		parsed, err := jwt.ParseWithClaims(raw, claims, m.keyFunc(hctx.Context(), cfg), jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}))
 		if err != nil {
 			writeAuthErr(api, hctx, http.StatusUnauthorized, "invalid token")
 			return
@@ -96,11 +126,11 @@ func (m *Middleware) Huma(api huma.API) func(huma.Context, func(huma.Context)) {
 			return
 		}
 
-		if !verifyIssuer(claims, m.cfg.Issuer) {
+		if !verifyIssuer(claims, cfg.Issuer) {
 			writeAuthErr(api, hctx, http.StatusUnauthorized, "invalid issuer")
 			return
 		}
-		if !verifyAudience(claims, m.cfg.Audience) {
+		if !verifyAudience(claims, cfg.Audience) {
 			writeAuthErr(api, hctx, http.StatusUnauthorized, "invalid audience")
 			return
 		}
@@ -110,12 +140,61 @@ func (m *Middleware) Huma(api huma.API) func(huma.Context, func(huma.Context)) {
 	}
 }
 
+// IfConfigured is Huma for routes that stay open while auth isn't
+// configured: it requires a valid token only once it is.
+func (m *Middleware) IfConfigured(api huma.API) func(huma.Context, func(huma.Context)) {
+	auth := m.Huma(api)
+	return func(hctx huma.Context, next func(huma.Context)) {
+		if !m.config().Valid() {
+			next(hctx)
+			return
+		}
+		auth(hctx, next)
+	}
+}
+
+// Ping checks that the JWKS endpoint is reachable and answers 2xx, without
+// touching the key cache (readiness check). It passes while auth is not
+// configured, since a reload may turn it on or off.
+func (m *Middleware) Ping(ctx context.Context) error {
+	cfg := m.config()
+	if !cfg.Valid() {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.JWKSURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient(cfg).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("jwks fetch failed: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func httpClient(cfg *Config) *http.Client {
+	if cfg.HTTPClient != nil {
+		return cfg.HTTPClient
+	}
+	return &http.Client{Timeout: 10 * time.Second}
+}
+
 func writeAuthErr(api huma.API, ctx huma.Context, status int, msg string) {
+	// Only the failure reason is recorded; never the token or the Authorization header.
+	trace.SpanFromContext(ctx.Context()).AddEvent("auth.failure", trace.WithAttributes(
+		attribute.Int("http.response.status_code", status),
+		attribute.String("webhookd.auth.reason", msg),
+	))
+
 	se := huma.NewError(status, msg)
 	_ = huma.WriteErr(api, ctx, status, msg, se)
 }
 
-func (m *Middleware) keyFunc(reqCtx context.Context) jwt.Keyfunc {
+func (m *Middleware) keyFunc(reqCtx context.Context, cfg *Config) jwt.Keyfunc {
 	return func(t *jwt.Token) (any, error) {
 		kid, _ := t.Header["kid"].(string)
 		if kid == "" {
@@ -128,7 +207,7 @@ func (m *Middleware) keyFunc(reqCtx context.Context) jwt.Keyfunc {
 		}
 
 		// Refresh JWKS and try again.
-		if err := m.refreshKeys(reqCtx); err != nil {
+		if err := m.refreshKeys(reqCtx, cfg); err != nil {
 			return nil, err
 		}
 		if key := m.getCachedKey(kid); key != nil {
@@ -154,7 +233,7 @@ type jwks struct {
 	} `json:"keys"`
 }
 
-func (m *Middleware) refreshKeys(ctx context.Context) error {
+func (m *Middleware) refreshKeys(ctx context.Context, cfg *Config) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -163,12 +242,17 @@ func (m *Middleware) refreshKeys(ctx context.Context) error {
 		return nil
 	}
 
-	client := m.cfg.HTTPClient
-	if client == nil {
-		client = &http.Client{Timeout: 10 * time.Second}
+	err := m.fetchKeysLocked(ctx, cfg)
+	if cfg.OnJWKSRefresh != nil {
+		cfg.OnJWKSRefresh(err)
 	}
+	return err
+}
+
+func (m *Middleware) fetchKeysLocked(ctx context.Context, cfg *Config) error {
+	client := httpClient(cfg)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.cfg.JWKSURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.JWKSURL, nil)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,272 @@
+// Package celscript runs the scripts of hooks' responses (ports.ScriptEngine)
+// as CEL expressions (https://cel.dev). CEL has no statements, loops or I/O:
+// a script is an expression over the request, so it can't reach the network
+// or files, and every evaluation is bounded by a cost limit and a timeout.
+package celscript
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"cel.dev/cel-go/cel"
+	"cel.dev/cel-go/common/types"
+	"cel.dev/cel-go/common/types/ref"
+	"cel.dev/cel-go/common/types/traits"
+	"cel.dev/cel-go/ext"
+	"cel.dev/cel-go/interpreter"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"webhookd/internal/application/ports"
+	"webhookd/internal/domain/webhook"
+)
+
+const (
+	// CostLimit bounds the work of one evaluation, in CEL cost units (about
+	// one per operation, more for operations on large strings and lists). It
+	// also bounds the memory a script can allocate.
+	CostLimit = 1_000_000
+	// Timeout bounds the run time of one evaluation.
+	Timeout = 100 * time.Millisecond
+)
+
+// interruptCheckFrequency is how many loop iterations run between checks of
+// the timeout.
+const interruptCheckFrequency = 100
+
+// Engine compiles scripts. They see the request as the variable request,
+// with the fields method, path, query (first value of each parameter),
+// headers (lower-case names, values joined with ", "), body (a string) and
+// json (the body decoded as JSON, or null) and cloudevent (the attributes of
+// the CloudEvent the request carries, or null). The strings, encoders and
+// math extensions are available.
+type Engine struct {
+	env *cel.Env
+}
+
+func NewEngine() (*Engine, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("request", cel.MapType(cel.StringType, cel.DynType)),
+		cel.OptionalTypes(),
+		ext.Strings(),
+		ext.Encoders(),
+		ext.Math(),
+		cel.ParserExpressionSizeLimit(webhook.MaxScriptLen),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Engine{env: env}, nil
+}
+
+var _ ports.ScriptEngine = (*Engine)(nil)
+
+func (e *Engine) Compile(source string) (ports.Script, error) {
+	ast, iss := e.env.Compile(source)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	switch t := ast.OutputType(); t.Kind() {
+	case types.MapKind, types.StringKind, types.BytesKind, types.DynKind:
+	default:
+		return nil, fmt.Errorf("must return a map with status, headers and body, or the body as a string; returns %s", t)
+	}
+	prg, err := e.env.Program(ast,
+		cel.CostLimit(CostLimit),
+		cel.InterruptCheckFrequency(interruptCheckFrequency),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return script{prg}, nil
+}
+
+type script struct{ prg cel.Program }
+
+func (s script) Run(ctx context.Context, req webhook.TemplateData) (webhook.ScriptResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+	out, _, err := s.prg.ContextEval(ctx, map[string]any{"request": request(req)})
+	if err != nil {
+		var cancelled interpreter.EvalCancelledError
+		switch {
+		case errors.As(err, &cancelled) && cancelled.Cause == interpreter.CostLimitExceeded:
+			return webhook.ScriptResult{}, fmt.Errorf("exceeded the cost limit of %d", CostLimit)
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			return webhook.ScriptResult{}, fmt.Errorf("took longer than %v", Timeout)
+		}
+		return webhook.ScriptResult{}, err
+	}
+	return result(out)
+}
+
+// request is the request variable of scripts.
+func request(d webhook.TemplateData) map[string]any {
+	return map[string]any{
+		"method":     d.Method,
+		"path":       d.Path,
+		"query":      firstValues(d.Query),
+		"headers":    joinedHeaders(d.Header),
+		"body":       d.Body,
+		"json":       jsonValue(d.JSON),
+		"cloudevent": cloudEvent(d.CloudEvent),
+	}
+}
+
+// cloudEvent returns the attributes of e by their CloudEvents names, and
+// the mode it arrived in.
+func cloudEvent(e *webhook.CloudEvent) any {
+	if e == nil {
+		return nil
+	}
+	return map[string]any{
+		"mode":            e.Mode,
+		"specversion":     e.SpecVersion,
+		"id":              e.ID,
+		"source":          e.Source,
+		"type":            e.Type,
+		"subject":         e.Subject,
+		"time":            e.Time,
+		"datacontenttype": e.DataContentType,
+	}
+}
+
+func firstValues(q url.Values) map[string]string {
+	out := make(map[string]string, len(q))
+	for k, v := range q {
+		if len(v) > 0 {
+			out[k] = v[0]
+		}
+	}
+	return out
+}
+
+func joinedHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		out[strings.ToLower(k)] = strings.Join(v, ", ")
+	}
+	return out
+}
+
+// jsonValue converts the json.Numbers of v to int64 when they are integers,
+// else to float64, since CEL doesn't know json.Number.
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[k] = jsonValue(e)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = jsonValue(e)
+		}
+		return out
+	}
+	return v
+}
+
+// result converts what a script returned: a map with status, headers and
+// body, or the body alone.
+func result(v ref.Val) (webhook.ScriptResult, error) {
+	var res webhook.ScriptResult
+	m, ok := v.(traits.Mapper)
+	if !ok {
+		err := setBody(&res, v)
+		return res, err
+	}
+	it := m.Iterator()
+	for it.HasNext() == types.True {
+		k := it.Next()
+		key, ok := k.(types.String)
+		if !ok {
+			return res, fmt.Errorf("returned a map with key %v; keys are status, headers and body", k)
+		}
+		val := m.Get(k)
+		var err error
+		switch key {
+		case "status":
+			res.Status, err = status(val)
+		case "headers":
+			res.Headers, err = headers(val)
+		case "body":
+			err = setBody(&res, val)
+		default:
+			err = fmt.Errorf("returned a map with key %q; keys are status, headers and body", string(key))
+		}
+		if err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+func status(v ref.Val) (int, error) {
+	switch v := v.(type) {
+	case types.Int:
+		if v >= 0 && v <= math.MaxInt32 {
+			return int(v), nil
+		}
+	case types.Uint:
+		if v <= math.MaxInt32 {
+			return int(v), nil
+		}
+	case types.Double:
+		if v == types.Double(math.Trunc(float64(v))) && v >= 0 && v <= math.MaxInt32 {
+			return int(v), nil
+		}
+	}
+	return 0, fmt.Errorf("status must be an int, got %s %v", v.Type(), v)
+}
+
+var headersType = reflect.TypeOf(map[string]string{})
+
+func headers(v ref.Val) (map[string]string, error) {
+	h, err := v.ConvertToNative(headersType)
+	if err != nil {
+		return nil, fmt.Errorf("headers must be a map of strings: %v", err)
+	}
+	return h.(map[string]string), nil
+}
+
+var jsonType = reflect.TypeOf(&structpb.Value{})
+
+// setBody sets the body: strings and bytes as they are, anything else
+// encoded as JSON.
+func setBody(res *webhook.ScriptResult, v ref.Val) error {
+	var body string
+	switch v := v.(type) {
+	case types.String:
+		body = string(v)
+	case types.Bytes:
+		body, res.ContentType = string(v), "application/octet-stream"
+	default:
+		pb, err := v.ConvertToNative(jsonType)
+		if err != nil {
+			return fmt.Errorf("body can't be encoded as JSON: %v", err)
+		}
+		b, err := json.Marshal(pb.(*structpb.Value).AsInterface())
+		if err != nil {
+			return fmt.Errorf("body can't be encoded as JSON: %v", err)
+		}
+		body, res.ContentType = string(b), "application/json"
+	}
+	res.Body = &body
+	return nil
+}
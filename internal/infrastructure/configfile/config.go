@@ -1,68 +1,217 @@
 package configfile
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"net"
 	"os"
-	"strconv"
 	"strings"
+
+	"webhookd/internal/domain/webhook"
 )
 
 type Config struct {
-	Server ServerConfig `json:"server"`
-	DB     DBConfig     `json:"db"`
+	Server  ServerConfig  `json:"server"`
+	DB      DBConfig      `json:"db"`
+	Metrics MetricsConfig `json:"metrics"`
+	Client  ClientConfig  `json:"client"`
+	Log     LogConfig     `json:"log"`
+	OTel    OTelConfig    `json:"otel"`
+
+	EnableAuthOnOptions    bool     `json:"enable_auth_on_options" doc:"Require a token for OPTIONS requests too"`
+	TokenExtractors        []string `json:"token_extractors" enum:"headers,params" doc:"Where to look for bearer tokens"`
+	OAuthJsonWebKeySetsURL string   `json:"oauth_json_web_key_sets_url" doc:"JWKS URL used to verify tokens; enables auth together with oauth_issuer and oauth_audience"`
+	OAuthIssuer            string   `json:"oauth_issuer" doc:"Expected iss claim"`
+	OAuthAudience          string   `json:"oauth_audience" doc:"Expected aud claim"`
+
+	Hooks []HookConfig `json:"hooks" doc:"Hooks managed by this file; created, updated and deactivated to match it at startup and on reload"`
+}
+
+// HookConfig declares a hook in the config file. Its ID is chosen by the
+// user and stays stable across restarts, so the hook URL does too.
+type HookConfig struct {
+	ID     string `json:"id" pattern:"^[A-Za-z0-9][A-Za-z0-9._~-]*(/[A-Za-z0-9][A-Za-z0-9._~-]*)*$" maxLength:"200" doc:"Hook id; the hook is served at /v1/hooks/{id}. May contain '/' for a multi-segment path"`
+	Method string `json:"method,omitempty" enum:"ANY,GET,POST,PUT,PATCH,DELETE,OPTIONS" default:"GET" doc:"HTTP method the hook answers; ANY accepts all"`
+	HookResponseConfig
+	// Responses are keyed by method (or ANY) and take precedence over the
+	// response above for that method.
+	Responses map[string]HookResponseConfig `json:"responses,omitempty" doc:"Further methods the hook accepts, each with its own response (keys: GET, POST, ..., or ANY)"`
+	Chaos     *webhook.Chaos                `json:"chaos,omitempty" doc:"Latency and faults injected into the hook's responses"`
+	Proxy     *webhook.Proxy                `json:"proxy,omitempty" doc:"Record responses of an upstream API below this hook, or replay them"`
+	// Validation schemas are compiled when the hooks are reconciled.
+	Validation *webhook.RequestValidation `json:"validation,omitempty" doc:"Check request bodies against a JSON Schema"`
+}
+
+// HookResponseConfig is a hook's response. Body, body_base64 and body_file
+// are alternatives.
+type HookResponseConfig struct {
+	Body        string            `json:"body,omitempty" doc:"Response body"`
+	BodyBase64  []byte            `json:"body_base64,omitempty" doc:"Binary response body, base64-encoded"`
+	BodyFile    string            `json:"body_file,omitempty" doc:"Serve this file, relative to server.body_dir"`
+	ContentType string            `json:"content_type,omitempty" doc:"Content-Type of the response, overriding headers"`
+	Headers     map[string]string `json:"headers,omitempty" doc:"Response headers"`
+	Status      int               `json:"status,omitempty" minimum:"100" maximum:"599" default:"200" doc:"Response status code"`
+	Template    bool              `json:"template,omitempty" doc:"Render body and header values as Go templates"`
+	// Scripts are compiled when the hooks are reconciled.
+	Script string `json:"script,omitempty" doc:"CEL expression computing the response from the request"`
+	Plugin string `json:"plugin,omitempty" doc:"WebAssembly plugin computing the response, from server.plugin_dir"`
+}
 
-	EnableAuthOnOptions    bool     `json:"enable_auth_on_options"`
-	TokenExtractors        []string `json:"token_extractors"`
-	OAuthJsonWebKeySetsURL string   `json:"oauth_json_web_key_sets_url"`
-	OAuthIssuer            string   `json:"oauth_issuer"`
-	OAuthAudience          string   `json:"oauth_audience"`
+// Response converts r into the domain type.
+func (r HookResponseConfig) Response() webhook.Response {
+	return webhook.Response{
+		Body:        r.Body,
+		BodyBase64:  r.BodyBase64,
+		BodyFile:    r.BodyFile,
+		ContentType: r.ContentType,
+		Headers:     r.Headers,
+		Status:      r.Status,
+		Template:    r.Template,
+		Script:      r.Script,
+		Plugin:      r.Plugin,
+	}
 }
 
 type ServerConfig struct {
-	Addr string `json:"addr"`
+	Addr string `json:"addr" doc:"Listen address (host:port)" default:"0.0.0.0:1337"`
+	// ShutdownDelaySeconds keeps serving after a shutdown signal while /readyz
+	// already fails, giving load balancers time to drain this instance. 0
+	// closes the listener right away.
+	ShutdownDelaySeconds int `json:"shutdown_delay_seconds" minimum:"0" default:"5" doc:"Keep serving this long after a shutdown signal while /readyz fails (0 disables)"`
+	// BodyDir holds the files hooks serve with body_file; hooks can't reach
+	// outside it. Empty disables body_file.
+	BodyDir string `json:"body_dir" doc:"Directory of the files hooks serve with body_file"`
+	// PluginDir holds WebAssembly plugins (name.wasm) loaded at startup, so
+	// hooks in this file can use them.
+	PluginDir string `json:"plugin_dir" doc:"Directory of WebAssembly plugins (<name>.wasm) loaded at startup"`
+	// MaxPluginInstances bounds the plugins running at once, and with them
+	// the memory plugins take (64MiB each at most).
+	MaxPluginInstances int `json:"max_plugin_instances" minimum:"1" default:"16" doc:"Plugin instances that may run at once; further plugin requests get 503"`
+	// QuotedBodies restores the old behavior of returning a hook's body as a
+	// JSON string ("hello" with quotes) unless it is binary, file-backed or
+	// has a content_type.
+	QuotedBodies bool `json:"quoted_bodies" doc:"Return plain hook bodies as JSON strings, as before they were served verbatim"`
+	// ChaosHeader lets callers pass chaos settings in X-Webhookd-Chaos, so
+	// any hook can be made to misbehave for one request. Off by default.
+	ChaosHeader bool `json:"chaos_header" doc:"Accept chaos settings from the X-Webhookd-Chaos request header"`
+	// ProxyUpstreams is the allowlist of recording proxy upstreams (see
+	// webhook.ParseUpstreams); empty allows none, so proxies can't reach
+	// internal services unless told to.
+	ProxyUpstreams []string `json:"proxy_upstreams" doc:"Hosts (name, IP or host:port) and CIDR ranges recording proxies may forward to; empty allows none"`
+}
+
+// Upstreams parses ProxyUpstreams; Validate has checked it.
+func (s ServerConfig) Upstreams() webhook.Upstreams {
+	u, _ := webhook.ParseUpstreams(s.ProxyUpstreams)
+	return u
+}
+
+// ClientConfig is read by the CLI client subcommands (webhookd hooks ...), not the server.
+type ClientConfig struct {
+	ServerURL string `json:"server_url" doc:"Server base URL for the CLI client" default:"http://localhost:1337"`
+	Token     string `json:"token" doc:"Bearer token sent by the CLI client"`
+}
+
+type MetricsConfig struct {
+	Disabled bool   `json:"disabled" doc:"Disable the Prometheus endpoint"`
+	Path     string `json:"path" doc:"Path of the Prometheus endpoint" default:"/metrics"`
+	// Addr moves the metrics endpoint to a separate admin listener (e.g. "127.0.0.1:9090").
+	// Empty serves it on the main listener.
+	Addr string `json:"addr" doc:"Serve metrics on a separate listener (host:port) instead of the main one"`
+}
+
+type LogConfig struct {
+	Level     string `json:"level" enum:"debug,info,warn,error" default:"info" doc:"Minimum log level"`
+	Format    string `json:"format" enum:"text,json" default:"text" doc:"Console log format"`
+	AddSource bool   `json:"add_source" doc:"Include the source file and line in log records"`
+}
+
+// OTelConfig configures OpenTelemetry export. Setting an endpoint or the
+// stdout exporter enables it implicitly.
+type OTelConfig struct {
+	Enabled     bool              `json:"enabled" doc:"Export traces (and metrics/logs) via OpenTelemetry"`
+	Exporter    string            `json:"exporter" enum:"otlp,stdout" default:"otlp" doc:"otlp sends to a collector; stdout prints everything for offline debugging"`
+	Endpoint    string            `json:"endpoint" doc:"OTLP/HTTP endpoint (base URL or host:port); default http://localhost:4318"`
+	Headers     map[string]string `json:"headers" doc:"Headers sent with every OTLP request (e.g. authorization)"`
+	Insecure    bool              `json:"insecure" doc:"Use http:// for a host:port endpoint"`
+	ServiceName string            `json:"service_name" default:"webhookd"`
+	Environment string            `json:"environment" doc:"deployment.environment.name resource attribute"`
+	SampleRatio float64           `json:"sample_ratio" minimum:"0" maximum:"1" default:"1" doc:"Fraction of traces to sample"`
+
+	MetricsDisabled             bool `json:"metrics_disabled" doc:"Don't run the OTel metrics pipeline"`
+	LogsDisabled                bool `json:"logs_disabled" doc:"Don't run the OTel logs pipeline"`
+	MetricExportIntervalSeconds int  `json:"metric_export_interval_seconds" minimum:"0" doc:"Metric export interval (default 60)"`
+}
+
+// Active reports whether OTel export should be set up.
+func (o OTelConfig) Active() bool {
+	return o.Enabled || o.Endpoint != "" || strings.EqualFold(o.Exporter, "stdout")
 }
 
 type DBConfig struct {
-	Driver string `json:"driver"` // sqlite | postgres | memory
-	DSN    string `json:"dsn"`
+	Driver string `json:"driver" enum:"sqlite,postgres,memory" default:"sqlite" doc:"Audit log storage"`
+	DSN    string `json:"dsn" doc:"Data source name; defaults to :memory: for sqlite, required for postgres"`
 
-	MaxOpenConns           int `json:"max_open_conns"`
-	MaxIdleConns           int `json:"max_idle_conns"`
-	ConnMaxLifetimeSeconds int `json:"conn_max_lifetime_seconds"`
-	ConnMaxIdleTimeSeconds int `json:"conn_max_idle_time_seconds"`
+	MaxOpenConns           int `json:"max_open_conns" minimum:"0"`
+	MaxIdleConns           int `json:"max_idle_conns" minimum:"0"`
+	ConnMaxLifetimeSeconds int `json:"conn_max_lifetime_seconds" minimum:"0"`
+	ConnMaxIdleTimeSeconds int `json:"conn_max_idle_time_seconds" minimum:"0"`
 
-	SQLitePragmas map[string]string `json:"sqlite_pragmas"`
+	SQLitePragmas map[string]string `json:"sqlite_pragmas" doc:"PRAGMAs applied to SQLite connections (default busy_timeout=5000, foreign_keys=ON)"`
 }
 
+// Default is the config Load starts from, before the file, environment and
+// flags. The DSN depends on the driver and is left to ApplyDefaults.
 func Default() Config {
 	// Keep auth defaults “off” unless explicitly configured.
 	return Config{
 		Server: ServerConfig{
-			Addr: "0.0.0.0:1337",
+			Addr:                 "0.0.0.0:1337",
+			ShutdownDelaySeconds: 5,
+			MaxPluginInstances:   16,
 		},
 		DB: DBConfig{
 			Driver: "sqlite",
-			DSN:    ":memory:",
+		},
+		Metrics: MetricsConfig{
+			Path: "/metrics",
+		},
+		Client: ClientConfig{
+			ServerURL: "http://localhost:1337",
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		OTel: OTelConfig{
+			Exporter:    "otlp",
+			ServiceName: "webhookd",
+			SampleRatio: 1,
 		},
 	}
 }
 
+// ParseFile reads a single config file (JSON, YAML or TOML, see FormatFor),
+// applies defaults and validates it.
 func ParseFile(path string) (Config, error) {
 	b, err := os.ReadFile(path)
 	if err != nil {
 		return Config{}, err
 	}
-
-	var c Config
-	if err := json.Unmarshal(b, &c); err != nil {
+	doc, err := decodeDocument(path, b)
+	if err != nil {
+		return Config{}, err
+	}
+	c, _, err := doc.config(false)
+	if err != nil {
 		return Config{}, err
 	}
 
 	c.ApplyDefaults()
 	if err := c.Validate(); err != nil {
-		return Config{}, err
+		key, _, _ := strings.Cut(err.Error(), ":")
+		return Config{}, doc.errorAt(key, err)
 	}
 
 	return c, nil
@@ -72,6 +221,9 @@ func (c *Config) ApplyDefaults() {
 	if c.Server.Addr == "" {
 		c.Server.Addr = "0.0.0.0:1337"
 	}
+	if c.Server.MaxPluginInstances == 0 {
+		c.Server.MaxPluginInstances = 16
+	}
 
 	if c.DB.Driver == "" {
 		c.DB.Driver = "sqlite"
@@ -80,104 +232,28 @@ func (c *Config) ApplyDefaults() {
 		c.DB.DSN = ":memory:"
 	}
 
-	// Pragmas default to nil unless the user provides them (repo layer will apply its own defaults).
-	if c.DB.SQLitePragmas == nil {
-		c.DB.SQLitePragmas = map[string]string{}
+	if c.Metrics.Path == "" {
+		c.Metrics.Path = "/metrics"
 	}
-}
 
-func (c *Config) ApplyEnv(prefix string) error {
-	// Server
-	if v := os.Getenv(prefix + "ADDR"); v != "" {
-		c.Server.Addr = v
+	if c.Log.Level == "" {
+		c.Log.Level = "info"
 	}
-	if v := os.Getenv(prefix + "SERVER_ADDR"); v != "" {
-		c.Server.Addr = v
+	if c.Log.Format == "" {
+		c.Log.Format = "text"
 	}
 
-	// DB
-	if v := os.Getenv(prefix + "DB_DRIVER"); v != "" {
-		c.DB.Driver = v
-	}
-	if v := os.Getenv(prefix + "DB_DSN"); v != "" {
-		c.DB.DSN = v
-	}
-	if v := os.Getenv(prefix + "DB_MAX_OPEN_CONNS"); v != "" {
-		n, err := strconv.Atoi(v)
-		if err != nil {
-			return fmt.Errorf("%sDB_MAX_OPEN_CONNS: %w", prefix, err)
-		}
-		c.DB.MaxOpenConns = n
+	if c.OTel.Exporter == "" {
+		c.OTel.Exporter = "otlp"
 	}
-	if v := os.Getenv(prefix + "DB_MAX_IDLE_CONNS"); v != "" {
-		n, err := strconv.Atoi(v)
-		if err != nil {
-			return fmt.Errorf("%sDB_MAX_IDLE_CONNS: %w", prefix, err)
-		}
-		c.DB.MaxIdleConns = n
-	}
-	if v := os.Getenv(prefix + "DB_CONN_MAX_LIFETIME_SECONDS"); v != "" {
-		n, err := strconv.Atoi(v)
-		if err != nil {
-			return fmt.Errorf("%sDB_CONN_MAX_LIFETIME_SECONDS: %w", prefix, err)
-		}
-		c.DB.ConnMaxLifetimeSeconds = n
-	}
-	if v := os.Getenv(prefix + "DB_CONN_MAX_IDLE_TIME_SECONDS"); v != "" {
-		n, err := strconv.Atoi(v)
-		if err != nil {
-			return fmt.Errorf("%sDB_CONN_MAX_IDLE_TIME_SECONDS: %w", prefix, err)
-		}
-		c.DB.ConnMaxIdleTimeSeconds = n
+	if c.OTel.ServiceName == "" {
+		c.OTel.ServiceName = "webhookd"
 	}
 
-	// SQLite pragmas via env, as JSON object string to avoid a huge list of env vars.
-	// Example: WEBHOOKD_SQLITE_PRAGMAS='{"busy_timeout":"5000","foreign_keys":"ON"}'
-	if v := os.Getenv(prefix + "SQLITE_PRAGMAS"); v != "" {
-		var m map[string]string
-		if err := json.Unmarshal([]byte(v), &m); err != nil {
-			return fmt.Errorf("%sSQLITE_PRAGMAS: invalid JSON object: %w", prefix, err)
-		}
-		if c.DB.SQLitePragmas == nil {
-			c.DB.SQLitePragmas = map[string]string{}
-		}
-		for k, val := range m {
-			c.DB.SQLitePragmas[k] = val
-		}
-	}
-
-	// Auth
-	if v := os.Getenv(prefix + "ENABLE_AUTH_ON_OPTIONS"); v != "" {
-		b, err := strconv.ParseBool(v)
-		if err != nil {
-			return fmt.Errorf("%sENABLE_AUTH_ON_OPTIONS: %w", prefix, err)
-		}
-		c.EnableAuthOnOptions = b
-	}
-	if v := os.Getenv(prefix + "TOKEN_EXTRACTORS"); v != "" {
-		parts := strings.Split(v, ",")
-		out := make([]string, 0, len(parts))
-		for _, p := range parts {
-			p = strings.TrimSpace(p)
-			if p == "" {
-				continue
-			}
-			out = append(out, p)
-		}
-		c.TokenExtractors = out
-	}
-	if v := os.Getenv(prefix + "OAUTH_JSON_WEB_KEY_SETS_URL"); v != "" {
-		c.OAuthJsonWebKeySetsURL = v
-	}
-	if v := os.Getenv(prefix + "OAUTH_ISSUER"); v != "" {
-		c.OAuthIssuer = v
-	}
-	if v := os.Getenv(prefix + "OAUTH_AUDIENCE"); v != "" {
-		c.OAuthAudience = v
+	// Pragmas default to nil unless the user provides them (repo layer will apply its own defaults).
+	if c.DB.SQLitePragmas == nil {
+		c.DB.SQLitePragmas = map[string]string{}
 	}
-
-	c.ApplyDefaults()
-	return c.Validate()
 }
 
 func (c Config) Validate() error {
@@ -197,6 +273,17 @@ func (c Config) Validate() error {
 		return errors.New("oauth config incomplete: require oauth_json_web_key_sets_url, oauth_issuer, oauth_audience together")
 	}
 
+	if c.Server.ShutdownDelaySeconds < 0 {
+		return errors.New("server.shutdown_delay_seconds: must be >= 0")
+	}
+	if c.Server.MaxPluginInstances < 1 {
+		return errors.New("server.max_plugin_instances: must be >= 1")
+	}
+	upstreams, err := webhook.ParseUpstreams(c.Server.ProxyUpstreams)
+	if err != nil {
+		return fmt.Errorf("server.proxy_upstreams: %w", err)
+	}
+
 	// DB config
 	driver := strings.ToLower(strings.TrimSpace(c.DB.Driver))
 	switch driver {
@@ -212,5 +299,114 @@ func (c Config) Validate() error {
 		return errors.New("db: pool settings must be >= 0")
 	}
 
+	// Metrics
+	if c.Metrics.Path != "" && !strings.HasPrefix(c.Metrics.Path, "/") {
+		return fmt.Errorf("metrics.path: must start with / (got %q)", c.Metrics.Path)
+	}
+	if c.Metrics.Addr != "" {
+		if _, _, err := net.SplitHostPort(c.Metrics.Addr); err != nil {
+			return fmt.Errorf("metrics.addr: %w", err)
+		}
+	}
+
+	// Logging
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); c.Log.Level != "" && err != nil {
+		return fmt.Errorf("log.level: unsupported value %q (allowed: debug, info, warn, error)", c.Log.Level)
+	}
+	switch strings.ToLower(c.Log.Format) {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf("log.format: unsupported value %q (allowed: text, json)", c.Log.Format)
+	}
+
+	// OpenTelemetry
+	switch strings.ToLower(c.OTel.Exporter) {
+	case "", "otlp", "stdout":
+	default:
+		return fmt.Errorf("otel.exporter: unsupported value %q (allowed: otlp, stdout)", c.OTel.Exporter)
+	}
+	if c.OTel.SampleRatio < 0 || c.OTel.SampleRatio > 1 {
+		return fmt.Errorf("otel.sample_ratio: must be between 0 and 1 (got %v)", c.OTel.SampleRatio)
+	}
+	if c.OTel.MetricExportIntervalSeconds < 0 {
+		return errors.New("otel.metric_export_interval_seconds: must be >= 0")
+	}
+
+	// Hooks
+	seen := make(map[string]bool, len(c.Hooks))
+	for i, h := range c.Hooks {
+		if h.ID == "" {
+			return fmt.Errorf("hooks[%d].id: required", i)
+		}
+		if err := webhook.ID(h.ID).Validate(); err != nil {
+			return fmt.Errorf("hooks[%d].id: %w", i, err)
+		}
+		if seen[h.ID] {
+			return fmt.Errorf("hooks[%d].id: duplicate id %q", i, h.ID)
+		}
+		seen[h.ID] = true
+		if !validHookMethod(h.Method) {
+			return fmt.Errorf("hooks[%d].method: unsupported value %q (allowed: ANY, GET, POST, PUT, PATCH, DELETE, OPTIONS)", i, h.Method)
+		}
+		if err := c.validateHookResponse(fmt.Sprintf("hooks[%d]", i), h.HookResponseConfig); err != nil {
+			return err
+		}
+		for _, m := range SortedKeys(h.Responses) {
+			if m == "" || !validHookMethod(m) {
+				return fmt.Errorf("hooks[%d].responses.%s: unsupported method %q (allowed: ANY, GET, POST, PUT, PATCH, DELETE, OPTIONS)", i, m, m)
+			}
+			if err := c.validateHookResponse(fmt.Sprintf("hooks[%d].responses.%s", i, m), h.Responses[m]); err != nil {
+				return err
+			}
+		}
+		if h.Chaos != nil {
+			if err := h.Chaos.Validate(); err != nil {
+				return fmt.Errorf("hooks[%d].chaos: %w", i, err)
+			}
+		}
+		if h.Proxy != nil {
+			if err := h.Proxy.Validate(); err != nil {
+				return fmt.Errorf("hooks[%d].proxy: %w", i, err)
+			}
+			if h.Proxy.Upstream != "" {
+				if err := upstreams.Allows(h.Proxy.Upstream); err != nil {
+					return fmt.Errorf("hooks[%d].proxy.upstream: %w", i, err)
+				}
+			}
+		}
+		if h.Validation != nil {
+			if err := h.Validation.Validate(); err != nil {
+				return fmt.Errorf("hooks[%d].validation: %w", i, err)
+			}
+		}
+	}
+
 	return nil
 }
+
+// validateHookResponse checks a hook response; key prefixes the messages so
+// they point into the file.
+func (c Config) validateHookResponse(key string, r HookResponseConfig) error {
+	if r.Status != 0 && (r.Status < 100 || r.Status > 599) {
+		return fmt.Errorf("%s.status: must be between 100 and 599 (got %d)", key, r.Status)
+	}
+	if r.BodyFile != "" && c.Server.BodyDir == "" {
+		return fmt.Errorf("%s.body_file: requires server.body_dir", key)
+	}
+	if r.Plugin != "" && c.Server.PluginDir == "" {
+		return fmt.Errorf("%s.plugin: requires server.plugin_dir", key)
+	}
+	if err := r.Response().Validate(); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	return nil
+}
+
+func validHookMethod(m string) bool {
+	switch strings.ToUpper(strings.TrimSpace(m)) {
+	case "", webhook.MethodAny, "GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS":
+		return true
+	}
+	return false
+}
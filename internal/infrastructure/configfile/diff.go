@@ -0,0 +1,48 @@
+package configfile
+
+import (
+	"reflect"
+	"strings"
+)
+
+// reloadable lists the fields (and field prefixes) a running server picks up
+// on reload; everything else needs a restart.
+var reloadable = []string{
+	"enable_auth_on_options",
+	"token_extractors",
+	"oauth_json_web_key_sets_url",
+	"oauth_issuer",
+	"oauth_audience",
+	"log.level",
+	"hooks",
+	// Only read by the CLI client.
+	"client",
+}
+
+// Reloadable reports whether a change to key (a dotted field path) takes
+// effect without a restart.
+func Reloadable(key string) bool {
+	for _, k := range reloadable {
+		if key == k || strings.HasPrefix(key, k+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff returns the dotted field paths whose values differ between a and b.
+func Diff(a, b Config) []string {
+	fa, fb := a.Fields(), b.Fields()
+	var out []string
+	for _, k := range SortedKeys(fa) {
+		if !reflect.DeepEqual(fa[k], fb[k]) {
+			out = append(out, k)
+		}
+	}
+	for _, k := range SortedKeys(fb) {
+		if _, ok := fa[k]; !ok {
+			out = append(out, k)
+		}
+	}
+	return out
+}
@@ -0,0 +1,73 @@
+package configfile
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestReloadable(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"log.level", true},
+		{"log.format", false},
+		{"oauth_issuer", true},
+		{"token_extractors", true},
+		{"hooks", true},
+		{"client.token", true},
+		{"server.addr", false},
+		{"db.sqlite_pragmas.busy_timeout", false},
+		// Prefixes match whole segments only.
+		{"log.levels", false},
+		{"clients", false},
+	}
+	for _, tt := range tests {
+		if got := Reloadable(tt.key); got != tt.want {
+			t.Errorf("Reloadable(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	base := Default()
+	base.ApplyDefaults()
+
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		want   []string
+	}{
+		{name: "same", change: func(*Config) {}},
+		{name: "scalar", change: func(c *Config) { c.Log.Level = "debug" }, want: []string{"log.level"}},
+		{
+			name:   "several",
+			change: func(c *Config) { c.Server.Addr = ":1"; c.OAuthIssuer = "iss" },
+			want:   []string{"oauth_issuer", "server.addr"},
+		},
+		{
+			name:   "map entry added",
+			change: func(c *Config) { c.DB.SQLitePragmas = map[string]string{"busy_timeout": "1"} },
+			want:   []string{"db.sqlite_pragmas", "db.sqlite_pragmas.busy_timeout"},
+		},
+		{
+			name:   "list",
+			change: func(c *Config) { c.Hooks = []HookConfig{{ID: "a"}} },
+			want:   []string{"hooks"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := base
+			changed.DB.SQLitePragmas = map[string]string{}
+			tt.change(&changed)
+			got := Diff(base, changed)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Diff = %v, want %v", got, tt.want)
+			}
+			if back := Diff(changed, base); !slices.Equal(slices.Sorted(slices.Values(back)), slices.Sorted(slices.Values(got))) {
+				t.Errorf("Diff reversed = %v, want %v", back, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package configfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix is the prefix of the environment variables read by the server.
+const EnvPrefix = "WEBHOOKD_"
+
+// envBinding maps environment variables onto one config field.
+type envBinding struct {
+	key string // dotted field path, as in Config.Fields
+	// names are tried in order after the prefix; the first one set wins.
+	names []string
+	// std are unprefixed standard names (e.g. OTEL_*), tried after names.
+	std []string
+	set func(c *Config, v string) error
+}
+
+var envBindings = []envBinding{
+	// Server
+	{key: "server.addr", names: []string{"SERVER_ADDR", "ADDR"}, set: func(c *Config, v string) error {
+		c.Server.Addr = v
+		return nil
+	}},
+	{key: "server.shutdown_delay_seconds", names: []string{"SHUTDOWN_DELAY_SECONDS"}, set: func(c *Config, v string) error {
+		return setInt(&c.Server.ShutdownDelaySeconds, v)
+	}},
+	{key: "server.body_dir", names: []string{"BODY_DIR"}, set: func(c *Config, v string) error {
+		c.Server.BodyDir = v
+		return nil
+	}},
+	{key: "server.plugin_dir", names: []string{"PLUGIN_DIR"}, set: func(c *Config, v string) error {
+		c.Server.PluginDir = v
+		return nil
+	}},
+	{key: "server.max_plugin_instances", names: []string{"MAX_PLUGIN_INSTANCES"}, set: func(c *Config, v string) error {
+		return setInt(&c.Server.MaxPluginInstances, v)
+	}},
+	{key: "server.quoted_bodies", names: []string{"QUOTED_BODIES"}, set: func(c *Config, v string) error {
+		return setBool(&c.Server.QuotedBodies, v)
+	}},
+	{key: "server.chaos_header", names: []string{"CHAOS_HEADER"}, set: func(c *Config, v string) error {
+		return setBool(&c.Server.ChaosHeader, v)
+	}},
+	{key: "server.proxy_upstreams", names: []string{"PROXY_UPSTREAMS"}, set: func(c *Config, v string) error {
+		c.Server.ProxyUpstreams = splitList(v)
+		return nil
+	}},
+
+	// DB
+	{key: "db.driver", names: []string{"DB_DRIVER"}, set: func(c *Config, v string) error {
+		c.DB.Driver = v
+		return nil
+	}},
+	{key: "db.dsn", names: []string{"DB_DSN"}, set: func(c *Config, v string) error {
+		c.DB.DSN = v
+		return nil
+	}},
+	{key: "db.max_open_conns", names: []string{"DB_MAX_OPEN_CONNS"}, set: func(c *Config, v string) error {
+		return setInt(&c.DB.MaxOpenConns, v)
+	}},
+	{key: "db.max_idle_conns", names: []string{"DB_MAX_IDLE_CONNS"}, set: func(c *Config, v string) error {
+		return setInt(&c.DB.MaxIdleConns, v)
+	}},
+	{key: "db.conn_max_lifetime_seconds", names: []string{"DB_CONN_MAX_LIFETIME_SECONDS"}, set: func(c *Config, v string) error {
+		return setInt(&c.DB.ConnMaxLifetimeSeconds, v)
+	}},
+	{key: "db.conn_max_idle_time_seconds", names: []string{"DB_CONN_MAX_IDLE_TIME_SECONDS"}, set: func(c *Config, v string) error {
+		return setInt(&c.DB.ConnMaxIdleTimeSeconds, v)
+	}},
+	// SQLite pragmas via env, as JSON object string to avoid a huge list of env vars.
+	// Example: WEBHOOKD_SQLITE_PRAGMAS='{"busy_timeout":"5000","foreign_keys":"ON"}'
+	{key: "db.sqlite_pragmas", names: []string{"SQLITE_PRAGMAS"}, set: func(c *Config, v string) error {
+		var m map[string]string
+		if err := json.Unmarshal([]byte(v), &m); err != nil {
+			return fmt.Errorf("invalid JSON object: %w", err)
+		}
+		if c.DB.SQLitePragmas == nil {
+			c.DB.SQLitePragmas = map[string]string{}
+		}
+		for k, val := range m {
+			c.DB.SQLitePragmas[k] = val
+		}
+		return nil
+	}},
+
+	// Metrics
+	{key: "metrics.disabled", names: []string{"METRICS_DISABLED"}, set: func(c *Config, v string) error {
+		return setBool(&c.Metrics.Disabled, v)
+	}},
+	{key: "metrics.path", names: []string{"METRICS_PATH"}, set: func(c *Config, v string) error {
+		c.Metrics.Path = v
+		return nil
+	}},
+	{key: "metrics.addr", names: []string{"METRICS_ADDR"}, set: func(c *Config, v string) error {
+		c.Metrics.Addr = v
+		return nil
+	}},
+
+	// Auth
+	{key: "enable_auth_on_options", names: []string{"ENABLE_AUTH_ON_OPTIONS"}, set: func(c *Config, v string) error {
+		return setBool(&c.EnableAuthOnOptions, v)
+	}},
+	{key: "token_extractors", names: []string{"TOKEN_EXTRACTORS"}, set: func(c *Config, v string) error {
+		c.TokenExtractors = splitList(v)
+		return nil
+	}},
+	{key: "oauth_json_web_key_sets_url", names: []string{"OAUTH_JSON_WEB_KEY_SETS_URL"}, set: func(c *Config, v string) error {
+		c.OAuthJsonWebKeySetsURL = v
+		return nil
+	}},
+	{key: "oauth_issuer", names: []string{"OAUTH_ISSUER"}, set: func(c *Config, v string) error {
+		c.OAuthIssuer = v
+		return nil
+	}},
+	{key: "oauth_audience", names: []string{"OAUTH_AUDIENCE"}, set: func(c *Config, v string) error {
+		c.OAuthAudience = v
+		return nil
+	}},
+
+	// Client
+	{key: "client.server_url", names: []string{"SERVER_URL"}, set: func(c *Config, v string) error {
+		c.Client.ServerURL = v
+		return nil
+	}},
+	{key: "client.token", names: []string{"TOKEN"}, set: func(c *Config, v string) error {
+		c.Client.Token = v
+		return nil
+	}},
+
+	// Logging
+	{key: "log.level", names: []string{"LOG_LEVEL"}, set: func(c *Config, v string) error {
+		c.Log.Level = strings.ToLower(v)
+		return nil
+	}},
+	{key: "log.format", names: []string{"LOG_FORMAT"}, set: func(c *Config, v string) error {
+		c.Log.Format = strings.ToLower(v)
+		return nil
+	}},
+
+	// OpenTelemetry
+	{key: "otel.enabled", names: []string{"OTEL_ENABLED"}, set: func(c *Config, v string) error {
+		return setBool(&c.OTel.Enabled, v)
+	}},
+	{key: "otel.exporter", names: []string{"OTEL_EXPORTER"}, set: func(c *Config, v string) error {
+		c.OTel.Exporter = strings.ToLower(v)
+		return nil
+	}},
+	{
+		key:   "otel.endpoint",
+		names: []string{"OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"},
+		std:   []string{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT"},
+		set: func(c *Config, v string) error {
+			c.OTel.Endpoint = v
+			return nil
+		},
+	},
+	{
+		key:   "otel.headers",
+		names: []string{"OTEL_EXPORTER_OTLP_HEADERS"},
+		std:   []string{"OTEL_EXPORTER_OTLP_HEADERS"},
+		set: func(c *Config, v string) error {
+			h, err := parseHeaders(v)
+			if err != nil {
+				return err
+			}
+			c.OTel.Headers = h
+			return nil
+		},
+	},
+	{
+		key:   "otel.insecure",
+		names: []string{"OTEL_EXPORTER_OTLP_INSECURE"},
+		std:   []string{"OTEL_EXPORTER_OTLP_INSECURE"},
+		set: func(c *Config, v string) error {
+			return setBool(&c.OTel.Insecure, v)
+		},
+	},
+	{
+		key:   "otel.service_name",
+		names: []string{"OTEL_SERVICE_NAME"},
+		std:   []string{"OTEL_SERVICE_NAME"},
+		set: func(c *Config, v string) error {
+			c.OTel.ServiceName = v
+			return nil
+		},
+	},
+	{
+		key:   "otel.environment",
+		names: []string{"ENV"},
+		std:   []string{"ENV", "OTEL_ENVIRONMENT"},
+		set: func(c *Config, v string) error {
+			c.OTel.Environment = v
+			return nil
+		},
+	},
+	{key: "otel.sample_ratio", names: []string{"OTEL_TRACES_SAMPLER_RATIO"}, set: func(c *Config, v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return err
+		}
+		c.OTel.SampleRatio = f
+		return nil
+	}},
+	{key: "otel.metrics_disabled", names: []string{"OTEL_METRICS_ENABLED"}, set: func(c *Config, v string) error {
+		return setNegatedBool(&c.OTel.MetricsDisabled, v)
+	}},
+	{key: "otel.logs_disabled", names: []string{"OTEL_LOGS_ENABLED"}, set: func(c *Config, v string) error {
+		return setNegatedBool(&c.OTel.LogsDisabled, v)
+	}},
+	{key: "otel.metric_export_interval_seconds", names: []string{"OTEL_METRIC_EXPORT_INTERVAL"}, set: func(c *Config, v string) error {
+		// Accepts a Go duration ("30s") or plain seconds.
+		if d, err := time.ParseDuration(v); err == nil {
+			c.OTel.MetricExportIntervalSeconds = int(d.Seconds())
+			return nil
+		}
+		return setInt(&c.OTel.MetricExportIntervalSeconds, v)
+	}},
+}
+
+// ApplyEnv overrides c with the environment variables named prefix+NAME (see
+// README), then applies defaults and validates.
+func (c *Config) ApplyEnv(prefix string) error {
+	if _, err := c.applyEnv(prefix, os.LookupEnv); err != nil {
+		return err
+	}
+	c.ApplyDefaults()
+	return c.Validate()
+}
+
+// applyEnv returns the variable that set each field it changed.
+func (c *Config) applyEnv(prefix string, lookup func(string) (string, bool)) (map[string]string, error) {
+	set := map[string]string{}
+	for _, b := range envBindings {
+		name, v, ok := b.lookup(prefix, lookup)
+		if !ok {
+			continue
+		}
+		if err := b.set(c, v); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		set[b.key] = name
+	}
+	return set, nil
+}
+
+func (b envBinding) lookup(prefix string, lookup func(string) (string, bool)) (name, value string, ok bool) {
+	names := make([]string, 0, len(b.names)+len(b.std))
+	for _, n := range b.names {
+		names = append(names, prefix+n)
+	}
+	names = append(names, b.std...)
+	for _, n := range names {
+		if v, found := lookup(n); found && strings.TrimSpace(v) != "" {
+			return n, strings.TrimSpace(v), true
+		}
+	}
+	return "", "", false
+}
+
+// splitList reads a comma-separated list, dropping empty items.
+func splitList(v string) []string {
+	parts := strings.Split(v, ",")
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func setInt(dst *int, v string) error {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return err
+	}
+	*dst = n
+	return nil
+}
+
+func setBool(dst *bool, v string) error {
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return err
+	}
+	*dst = b
+	return nil
+}
+
+func setNegatedBool(dst *bool, v string) error {
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return err
+	}
+	*dst = !b
+	return nil
+}
+
+// parseHeaders parses the OTEL_EXPORTER_OTLP_HEADERS format: k1=v1,k2=v2.
+func parseHeaders(v string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, p := range strings.Split(v, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		k, val, ok := strings.Cut(p, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid header %q (expected key=value)", p)
+		}
+		k = strings.TrimSpace(k)
+		if k == "" {
+			return nil, errors.New("invalid header with empty key")
+		}
+		out[k] = strings.TrimSpace(val)
+	}
+	return out, nil
+}
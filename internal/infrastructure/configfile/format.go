@@ -0,0 +1,430 @@
+package configfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+	FormatTOML Format = "toml"
+)
+
+// FormatFor picks the file format from the extension; anything unknown is
+// read as JSON, as before other formats were supported.
+func FormatFor(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatJSON
+	}
+}
+
+// PositionError is an error tied to a place in a config file.
+type PositionError struct {
+	Path string
+	Line int // 1-based; 0 when unknown
+	Col  int // 1-based; 0 when unknown
+	Err  error
+}
+
+func (e *PositionError) Error() string {
+	switch {
+	case e.Line > 0 && e.Col > 0:
+		return fmt.Sprintf("%s:%d:%d: %v", e.Path, e.Line, e.Col, e.Err)
+	case e.Line > 0:
+		return fmt.Sprintf("%s:%d: %v", e.Path, e.Line, e.Err)
+	default:
+		return fmt.Sprintf("%s: %v", e.Path, e.Err)
+	}
+}
+
+func (e *PositionError) Unwrap() error { return e.Err }
+
+type position struct{ line, col int }
+
+// document is a config file decoded into JSON-compatible values, with the
+// position of every dotted key. List items are keyed by index, e.g.
+// "hooks[0].id".
+type document struct {
+	path      string
+	values    map[string]any
+	positions map[string]position
+}
+
+func decodeDocument(path string, raw []byte) (*document, error) {
+	doc := &document{path: path, values: map[string]any{}, positions: map[string]position{}}
+	var err error
+	switch FormatFor(path) {
+	case FormatYAML:
+		err = doc.decodeYAML(raw)
+	case FormatTOML:
+		err = doc.decodeTOML(raw)
+	default:
+		err = doc.decodeJSON(raw)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// config converts the document into a Config on top of Default(); unknown
+// keys and type mismatches point at the offending key. When lenient, unknown
+// keys are returned as warnings instead, as files were decoded before the
+// schema was enforced.
+func (d *document) config(lenient bool) (_ Config, warnings []error, _ error) {
+	var unknown []string
+	unknownKeys("", d.values, reflect.TypeFor[Config](), &unknown)
+	for _, key := range unknown {
+		err := d.errorAt(key, fmt.Errorf("%s: unknown key", key))
+		if !lenient {
+			return Config{}, nil, err
+		}
+		warnings = append(warnings, err)
+	}
+	b, err := json.Marshal(d.values)
+	if err != nil {
+		return Config{}, nil, &PositionError{Path: d.path, Err: err}
+	}
+	c := Default()
+	if err := json.Unmarshal(b, &c); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return Config{}, nil, d.errorAt(typeErr.Field, fmt.Errorf("%s: expected %s, got %s", typeErr.Field, typeErr.Type, typeErr.Value))
+		}
+		return Config{}, nil, &PositionError{Path: d.path, Err: err}
+	}
+	return c, warnings, nil
+}
+
+// unknownKeys appends to out the keys of v, a decoded value at prefix, that
+// t has no field for, in order. The schema is closed, so such keys are typos
+// rather than settings (except "$schema", which editors use). Values t
+// decodes itself are not looked into.
+func unknownKeys(prefix string, v any, t reflect.Type, out *[]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(reflect.TypeFor[json.Unmarshaler]()) {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := v.(map[string]any)
+		if !ok {
+			return
+		}
+		fields := jsonFields(t)
+		for _, k := range SortedKeys(m) {
+			key := joinKey(prefix, k)
+			ft, ok := fields[k]
+			switch {
+			case !ok && prefix == "" && k == "$schema":
+			case !ok:
+				*out = append(*out, key)
+			default:
+				unknownKeys(key, m[k], ft, out)
+			}
+		}
+	case reflect.Map:
+		m, ok := v.(map[string]any)
+		if !ok {
+			return
+		}
+		for _, k := range SortedKeys(m) {
+			unknownKeys(joinKey(prefix, k), m[k], t.Elem(), out)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := v.([]any)
+		if !ok {
+			return
+		}
+		for i, item := range items {
+			unknownKeys(indexKey(prefix, i), item, t.Elem(), out)
+		}
+	}
+}
+
+// jsonFields maps the JSON names of the fields of struct t, including those
+// of embedded structs, to their types.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	out := map[string]reflect.Type{}
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || (f.Anonymous && f.Tag.Get("json") == "") {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = f.Name
+		}
+		out[name] = f.Type
+	}
+	return out
+}
+
+// errorAt attaches the position of key (or its closest parent) to err.
+func (d *document) errorAt(key string, err error) error {
+	pos, ok := d.positions[keyOrParent(d.positions, key)]
+	if !ok {
+		return &PositionError{Path: d.path, Err: err}
+	}
+	return &PositionError{Path: d.path, Line: pos.line, Col: pos.col, Err: err}
+}
+
+func (d *document) decodeJSON(raw []byte) error {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line, col := lineCol(raw, syntaxErr.Offset)
+			return &PositionError{Path: d.path, Line: line, Col: col, Err: err}
+		}
+		return &PositionError{Path: d.path, Err: err}
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return &PositionError{Path: d.path, Line: 1, Col: 1, Err: errors.New("top level must be an object")}
+	}
+	d.values = m
+
+	// Second pass over the token stream for key positions.
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	var walk func(prefix string) error
+	walk = func(prefix string) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'):
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				key := joinKey(prefix, keyTok.(string))
+				// InputOffset is just past the key; close enough to point at its line.
+				line, col := lineCol(raw, dec.InputOffset())
+				if _, seen := d.positions[key]; !seen {
+					d.positions[key] = position{line, col}
+				}
+				if err := walk(key); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				key := indexKey(prefix, i)
+				line, col := lineCol(raw, dec.InputOffset())
+				d.positions[key] = position{line, col}
+				if err := walk(key); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+		}
+		return err
+	}
+	_ = walk("")
+	return nil
+}
+
+func (d *document) decodeYAML(raw []byte) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(raw, &root); err != nil {
+		// yaml.v3 errors already read "yaml: line N: ...".
+		return &PositionError{Path: d.path, Err: err}
+	}
+	if len(root.Content) == 0 {
+		return nil // empty file
+	}
+	top := root.Content[0]
+	if top.Kind != yaml.MappingNode {
+		return &PositionError{Path: d.path, Line: top.Line, Col: top.Column, Err: errors.New("top level must be a mapping")}
+	}
+	v, err := yamlValue(top)
+	if err != nil {
+		return &PositionError{Path: d.path, Err: err}
+	}
+	d.values = v.(map[string]any)
+
+	var walk func(prefix string, n *yaml.Node)
+	walk = func(prefix string, n *yaml.Node) {
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				k, val := n.Content[i], n.Content[i+1]
+				key := joinKey(prefix, k.Value)
+				if _, seen := d.positions[key]; !seen {
+					d.positions[key] = position{k.Line, k.Column}
+				}
+				walk(key, val)
+			}
+		case yaml.SequenceNode:
+			for i, item := range n.Content {
+				key := indexKey(prefix, i)
+				d.positions[key] = position{item.Line, item.Column}
+				walk(key, item)
+			}
+		case yaml.AliasNode:
+			walk(prefix, n.Alias)
+		}
+	}
+	walk("", top)
+	return nil
+}
+
+// yamlValue converts a node into JSON-compatible values (string-keyed maps).
+func yamlValue(n *yaml.Node) (any, error) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		m := make(map[string]any, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, val := n.Content[i], n.Content[i+1]
+			if k.Tag == "!!merge" {
+				merged, err := yamlValue(val)
+				if err != nil {
+					return nil, err
+				}
+				if mm, ok := merged.(map[string]any); ok {
+					for mk, mv := range mm {
+						if _, exists := m[mk]; !exists {
+							m[mk] = mv
+						}
+					}
+				}
+				continue
+			}
+			v, err := yamlValue(val)
+			if err != nil {
+				return nil, err
+			}
+			m[k.Value] = v
+		}
+		return m, nil
+	case yaml.SequenceNode:
+		out := make([]any, 0, len(n.Content))
+		for _, item := range n.Content {
+			v, err := yamlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	case yaml.AliasNode:
+		return yamlValue(n.Alias)
+	default:
+		var v any
+		if err := n.Decode(&v); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n.Line, err)
+		}
+		return v, nil
+	}
+}
+
+func (d *document) decodeTOML(raw []byte) error {
+	var m map[string]any
+	if _, err := toml.Decode(string(raw), &m); err != nil {
+		var parseErr toml.ParseError
+		if errors.As(err, &parseErr) {
+			return &PositionError{Path: d.path, Line: parseErr.Position.Line, Col: parseErr.Position.Col, Err: errors.New(parseErr.Message)}
+		}
+		return &PositionError{Path: d.path, Err: err}
+	}
+	if m != nil {
+		d.values = m
+	}
+	d.positions = tomlPositions(raw)
+	return nil
+}
+
+// tomlPositions finds the line of every table header and key assignment.
+// The TOML library doesn't expose key positions; this line scan is good
+// enough for error messages (it doesn't follow multi-line strings).
+func tomlPositions(raw []byte) map[string]position {
+	out := map[string]position{}
+	table := ""
+	arrays := map[string]int{} // [[array]] tables seen so far
+	sc := bufio.NewScanner(bytes.NewReader(raw))
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		trimmed := strings.TrimSpace(text)
+		col := len(text) - len(strings.TrimLeft(text, " \t")) + 1
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			continue
+		case strings.HasPrefix(trimmed, "["):
+			name := tomlKey(strings.Trim(strings.SplitN(trimmed, "#", 2)[0], "[] \t"))
+			if _, seen := out[name]; !seen {
+				out[name] = position{line, col}
+			}
+			table = name
+			if strings.HasPrefix(trimmed, "[[") {
+				table = indexKey(name, arrays[name])
+				arrays[name]++
+				out[table] = position{line, col}
+			}
+		default:
+			k, _, ok := strings.Cut(trimmed, "=")
+			if !ok {
+				continue
+			}
+			key := joinKey(table, tomlKey(k))
+			if _, seen := out[key]; !seen {
+				out[key] = position{line, col}
+			}
+		}
+	}
+	return out
+}
+
+// tomlKey normalizes a (possibly dotted or quoted) TOML key to our dotted form.
+func tomlKey(k string) string {
+	parts := strings.Split(strings.TrimSpace(k), ".")
+	for i, p := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(p), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
+
+func indexKey(prefix string, i int) string {
+	return fmt.Sprintf("%s[%d]", prefix, i)
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func lineCol(raw []byte, offset int64) (line, col int) {
+	if offset > int64(len(raw)) {
+		offset = int64(len(raw))
+	}
+	before := raw[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	col = int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
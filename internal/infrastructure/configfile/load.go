@@ -0,0 +1,284 @@
+package configfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultPath is the config file looked up when --config isn't given.
+	DefaultPath = "webhookd.json"
+	// LegacyPath is read when none of DefaultPaths exists (deprecated).
+	LegacyPath = ".webhookdrc.json"
+)
+
+// DefaultPaths are looked up, in the working directory, when no config path
+// (or DefaultPath) is given. At most one of them may exist.
+var DefaultPaths = []string{DefaultPath, "webhookd.yaml", "webhookd.yml", "webhookd.toml"}
+
+// Value sources reported by Loaded.Sources. Values set from the environment
+// or flags are reported as "env:NAME" and "flag:--name".
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+)
+
+// Loaded is a config together with where it came from.
+type Loaded struct {
+	Config Config
+	// Path is the file that was read; empty when running on defaults.
+	Path string
+	// Legacy is set when Path is the deprecated LegacyPath fallback.
+	Legacy bool
+	// Warnings are problems that don't stop the config from loading: the
+	// unknown keys of a legacy file.
+	Warnings []error
+	// Sources maps each dotted field path (e.g. "db.driver") to the source of its value.
+	Sources map[string]string
+}
+
+// Override is a command-line flag applied on top of file and environment.
+type Override struct {
+	Flag string // e.g. "--port"
+	Key  string // dotted field path it sets
+	// Apply may read the config as loaded so far (e.g. to keep the host when
+	// only --port is given).
+	Apply func(c *Config) error
+}
+
+type LoadOptions struct {
+	Path string
+	// EnvPrefix selects the environment variables read (normally EnvPrefix);
+	// empty disables the environment layer.
+	EnvPrefix string
+	// LookupEnv defaults to os.LookupEnv.
+	LookupEnv func(string) (string, bool)
+	Overrides []Override
+}
+
+// Load assembles the config from its layers, lowest precedence first:
+// defaults < file < environment < flags. Without a path (or with
+// DefaultPath), the first of DefaultPaths found is read, then LegacyPath, and
+// otherwise only defaults apply; any other missing path is an error. The
+// result has defaults applied and is validated; errors caused by the file
+// carry its name and the line of the offending key.
+func Load(opts LoadOptions) (Loaded, error) {
+	loaded, doc, err := readFile(opts.Path)
+	if err != nil {
+		return Loaded{}, err
+	}
+	var fromFile map[string]any
+	if doc != nil {
+		fromFile = flatten(doc.values)
+	} else {
+		loaded.Config = Default()
+	}
+	cfg := &loaded.Config
+
+	fromEnv := map[string]string{}
+	if opts.EnvPrefix != "" {
+		lookup := opts.LookupEnv
+		if lookup == nil {
+			lookup = os.LookupEnv
+		}
+		if fromEnv, err = cfg.applyEnv(opts.EnvPrefix, lookup); err != nil {
+			return Loaded{}, err
+		}
+	}
+
+	fromFlags := map[string]string{}
+	for _, o := range opts.Overrides {
+		if err := o.Apply(cfg); err != nil {
+			return Loaded{}, fmt.Errorf("%s: %w", o.Flag, err)
+		}
+		fromFlags[o.Key] = o.Flag
+	}
+
+	cfg.ApplyDefaults()
+	if err := cfg.Validate(); err != nil {
+		// Blame whichever layer set the key the error is about.
+		key, _, _ := strings.Cut(err.Error(), ":")
+		switch {
+		case fromFlags[keyOrParent(fromFlags, key)] != "":
+			return Loaded{}, fmt.Errorf("%s: %w", fromFlags[keyOrParent(fromFlags, key)], err)
+		case fromEnv[keyOrParent(fromEnv, key)] != "":
+			return Loaded{}, fmt.Errorf("%s: %w", fromEnv[keyOrParent(fromEnv, key)], err)
+		case doc != nil:
+			return Loaded{}, doc.errorAt(key, err)
+		}
+		return Loaded{}, err
+	}
+
+	loaded.Sources = map[string]string{}
+	for k := range cfg.Fields() {
+		switch {
+		case fromFlags[keyOrParent(fromFlags, k)] != "":
+			loaded.Sources[k] = "flag:" + fromFlags[keyOrParent(fromFlags, k)]
+		case fromEnv[keyOrParent(fromEnv, k)] != "":
+			loaded.Sources[k] = "env:" + fromEnv[keyOrParent(fromEnv, k)]
+		case hasKeyOrParent(fromFile, k):
+			loaded.Sources[k] = SourceFile
+		default:
+			loaded.Sources[k] = SourceDefault
+		}
+	}
+	return loaded, nil
+}
+
+// readFile reads the file layer.
+func readFile(path string) (Loaded, *document, error) {
+	if path == "" || path == DefaultPath {
+		found, legacy, err := discover()
+		if err != nil || found == "" {
+			return Loaded{}, nil, err
+		}
+		loaded, doc, err := readPath(found, legacy)
+		loaded.Legacy = legacy
+		return loaded, doc, err
+	}
+	return readPath(path, false)
+}
+
+// readPath reads the file at path; legacy files are decoded leniently.
+func readPath(path string, legacy bool) (Loaded, *document, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Loaded{}, nil, err
+	}
+	doc, err := decodeDocument(path, raw)
+	if err != nil {
+		return Loaded{}, nil, err
+	}
+	cfg, warnings, err := doc.config(legacy)
+	if err != nil {
+		return Loaded{}, nil, err
+	}
+	return Loaded{Config: cfg, Path: path, Warnings: warnings}, doc, nil
+}
+
+// discover returns the default config file present in the working directory,
+// if any.
+func discover() (path string, legacy bool, _ error) {
+	var found []string
+	for _, p := range DefaultPaths {
+		if _, err := os.Stat(p); err == nil {
+			found = append(found, p)
+		} else if !os.IsNotExist(err) {
+			return "", false, err
+		}
+	}
+	switch len(found) {
+	case 0:
+	case 1:
+		return found[0], false, nil
+	default:
+		return "", false, fmt.Errorf("several config files found (%s); choose one with --config", strings.Join(found, ", "))
+	}
+
+	if _, err := os.Stat(LegacyPath); err == nil {
+		return LegacyPath, true, nil
+	} else if !os.IsNotExist(err) {
+		return "", false, fmt.Errorf("stat legacy config %q: %w", LegacyPath, err)
+	}
+	return "", false, nil
+}
+
+// keyOrParent returns k, or the closest parent of k present in m (map-valued
+// fields such as db.sqlite_pragmas are set as a whole).
+func keyOrParent[V any](m map[string]V, k string) string {
+	for {
+		if _, ok := m[k]; ok {
+			return k
+		}
+		i := strings.LastIndexByte(k, '.')
+		if i < 0 {
+			return k
+		}
+		k = k[:i]
+	}
+}
+
+func hasKeyOrParent[V any](m map[string]V, k string) bool {
+	_, ok := m[keyOrParent(m, k)]
+	return ok
+}
+
+// Fields flattens c into dotted field paths (the JSON names), e.g.
+// "server.addr" or "db.sqlite_pragmas.busy_timeout". Lists are leaves.
+func (c Config) Fields() map[string]any {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return map[string]any{}
+	}
+	var doc any
+	_ = json.Unmarshal(b, &doc)
+	return flatten(doc)
+}
+
+func flatten(doc any) map[string]any {
+	out := map[string]any{}
+	var walk func(prefix string, v any)
+	walk = func(prefix string, v any) {
+		m, ok := v.(map[string]any)
+		if !ok || (len(m) == 0 && prefix != "") {
+			out[prefix] = v
+			return
+		}
+		for k, child := range m {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			walk(key, child)
+		}
+	}
+	walk("", doc)
+	return out
+}
+
+// SortedKeys returns the keys of a Fields/Sources map in a stable order.
+func SortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+const redacted = "REDACTED"
+
+var dsnPassword = regexp.MustCompile(`(?i)(password=)([^\s&]+)`)
+
+// Redacted returns a copy of c that is safe to print: tokens and OTLP header
+// values are replaced and passwords are removed from the DSN.
+func (c Config) Redacted() Config {
+	if c.Client.Token != "" {
+		c.Client.Token = redacted
+	}
+	if len(c.OTel.Headers) > 0 {
+		headers := make(map[string]string, len(c.OTel.Headers))
+		for k := range c.OTel.Headers {
+			headers[k] = redacted
+		}
+		c.OTel.Headers = headers
+	}
+	c.DB.DSN = redactDSN(c.DB.DSN)
+	return c
+}
+
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+			dsn = u.String()
+		}
+	}
+	// key=value DSNs (libpq style) and ?password= query parameters.
+	return dsnPassword.ReplaceAllString(dsn, "${1}"+redacted)
+}
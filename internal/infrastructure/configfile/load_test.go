@@ -0,0 +1,275 @@
+package configfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes a config file named name into a fresh directory.
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func lookupIn(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+}
+
+func setLevel(level string) Override {
+	return Override{Flag: "--log-level", Key: "log.level", Apply: func(c *Config) error {
+		c.Log.Level = level
+		return nil
+	}}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeConfig(t, "webhookd.yaml", "log:\n  level: debug\n")
+	tests := []struct {
+		name       string
+		path       string
+		env        map[string]string
+		overrides  []Override
+		wantLevel  string
+		wantSource string
+	}{
+		{name: "default", wantLevel: "info", wantSource: SourceDefault},
+		{name: "file over default", path: file, wantLevel: "debug", wantSource: SourceFile},
+		{
+			name: "env over file", path: file,
+			env:       map[string]string{"WEBHOOKD_LOG_LEVEL": "WARN"},
+			wantLevel: "warn", wantSource: "env:WEBHOOKD_LOG_LEVEL",
+		},
+		{
+			name: "flag over env", path: file,
+			env:       map[string]string{"WEBHOOKD_LOG_LEVEL": "warn"},
+			overrides: []Override{setLevel("error")},
+			wantLevel: "error", wantSource: "flag:--log-level",
+		},
+		{
+			name:      "blank env is unset",
+			path:      file,
+			env:       map[string]string{"WEBHOOKD_LOG_LEVEL": " "},
+			wantLevel: "debug", wantSource: SourceFile,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded, err := Load(LoadOptions{Path: tt.path, EnvPrefix: EnvPrefix, LookupEnv: lookupIn(tt.env), Overrides: tt.overrides})
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if got := loaded.Config.Log.Level; got != tt.wantLevel {
+				t.Errorf("log.level = %q, want %q", got, tt.wantLevel)
+			}
+			if got := loaded.Sources["log.level"]; got != tt.wantSource {
+				t.Errorf("source of log.level = %q, want %q", got, tt.wantSource)
+			}
+			if loaded.Path != tt.path {
+				t.Errorf("Path = %q, want %q", loaded.Path, tt.path)
+			}
+		})
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	tests := []struct {
+		name            string
+		content         string
+		wantSampleRatio float64
+		wantDelay       int
+		wantDSN         string
+	}{
+		{name: "no file", wantSampleRatio: 1, wantDelay: 5, wantDSN: ":memory:"},
+		{name: "other keys", content: `{"otel":{"service_name":"x"}}`, wantSampleRatio: 1, wantDelay: 5, wantDSN: ":memory:"},
+		{
+			name:            "explicit zero",
+			content:         `{"otel":{"sample_ratio":0},"server":{"shutdown_delay_seconds":0}}`,
+			wantSampleRatio: 0, wantDelay: 0, wantDSN: ":memory:",
+		},
+		{name: "postgres has no DSN default", content: `{"db":{"driver":"postgres","dsn":"postgres://db"}}`, wantSampleRatio: 1, wantDelay: 5, wantDSN: "postgres://db"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := LoadOptions{EnvPrefix: EnvPrefix, LookupEnv: lookupIn(nil)}
+			if tt.content != "" {
+				opts.Path = writeConfig(t, "webhookd.json", tt.content)
+			}
+			loaded, err := Load(opts)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			c := loaded.Config
+			if c.Client.ServerURL != "http://localhost:1337" {
+				t.Errorf("client.server_url = %q, want the schema default", c.Client.ServerURL)
+			}
+			if c.OTel.SampleRatio != tt.wantSampleRatio || c.Server.ShutdownDelaySeconds != tt.wantDelay || c.DB.DSN != tt.wantDSN {
+				t.Errorf("got sample_ratio %v, shutdown_delay_seconds %d, dsn %q; want %v, %d, %q",
+					c.OTel.SampleRatio, c.Server.ShutdownDelaySeconds, c.DB.DSN, tt.wantSampleRatio, tt.wantDelay, tt.wantDSN)
+			}
+		})
+	}
+}
+
+func TestLoadSources(t *testing.T) {
+	path := writeConfig(t, "webhookd.json", `{"db":{"sqlite_pragmas":{"busy_timeout":"100"}},"otel":{"service_name":"svc"}}`)
+	loaded, err := Load(LoadOptions{
+		Path:      path,
+		EnvPrefix: EnvPrefix,
+		LookupEnv: lookupIn(map[string]string{"WEBHOOKD_ADDR": "127.0.0.1:9000", "OTEL_SERVICE_NAME": "std"}),
+		Overrides: []Override{setLevel("warn")},
+	})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := map[string]string{
+		"server.addr":                    "env:WEBHOOKD_ADDR",
+		"log.level":                      "flag:--log-level",
+		"db.sqlite_pragmas.busy_timeout": SourceFile,
+		"otel.service_name":              "env:OTEL_SERVICE_NAME", // standard names count as environment too
+		"db.driver":                      SourceDefault,
+		"otel.sample_ratio":              SourceDefault,
+	}
+	for k, v := range want {
+		if got := loaded.Sources[k]; got != v {
+			t.Errorf("source of %s = %q, want %q", k, got, v)
+		}
+	}
+	// Every field has a source.
+	for k := range loaded.Config.Fields() {
+		if loaded.Sources[k] == "" {
+			t.Errorf("no source for %s", k)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		env     map[string]string
+		want    string
+		line    int // 0: not a PositionError
+	}{
+		{
+			name: "yaml invalid value", file: "webhookd.yaml",
+			content: "server:\n  addr: \":1\"\nlog:\n  level: loud\n",
+			want:    `log.level: unsupported value "loud"`, line: 4,
+		},
+		{
+			name: "yaml unknown key", file: "webhookd.yaml",
+			content: "server:\n  addr: \":1\"\n  adr: x\n",
+			want:    "server.adr: unknown key", line: 3,
+		},
+		{
+			name: "yaml syntax", file: "webhookd.yml",
+			content: "server:\n  addr: [\n",
+			want:    "yaml:",
+		},
+		{
+			name: "toml invalid value", file: "webhookd.toml",
+			content: "[server]\naddr = \":1\"\n\n[log]\nlevel = \"loud\"\n",
+			want:    `log.level: unsupported value "loud"`, line: 5,
+		},
+		{
+			name: "toml unknown key", file: "webhookd.toml",
+			content: "[otel]\nsample = 1\n",
+			want:    "otel.sample: unknown key", line: 2,
+		},
+		{
+			name: "toml syntax", file: "webhookd.toml",
+			content: "[server\naddr = 1\n",
+			line:    2,
+		},
+		{
+			name: "json invalid value", file: "webhookd.json",
+			content: "{\n  \"db\": {\n    \"driver\": \"mysql\"\n  }\n}\n",
+			want:    `db.driver: unsupported value "mysql"`, line: 3,
+		},
+		{
+			name: "json type mismatch", file: "webhookd.json",
+			content: "{\n  \"server\": {\n    \"addr\": 1337\n  }\n}\n",
+			want:    "server.addr: expected string", line: 3,
+		},
+		{
+			name: "json unknown key in a hook", file: "webhookd.json",
+			content: "{\n  \"hooks\": [\n    {\"id\": \"a\", \"bdy\": \"x\"}\n  ]\n}\n",
+			want:    "hooks[0].bdy: unknown key", line: 3,
+		},
+		{
+			name: "json syntax", file: "webhookd.json",
+			content: "{\n  \"server\": {,}\n}\n",
+			line:    2,
+		},
+		{
+			name: "env blamed over file", file: "webhookd.json",
+			content: `{"log":{"level":"debug"}}`,
+			env:     map[string]string{"WEBHOOKD_LOG_LEVEL": "loud"},
+			want:    `WEBHOOKD_LOG_LEVEL: log.level: unsupported value "loud"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, tt.file, tt.content)
+			_, err := Load(LoadOptions{Path: path, EnvPrefix: EnvPrefix, LookupEnv: lookupIn(tt.env)})
+			if err == nil {
+				t.Fatal("Load: want an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q doesn't mention %q", err, tt.want)
+			}
+			var posErr *PositionError
+			switch {
+			case tt.line == 0:
+				if errors.As(err, &posErr) && posErr.Line != 0 {
+					t.Errorf("error %q has a line; want none", err)
+				}
+			case !errors.As(err, &posErr):
+				t.Errorf("error %q (%T) is not a PositionError", err, err)
+			case posErr.Path != path || posErr.Line != tt.line:
+				t.Errorf("error at %s:%d, want %s:%d", posErr.Path, posErr.Line, path, tt.line)
+			}
+		})
+	}
+}
+
+func TestLoadAllowsSchemaKey(t *testing.T) {
+	path := writeConfig(t, "webhookd.json", `{"$schema":"./webhookd.schema.json","log":{"level":"warn"}}`)
+	if _, err := Load(LoadOptions{Path: path}); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+}
+
+func TestLoadMissingPath(t *testing.T) {
+	_, err := Load(LoadOptions{Path: filepath.Join(t.TempDir(), "missing.yaml")})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("got %v, want a not-exist error", err)
+	}
+}
+
+func TestLoadLegacyWarnsOfUnknownKeys(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile(LegacyPath, []byte(`{"port":1337,"log":{"level":"warn"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(LoadOptions{})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !loaded.Legacy || loaded.Config.Log.Level != "warn" {
+		t.Fatalf("got legacy %v, log.level %q; want the legacy file read", loaded.Legacy, loaded.Config.Log.Level)
+	}
+	if len(loaded.Warnings) != 1 || !strings.Contains(loaded.Warnings[0].Error(), "port: unknown key") {
+		t.Fatalf("warnings %v; want one for port", loaded.Warnings)
+	}
+}
@@ -0,0 +1,94 @@
+// Package jsonschema validates request bodies against JSON Schemas
+// (ports.SchemaCompiler).
+package jsonschema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	js "github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+
+	"webhookd/internal/application/ports"
+	"webhookd/internal/domain/webhook"
+)
+
+// maxErrors caps the errors reported per request.
+const maxErrors = 20
+
+// resourceURL names the schema being compiled; relative references resolve
+// against it and fail, since nothing else is loaded.
+const resourceURL = "urn:webhookd:request-schema"
+
+// Compiler compiles schemas with draft 2020-12 as the default. Schemas are
+// self-contained: references to other documents, files or URLs, are
+// refused rather than fetched.
+type Compiler struct{}
+
+func NewCompiler() *Compiler {
+	return &Compiler{}
+}
+
+var _ ports.SchemaCompiler = (*Compiler)(nil)
+
+// noLoader refuses every external reference.
+type noLoader struct{}
+
+func (noLoader) Load(url string) (any, error) {
+	return nil, fmt.Errorf("%s: references to other documents are not supported", url)
+}
+
+func (*Compiler) Compile(schema []byte) (ports.Schema, error) {
+	doc, err := js.UnmarshalJSON(bytes.NewReader(schema))
+	if err != nil {
+		return nil, err
+	}
+	c := js.NewCompiler()
+	c.DefaultDraft(js.Draft2020)
+	c.UseLoader(noLoader{})
+	if err := c.AddResource(resourceURL, doc); err != nil {
+		return nil, err
+	}
+	sch, err := c.Compile(resourceURL)
+	if err != nil {
+		return nil, err
+	}
+	return compiledSchema{sch}, nil
+}
+
+type compiledSchema struct{ sch *js.Schema }
+
+func (s compiledSchema) Validate(doc []byte) webhook.ValidationResult {
+	v, err := js.UnmarshalJSON(bytes.NewReader(doc))
+	if err != nil {
+		return webhook.ValidationResult{Errors: []webhook.ValidationError{{Message: "body is not valid JSON: " + err.Error()}}}
+	}
+	err = s.sch.Validate(v)
+	if err == nil {
+		return webhook.ValidationResult{Valid: true}
+	}
+	var ve *js.ValidationError
+	if !errors.As(err, &ve) {
+		return webhook.ValidationResult{Errors: []webhook.ValidationError{{Message: err.Error()}}}
+	}
+	var res webhook.ValidationResult
+	for _, u := range ve.BasicOutput().Errors {
+		if u.Error == nil {
+			continue
+		}
+		if _, group := u.Error.Kind.(*kind.Group); group {
+			// Wraps the errors below it, which are listed too.
+			continue
+		}
+		if len(res.Errors) == maxErrors {
+			res.Errors = append(res.Errors, webhook.ValidationError{Message: "more errors omitted"})
+			break
+		}
+		res.Errors = append(res.Errors, webhook.ValidationError{Location: u.InstanceLocation, Message: u.Error.String()})
+	}
+	if len(res.Errors) == 0 {
+		res.Errors = []webhook.ValidationError{{Message: ve.Error()}}
+	}
+	return res
+}
@@ -0,0 +1,469 @@
+// Package openapiimport turns an OpenAPI 3.x document into mock hooks: one
+// per path, answering each of its operations with an example response.
+package openapiimport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"webhookd/internal/domain/webhook"
+)
+
+// ErrInvalidDocument is returned for documents that can't be parsed or
+// aren't valid OpenAPI 3.x.
+var ErrInvalidDocument = errors.New("invalid OpenAPI document")
+
+const (
+	// maxDepth bounds the nesting of bodies generated from schemas.
+	maxDepth = 8
+	// maxNodes bounds the number of values generated for one operation, so
+	// schemas whose properties fan out into the same $refs can't blow up.
+	maxNodes = 10000
+)
+
+// Hook is the hook generated for the operations of one path.
+type Hook struct {
+	ID   webhook.ID
+	Path string // as in the document, e.g. /pets/{petId}
+	// Method answers the first operation, Response holds its response; the
+	// other operations are in Responses.
+	Method string
+	webhook.Response
+	Responses map[string]webhook.Response
+}
+
+// Methods lists the methods the hook answers, Method first.
+func (h Hook) Methods() []string {
+	return append([]string{h.Method}, slices.Sorted(maps.Keys(h.Responses))...)
+}
+
+// Skipped is an operation no hook answers, and why.
+type Skipped struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// Result is what Parse made of a document.
+type Result struct {
+	Title   string
+	Prefix  webhook.ID
+	Hooks   []Hook
+	Skipped []Skipped
+}
+
+// methodOrder is the order operations are considered in; the first one of a
+// path becomes its hook's Method. Hooks can't answer HEAD or TRACE.
+var methodOrder = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// Parse reads an OpenAPI 3.x document (JSON or YAML) and derives a hook for
+// each of its paths, with IDs below prefix; an empty prefix is made from the
+// document title. Path parameters are replaced by their example, or their
+// name. Each operation answers its lowest 2xx response (else the default
+// one), with the body of its example or one generated from its schema,
+// preferring JSON media types, and its declared headers.
+func Parse(ctx context.Context, data []byte, prefix webhook.ID) (Result, error) {
+	loader := openapi3.NewLoader()
+	loader.Context = ctx
+	doc, err := loader.LoadFromData(data)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return Result{}, fmt.Errorf("%w: openapi version %q is not 3.x", ErrInvalidDocument, doc.OpenAPI)
+	}
+	// Examples that don't match their schema are still good enough for a mock.
+	if err := doc.Validate(ctx, openapi3.DisableExamplesValidation(), openapi3.DisableSchemaDefaultsValidation()); err != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+
+	res := Result{Prefix: prefix}
+	if doc.Info != nil {
+		res.Title = doc.Info.Title
+	}
+	if res.Prefix == "" {
+		res.Prefix = webhook.ID(slug(res.Title))
+		if res.Prefix == "" {
+			res.Prefix = "openapi"
+		}
+	}
+	if err := res.Prefix.Validate(); err != nil {
+		return Result{}, fmt.Errorf("prefix: %w", err)
+	}
+	if doc.Paths == nil {
+		return res, nil
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(doc.Paths.Map())) {
+		item := doc.Paths.Value(path)
+		ops := item.Operations()
+		for _, m := range slices.Sorted(maps.Keys(ops)) {
+			if !slices.Contains(methodOrder, m) {
+				res.Skipped = append(res.Skipped, Skipped{Method: m, Path: path, Reason: "method not supported by hooks"})
+			}
+		}
+
+		id := hookID(res.Prefix, path, item)
+		if err := id.Validate(); err != nil {
+			for _, m := range methodOrder {
+				if ops[m] != nil {
+					res.Skipped = append(res.Skipped, Skipped{Method: m, Path: path, Reason: err.Error()})
+				}
+			}
+			continue
+		}
+
+		var h *Hook
+		for _, m := range methodOrder {
+			op := ops[m]
+			if op == nil {
+				continue
+			}
+			r, err := response(op)
+			if err != nil {
+				res.Skipped = append(res.Skipped, Skipped{Method: m, Path: path, Reason: err.Error()})
+				continue
+			}
+			if h == nil {
+				h = &Hook{ID: id, Path: path, Method: m, Response: r}
+				continue
+			}
+			if h.Responses == nil {
+				h.Responses = map[string]webhook.Response{}
+			}
+			h.Responses[m] = r
+		}
+		if h != nil {
+			res.Hooks = append(res.Hooks, *h)
+		}
+	}
+	return res, nil
+}
+
+var (
+	pathParam  = regexp.MustCompile(`\{([^}]+)\}`)
+	nonSlug    = regexp.MustCompile(`[^a-z0-9]+`)
+	validValue = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._~-]*$`)
+)
+
+// hookID maps path below prefix, filling in path parameters.
+func hookID(prefix webhook.ID, path string, item *openapi3.PathItem) webhook.ID {
+	path = pathParam.ReplaceAllStringFunc(path, func(m string) string {
+		name := m[1 : len(m)-1]
+		if v := paramExample(name, item); validValue.MatchString(v) {
+			return v
+		}
+		return name
+	})
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return prefix
+	}
+	return prefix + "/" + webhook.ID(path)
+}
+
+// paramExample finds an example value of the path parameter name, declared
+// on the path or any of its operations.
+func paramExample(name string, item *openapi3.PathItem) string {
+	params := slices.Clone(item.Parameters)
+	for _, m := range methodOrder {
+		if op := item.GetOperation(m); op != nil {
+			params = append(params, op.Parameters...)
+		}
+	}
+	for _, ref := range params {
+		p := ref.Value
+		if p == nil || p.In != openapi3.ParameterInPath || p.Name != name {
+			continue
+		}
+		v := p.Example
+		if v == nil {
+			v = firstExample(p.Examples)
+		}
+		if v == nil && p.Schema != nil {
+			v = schemaExample(p.Schema.Value)
+		}
+		if v != nil {
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+// response builds the hook response for op.
+func response(op *openapi3.Operation) (webhook.Response, error) {
+	if op.Responses == nil || op.Responses.Len() == 0 {
+		return webhook.Response{}, errors.New("no responses declared")
+	}
+	code, status := pickResponse(op.Responses)
+	ref := op.Responses.Value(code)
+	if ref == nil || ref.Value == nil {
+		return webhook.Response{}, fmt.Errorf("response %s is empty", code)
+	}
+	resp := ref.Value
+
+	smp := &sampler{budget: maxNodes}
+	r := webhook.Response{Status: status, Headers: map[string]string{}}
+	for _, name := range slices.Sorted(maps.Keys(resp.Headers)) {
+		hdr := resp.Headers[name].Value
+		if hdr == nil || strings.EqualFold(name, "Content-Type") || strings.EqualFold(name, "Content-Length") {
+			continue
+		}
+		v := hdr.Example
+		if v == nil {
+			v = firstExample(hdr.Examples)
+		}
+		if v == nil && hdr.Schema != nil {
+			v = smp.sample(hdr.Schema.Value, nil)
+		}
+		if v != nil {
+			r.Headers[name] = fmt.Sprint(v)
+		}
+	}
+	if smp.budget < 0 {
+		return webhook.Response{}, fmt.Errorf("response %s: generated headers exceed %d values", code, maxNodes)
+	}
+
+	ct, media := pickMedia(resp.Content)
+	if media == nil {
+		return r, nil
+	}
+	v := media.Example
+	if v == nil {
+		v = firstExample(media.Examples)
+	}
+	if v == nil && media.Schema != nil {
+		v = smp.sample(media.Schema.Value, nil)
+	}
+	if smp.budget < 0 {
+		return webhook.Response{}, fmt.Errorf("response %s: generated body exceeds %d values", code, maxNodes)
+	}
+	r.ContentType = ct
+	switch s, ok := v.(string); {
+	case ok && !isJSON(ct):
+		r.Body = s
+	case v != nil:
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return webhook.Response{}, fmt.Errorf("response %s: %v", code, err)
+		}
+		r.Body = string(b)
+		if r.ContentType == "" {
+			r.ContentType = "application/json"
+		}
+	}
+	return r, nil
+}
+
+// pickResponse chooses the response to serve: the lowest 2xx, then a 2XX
+// range, then default (as 200), then the lowest other code.
+func pickResponse(rs *openapi3.Responses) (code string, status int) {
+	var fallback string
+	for _, k := range slices.Sorted(maps.Keys(rs.Map())) {
+		n, err := strconv.Atoi(k)
+		switch {
+		case err == nil && n >= 200 && n < 300:
+			return k, n
+		case err == nil && fallback == "":
+			fallback = k
+		}
+	}
+	for _, k := range []string{"2XX", "2xx", "default"} {
+		if rs.Value(k) != nil {
+			return k, http.StatusOK
+		}
+	}
+	if n, err := strconv.Atoi(fallback); err == nil {
+		return fallback, n
+	}
+	// Only other ranges, e.g. 4XX.
+	k := slices.Sorted(maps.Keys(rs.Map()))[0]
+	n, _ := strconv.Atoi(k[:1])
+	return k, n * 100
+}
+
+// pickMedia prefers application/json, then other JSON types, then the first
+// media type in alphabetical order.
+func pickMedia(content openapi3.Content) (string, *openapi3.MediaType) {
+	if len(content) == 0 {
+		return "", nil
+	}
+	if m := content["application/json"]; m != nil {
+		return "application/json", m
+	}
+	types := slices.Sorted(maps.Keys(content))
+	for _, ct := range types {
+		if isJSON(ct) {
+			return ct, content[ct]
+		}
+	}
+	ct := types[0]
+	if strings.ContainsAny(ct, "*") {
+		// A range such as */* can't be a Content-Type; leave it to detection.
+		return "", content[ct]
+	}
+	return ct, content[ct]
+}
+
+func isJSON(ct string) bool {
+	return strings.HasSuffix(ct, "/json") || strings.HasSuffix(ct, "+json")
+}
+
+func firstExample(examples openapi3.Examples) any {
+	for _, k := range slices.Sorted(maps.Keys(examples)) {
+		if ex := examples[k]; ex != nil && ex.Value != nil && ex.Value.Value != nil {
+			return ex.Value.Value
+		}
+	}
+	return nil
+}
+
+// schemaExample returns the value a schema declares as example, default,
+// constant or first enum member, if any.
+func schemaExample(s *openapi3.Schema) any {
+	switch {
+	case s == nil:
+		return nil
+	case s.Example != nil:
+		return s.Example
+	case len(s.Examples) > 0:
+		return s.Examples[0]
+	case s.Default != nil:
+		return s.Default
+	case s.Const != nil:
+		return s.Const
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+	}
+	return nil
+}
+
+// sampler generates values from schemas, up to budget of them.
+type sampler struct {
+	budget int
+}
+
+// sample generates a value matching s. outer holds the schemas being
+// generated around it; recursive ones are left out (nil). Once the budget is
+// spent it returns nil and leaves budget negative.
+func (p *sampler) sample(s *openapi3.Schema, outer []*openapi3.Schema) any {
+	if s == nil || len(outer) > maxDepth || slices.Contains(outer, s) || p.budget < 0 {
+		return nil
+	}
+	if p.budget--; p.budget < 0 {
+		return nil
+	}
+	if v := schemaExample(s); v != nil {
+		return v
+	}
+	outer = append(outer, s)
+	if len(s.AllOf) > 0 {
+		merged := map[string]any{}
+		var last any
+		for _, ref := range s.AllOf {
+			last = p.sample(ref.Value, outer)
+			if m, ok := last.(map[string]any); ok {
+				maps.Copy(merged, m)
+			}
+		}
+		if len(merged) == 0 {
+			return last
+		}
+		return merged
+	}
+	for _, alts := range []openapi3.SchemaRefs{s.OneOf, s.AnyOf} {
+		if len(alts) > 0 {
+			return p.sample(alts[0].Value, outer)
+		}
+	}
+
+	switch {
+	case s.Type.Is("object") || (s.Type == nil && len(s.Properties) > 0):
+		m := map[string]any{}
+		for name, ref := range s.Properties {
+			if ref.Value == nil || ref.Value.WriteOnly {
+				continue
+			}
+			if v := p.sample(ref.Value, outer); v != nil {
+				m[name] = v
+			}
+		}
+		return m
+	case s.Type.Is("array"):
+		if s.Items == nil {
+			return []any{}
+		}
+		if v := p.sample(s.Items.Value, outer); v != nil {
+			return []any{v}
+		}
+		return []any{}
+	case s.Type.Is("string"):
+		return sampleString(s.Format)
+	case s.Type.Is("integer"):
+		if s.Min != nil {
+			return int64(*s.Min)
+		}
+		return 0
+	case s.Type.Is("number"):
+		if s.Min != nil {
+			return *s.Min
+		}
+		return 0.0
+	case s.Type.Is("boolean"):
+		return true
+	}
+	// Several or no types: use the first concrete one.
+	for _, t := range s.Type.Slice() {
+		if t != "null" {
+			c := *s
+			c.Type = &openapi3.Types{t}
+			p.budget++ // the same node, retried with one type
+			return p.sample(&c, outer[:len(outer)-1])
+		}
+	}
+	return nil
+}
+
+func sampleString(format string) string {
+	switch format {
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "date":
+		return "2024-01-01"
+	case "time":
+		return "00:00:00Z"
+	case "uuid":
+		return "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	case "email":
+		return "user@example.com"
+	case "uri", "url":
+		return "https://example.com"
+	case "hostname":
+		return "example.com"
+	case "ipv4":
+		return "192.0.2.1"
+	case "ipv6":
+		return "2001:db8::1"
+	case "byte":
+		return "c3RyaW5n"
+	default:
+		return "string"
+	}
+}
+
+// slug lowercases s and joins its words with "-".
+func slug(s string) string {
+	return strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
@@ -0,0 +1,70 @@
+package openapiimport
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fanOutSpec builds a document whose response schema has levels levels of
+// width properties each, all $ref-ing the next level: a full expansion has
+// width^levels leaves.
+func fanOutSpec(levels, width int) string {
+	var b strings.Builder
+	b.WriteString(`{"openapi":"3.0.3","info":{"title":"Fan","version":"1"},"paths":{"/fan":{"get":{"responses":{"200":{"description":"ok","content":{"application/json":{"schema":{"$ref":"#/components/schemas/L0"}}}}}}}},"components":{"schemas":{`)
+	for l := range levels {
+		if l > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `"L%d":{"type":"object","properties":{`, l)
+		for p := range width {
+			if p > 0 {
+				b.WriteString(",")
+			}
+			if l == levels-1 {
+				fmt.Fprintf(&b, `"p%d":{"type":"string"}`, p)
+			} else {
+				fmt.Fprintf(&b, `"p%d":{"$ref":"#/components/schemas/L%d"}`, p, l+1)
+			}
+		}
+		b.WriteString("}}")
+	}
+	b.WriteString("}}}")
+	return b.String()
+}
+
+func TestParseBoundsFanOut(t *testing.T) {
+	tests := []struct {
+		name          string
+		levels, width int
+		skipped       bool
+	}{
+		{name: "small", levels: 2, width: 3},
+		{name: "wide", levels: 5, width: 10, skipped: true},
+		{name: "huge", levels: 7, width: 12, skipped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Parse(context.Background(), []byte(fanOutSpec(tt.levels, tt.width)), "fan")
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !tt.skipped {
+				if len(res.Hooks) != 1 || len(res.Skipped) != 0 {
+					t.Fatalf("got %d hooks, skipped %v; want 1 hook", len(res.Hooks), res.Skipped)
+				}
+				return
+			}
+			if len(res.Hooks) != 0 {
+				t.Fatalf("got hook with %d byte body; want none", len(res.Hooks[0].Body))
+			}
+			if len(res.Skipped) != 1 || res.Skipped[0].Method != "GET" || res.Skipped[0].Path != "/fan" {
+				t.Fatalf("skipped = %v; want GET /fan", res.Skipped)
+			}
+			if !strings.Contains(res.Skipped[0].Reason, "exceeds") {
+				t.Errorf("reason = %q", res.Skipped[0].Reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package instrumented
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"webhookd/internal/application/ports"
+	"webhookd/internal/domain/webhook"
+	"webhookd/internal/observability"
+)
+
+var tracer = otel.Tracer("webhookd/internal/infrastructure/repository")
+
+// WebhooksRepo decorates a ports.WebhookRepository with a span and an operation
+// duration metric per call.
+type WebhooksRepo struct {
+	next ports.WebhookRepository
+	inst *observability.Instruments
+}
+
+func NewWebhooksRepo(next ports.WebhookRepository, inst *observability.Instruments) *WebhooksRepo {
+	return &WebhooksRepo{next: next, inst: inst}
+}
+
+func (r *WebhooksRepo) Create(ctx context.Context, h *webhook.Hook) (err error) {
+	ctx, done := r.start(ctx, "create", h.ID)
+	defer func() { done(err) }()
+	return r.next.Create(ctx, h)
+}
+
+func (r *WebhooksRepo) Get(ctx context.Context, id webhook.ID) (_ *webhook.Hook, _ bool, err error) {
+	ctx, done := r.start(ctx, "get", id)
+	defer func() { done(err) }()
+	return r.next.Get(ctx, id)
+}
+
+func (r *WebhooksRepo) Update(ctx context.Context, h *webhook.Hook) (_ *webhook.Hook, _ bool, err error) {
+	ctx, done := r.start(ctx, "update", h.ID)
+	defer func() { done(err) }()
+	return r.next.Update(ctx, h)
+}
+
+func (r *WebhooksRepo) Delete(ctx context.Context, id webhook.ID) (_ *webhook.Hook, _ bool, err error) {
+	ctx, done := r.start(ctx, "delete", id)
+	defer func() { done(err) }()
+	return r.next.Delete(ctx, id)
+}
+
+func (r *WebhooksRepo) Deactivate(ctx context.Context, id webhook.ID) (_ *webhook.Hook, _ bool, err error) {
+	ctx, done := r.start(ctx, "deactivate", id)
+	defer func() { done(err) }()
+	return r.next.Deactivate(ctx, id)
+}
+
+func (r *WebhooksRepo) Touch(ctx context.Context, id webhook.ID, now time.Time) (_ *webhook.Hook, _ bool, err error) {
+	ctx, done := r.start(ctx, "touch", id)
+	defer func() { done(err) }()
+	return r.next.Touch(ctx, id, now)
+}
+
+func (r *WebhooksRepo) List(ctx context.Context) (_ map[webhook.ID]*webhook.Hook, err error) {
+	ctx, done := r.start(ctx, "list", "")
+	defer func() { done(err) }()
+	return r.next.List(ctx)
+}
+
+// Ping forwards to the wrapped repository when it supports ports.Pinger.
+func (r *WebhooksRepo) Ping(ctx context.Context) (err error) {
+	p, ok := r.next.(ports.Pinger)
+	if !ok {
+		return nil
+	}
+	ctx, done := r.start(ctx, "ping", "")
+	defer func() { done(err) }()
+	return p.Ping(ctx)
+}
+
+// start opens the operation span; the returned func ends it and records the duration.
+func (r *WebhooksRepo) start(ctx context.Context, op string, id webhook.ID) (context.Context, func(error)) {
+	attrs := []attribute.KeyValue{attribute.String("webhookd.repository.operation", op)}
+	if id != "" {
+		attrs = append(attrs, attribute.String("webhookd.hook.id", string(id)))
+	}
+	ctx, span := tracer.Start(ctx, "repository."+op,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attrs...),
+	)
+	start := time.Now()
+	return ctx, func(err error) {
+		r.inst.RepositoryOp(ctx, op, time.Since(start), err)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"webhookd/internal/domain/audit"
+)
+
+type AuditRepo struct {
+	mu      sync.RWMutex
+	entries []audit.Entry
+}
+
+func NewAuditRepo() *AuditRepo {
+	return &AuditRepo{}
+}
+
+func (r *AuditRepo) Append(_ context.Context, e audit.Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e.Before = e.Before.Clone()
+	e.After = e.After.Clone()
+	r.entries = append(r.entries, e)
+	return nil
+}
+
+func (r *AuditRepo) Query(_ context.Context, f audit.Filter) ([]audit.Entry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := []audit.Entry{}
+	for _, e := range r.entries {
+		if !f.Matches(e) {
+			continue
+		}
+		e.Before = e.Before.Clone()
+		e.After = e.After.Clone()
+		out = append(out, e)
+	}
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[len(out)-f.Limit:]
+	}
+	return out, nil
+}
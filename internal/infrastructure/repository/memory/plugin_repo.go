@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+
+	"webhookd/internal/domain/plugin"
+)
+
+// PluginRepo keeps plugin modules.
+type PluginRepo struct {
+	mu      sync.RWMutex
+	plugins map[string]storedPlugin
+}
+
+type storedPlugin struct {
+	meta   plugin.Plugin
+	module []byte
+}
+
+func NewPluginRepo() *PluginRepo {
+	return &PluginRepo{plugins: map[string]storedPlugin{}}
+}
+
+func (r *PluginRepo) Put(_ context.Context, p plugin.Plugin, module []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.plugins[p.Name] = storedPlugin{meta: p, module: slices.Clone(module)}
+	return nil
+}
+
+// Get returns the stored module, which callers must not modify.
+func (r *PluginRepo) Get(_ context.Context, name string) (plugin.Plugin, []byte, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.plugins[name]
+	return p.meta, p.module, ok, nil
+}
+
+func (r *PluginRepo) List(_ context.Context) ([]plugin.Plugin, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]plugin.Plugin, 0, len(r.plugins))
+	for _, name := range slices.Sorted(maps.Keys(r.plugins)) {
+		out = append(out, r.plugins[name].meta)
+	}
+	return out, nil
+}
+
+func (r *PluginRepo) Delete(_ context.Context, name string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.plugins[name]
+	delete(r.plugins, name)
+	return ok, nil
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"webhookd/internal/domain/webhook"
+)
+
+const defaultRequestsPerHook = 100
+
+// RequestLog retains the last N captured requests per hook. Bodies are cut to
+// webhook.MaxLoggedBody, which bounds the memory each hook takes.
+type RequestLog struct {
+	perHook int
+
+	mu   sync.RWMutex
+	reqs map[webhook.ID][]webhook.Request
+}
+
+func NewRequestLog(perHook int) *RequestLog {
+	if perHook <= 0 {
+		perHook = defaultRequestsPerHook
+	}
+	return &RequestLog{perHook: perHook, reqs: map[webhook.ID][]webhook.Request{}}
+}
+
+func (l *RequestLog) Append(_ context.Context, r webhook.Request) error {
+	if len(r.Body) > webhook.MaxLoggedBody {
+		r.Body, r.BodyTruncated = r.Body[:webhook.MaxLoggedBody], true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rs := append(l.reqs[r.HookID], r.Clone())
+	if len(rs) > l.perHook {
+		// Copy instead of reslicing so the dropped requests can be collected.
+		rs = append([]webhook.Request(nil), rs[len(rs)-l.perHook:]...)
+	}
+	l.reqs[r.HookID] = rs
+	return nil
+}
+
+func (l *RequestLog) List(_ context.Context, id webhook.ID, limit int) ([]webhook.Request, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	rs := l.reqs[id]
+	if limit > 0 && len(rs) > limit {
+		rs = rs[len(rs)-limit:]
+	}
+	out := make([]webhook.Request, len(rs))
+	for i, r := range rs {
+		out[i] = r.Clone()
+	}
+	return out, nil
+}
+
+func (l *RequestLog) Forget(_ context.Context, id webhook.ID) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.reqs, id)
+	return nil
+}
@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"webhookd/internal/domain/webhook"
+)
+
+func TestRequestLogBounds(t *testing.T) {
+	ctx := context.Background()
+	l := NewRequestLog(2)
+	big := bytes.Repeat([]byte("x"), webhook.MaxLoggedBody+1)
+	for i, body := range [][]byte{[]byte("a"), []byte("b"), big} {
+		if err := l.Append(ctx, webhook.Request{ID: string(rune('0' + i)), HookID: "h", Body: body}); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	if len(big) != webhook.MaxLoggedBody+1 {
+		t.Fatal("Append changed the caller's body")
+	}
+	got, err := l.List(ctx, "h", 0)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "1" || got[1].ID != "2" {
+		t.Fatalf("got %d requests, want the last 2", len(got))
+	}
+	if got[0].BodyTruncated || string(got[0].Body) != "b" {
+		t.Errorf("small body: %q, truncated %v", got[0].Body, got[0].BodyTruncated)
+	}
+	if !got[1].BodyTruncated || len(got[1].Body) != webhook.MaxLoggedBody {
+		t.Errorf("big body: %d bytes, truncated %v; want %d, true", len(got[1].Body), got[1].BodyTruncated, webhook.MaxLoggedBody)
+	}
+}
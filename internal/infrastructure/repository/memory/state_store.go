@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"sync"
+
+	"webhookd/internal/domain/webhook"
+)
+
+// StateStore keeps the key-value stores of hooks.
+type StateStore struct {
+	mu     sync.RWMutex
+	stores map[webhook.ID]map[string]string
+	sizes  map[webhook.ID]int // bytes of keys and values per store
+}
+
+func NewStateStore() *StateStore {
+	return &StateStore{stores: map[webhook.ID]map[string]string{}, sizes: map[webhook.ID]int{}}
+}
+
+func (s *StateStore) Get(_ context.Context, id webhook.ID, key string) (string, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.stores[id][key]
+	return v, ok, nil
+}
+
+func (s *StateStore) Put(_ context.Context, id webhook.ID, key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	store := s.stores[id]
+	if store == nil {
+		store = map[string]string{}
+		s.stores[id] = store
+	}
+	if len(value) > webhook.MaxStoreValue {
+		return webhook.ErrStoreFull
+	}
+	old, exists := store[key]
+	if !exists && len(store) >= webhook.MaxStoreKeys {
+		return webhook.ErrStoreFull
+	}
+	size := s.sizes[id] + len(value)
+	if exists {
+		size -= len(old)
+	} else {
+		size += len(key)
+	}
+	if size > webhook.MaxStoreBytes {
+		return webhook.ErrStoreFull
+	}
+	store[key] = value
+	s.sizes[id] = size
+	return nil
+}
+
+func (s *StateStore) Delete(_ context.Context, id webhook.ID, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.stores[id][key]; ok {
+		delete(s.stores[id], key)
+		s.sizes[id] -= len(key) + len(v)
+	}
+	return nil
+}
+
+func (s *StateStore) List(_ context.Context, id webhook.ID) (map[string]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := maps.Clone(s.stores[id])
+	if out == nil {
+		out = map[string]string{}
+	}
+	return out, nil
+}
+
+func (s *StateStore) Reset(_ context.Context, id webhook.ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.stores, id)
+	delete(s.sizes, id)
+	return nil
+}
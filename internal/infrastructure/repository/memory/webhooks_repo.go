@@ -20,6 +20,9 @@ func NewWebhooksRepo() *WebhooksRepo {
 func (r *WebhooksRepo) Create(_ context.Context, h *webhook.Hook) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	if _, exists := r.hooks[h.ID]; exists {
+		return webhook.ErrIDExists
+	}
 	r.hooks[h.ID] = cloneHook(h)
 	return nil
 }
@@ -34,6 +37,27 @@ func (r *WebhooksRepo) Get(_ context.Context, id webhook.ID) (*webhook.Hook, boo
 	return cloneHook(h), true, nil
 }
 
+func (r *WebhooksRepo) Update(_ context.Context, h *webhook.Hook) (*webhook.Hook, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.hooks[h.ID]; !ok {
+		return nil, false, nil
+	}
+	r.hooks[h.ID] = cloneHook(h)
+	return cloneHook(h), true, nil
+}
+
+func (r *WebhooksRepo) Delete(_ context.Context, id webhook.ID) (*webhook.Hook, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h, ok := r.hooks[id]
+	if !ok {
+		return nil, false, nil
+	}
+	delete(r.hooks, id)
+	return cloneHook(h), true, nil
+}
+
 func (r *WebhooksRepo) Deactivate(_ context.Context, id webhook.ID) (*webhook.Hook, bool, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -70,6 +94,11 @@ func (r *WebhooksRepo) List(_ context.Context) (map[webhook.ID]*webhook.Hook, er
 	return out, nil
 }
 
+// Ping always succeeds; the map lives in-process.
+func (r *WebhooksRepo) Ping(context.Context) error {
+	return nil
+}
+
 func cloneHook(h *webhook.Hook) *webhook.Hook {
 	if h == nil {
 		return nil
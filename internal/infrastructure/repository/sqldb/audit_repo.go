@@ -0,0 +1,157 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"webhookd/internal/domain/audit"
+	"webhookd/internal/domain/webhook"
+)
+
+const auditSchema = `CREATE TABLE IF NOT EXISTS audit_log (
+	id           TEXT PRIMARY KEY,
+	at_unix_nano BIGINT NOT NULL,
+	action       TEXT NOT NULL,
+	hook_id      TEXT NOT NULL,
+	actor        TEXT NOT NULL,
+	source_ip    TEXT NOT NULL,
+	before_json  TEXT,
+	after_json   TEXT
+)`
+
+var auditIndexes = []string{
+	`CREATE INDEX IF NOT EXISTS audit_log_at ON audit_log (at_unix_nano)`,
+	`CREATE INDEX IF NOT EXISTS audit_log_hook_id ON audit_log (hook_id)`,
+}
+
+type AuditRepo struct {
+	db *DB
+}
+
+// NewAuditRepo creates the audit_log table if needed.
+func NewAuditRepo(ctx context.Context, db *DB) (*AuditRepo, error) {
+	for _, stmt := range append([]string{auditSchema}, auditIndexes...) {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return nil, fmt.Errorf("sqldb: migrate audit_log: %w", err)
+		}
+	}
+	return &AuditRepo{db: db}, nil
+}
+
+func (r *AuditRepo) Append(ctx context.Context, e audit.Entry) error {
+	before, err := marshalHook(e.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshalHook(e.After)
+	if err != nil {
+		return err
+	}
+
+	ph := r.db.Dialect.Placeholder
+	q := "INSERT INTO audit_log (id, at_unix_nano, action, hook_id, actor, source_ip, before_json, after_json) VALUES (" +
+		strings.Join([]string{ph(1), ph(2), ph(3), ph(4), ph(5), ph(6), ph(7), ph(8)}, ", ") + ")"
+	_, err = r.db.ExecContext(ctx, q,
+		e.ID, e.Time.UTC().UnixNano(), string(e.Action), string(e.HookID), e.Actor, e.SourceIP, before, after)
+	return err
+}
+
+func (r *AuditRepo) Query(ctx context.Context, f audit.Filter) ([]audit.Entry, error) {
+	var (
+		where []string
+		args  []any
+	)
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, cond+" "+r.db.Dialect.Placeholder(len(args)))
+	}
+	if f.HookID != "" {
+		add("hook_id =", string(f.HookID))
+	}
+	if f.Actor != "" {
+		add("actor =", f.Actor)
+	}
+	if f.Action != "" {
+		add("action =", string(f.Action))
+	}
+	if !f.Since.IsZero() {
+		add("at_unix_nano >=", f.Since.UTC().UnixNano())
+	}
+	if !f.Until.IsZero() {
+		add("at_unix_nano <", f.Until.UTC().UnixNano())
+	}
+
+	q := "SELECT id, at_unix_nano, action, hook_id, actor, source_ip, before_json, after_json FROM audit_log"
+	if len(where) > 0 {
+		q += " WHERE " + strings.Join(where, " AND ")
+	}
+	// Newest first so LIMIT keeps the most recent entries; reversed below.
+	q += " ORDER BY at_unix_nano DESC, id DESC"
+	if f.Limit > 0 {
+		q += " LIMIT " + strconv.Itoa(f.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []audit.Entry{}
+	for rows.Next() {
+		var (
+			e             audit.Entry
+			at            int64
+			action, hook  string
+			before, after sql.NullString
+		)
+		if err := rows.Scan(&e.ID, &at, &action, &hook, &e.Actor, &e.SourceIP, &before, &after); err != nil {
+			return nil, err
+		}
+		e.Time = time.Unix(0, at).UTC()
+		e.Action = audit.Action(action)
+		e.HookID = webhook.ID(hook)
+		if e.Before, err = unmarshalHook(before); err != nil {
+			return nil, err
+		}
+		if e.After, err = unmarshalHook(after); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, nil
+}
+
+func marshalHook(h *webhook.Hook) (sql.NullString, error) {
+	if h == nil {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(h)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+func unmarshalHook(s sql.NullString) (*webhook.Hook, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	var h webhook.Hook
+	if err := json.Unmarshal([]byte(s.String), &h); err != nil {
+		return nil, fmt.Errorf("sqldb: decode hook snapshot: %w", err)
+	}
+	return &h, nil
+}
@@ -0,0 +1,157 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // registers "pgx"
+	_ "modernc.org/sqlite"             // registers "sqlite"
+
+	"webhookd/internal/infrastructure/configfile"
+)
+
+// Dialect covers the few places where SQLite and Postgres disagree.
+type Dialect string
+
+const (
+	SQLite   Dialect = "sqlite"
+	Postgres Dialect = "postgres"
+)
+
+// Placeholder returns the n-th (1-based) bind parameter.
+func (d Dialect) Placeholder(n int) string {
+	if d == Postgres {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+type DB struct {
+	*sql.DB
+	Dialect Dialect
+}
+
+// Open connects using the db section of the config and applies pool settings
+// and SQLite pragmas.
+func Open(ctx context.Context, cfg configfile.DBConfig) (*DB, error) {
+	var (
+		dialect    Dialect
+		driverName string
+	)
+	switch strings.ToLower(strings.TrimSpace(cfg.Driver)) {
+	case "sqlite":
+		dialect, driverName = SQLite, "sqlite"
+	case "postgres":
+		dialect, driverName = Postgres, "pgx"
+	default:
+		return nil, fmt.Errorf("sqldb: unsupported driver %q", cfg.Driver)
+	}
+
+	db, err := sql.Open(driverName, cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("sqldb: open %s: %w", dialect, err)
+	}
+
+	if cfg.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetimeSeconds > 0 {
+		db.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetimeSeconds) * time.Second)
+	}
+	if cfg.ConnMaxIdleTimeSeconds > 0 {
+		db.SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleTimeSeconds) * time.Second)
+	}
+
+	if dialect == SQLite {
+		if isSQLiteMemory(cfg.DSN) {
+			// Every connection to :memory: gets its own empty database.
+			db.SetMaxOpenConns(1)
+			db.SetConnMaxLifetime(0)
+			db.SetConnMaxIdleTime(0)
+		}
+		if err := applyPragmas(ctx, db, cfg.SQLitePragmas); err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("sqldb: ping %s: %w", dialect, err)
+	}
+
+	return &DB{DB: db, Dialect: dialect}, nil
+}
+
+// Ping satisfies ports.Pinger-style readiness checks.
+func (db *DB) Ping(ctx context.Context) error {
+	return db.PingContext(ctx)
+}
+
+func isSQLiteMemory(dsn string) bool {
+	dsn = strings.TrimSpace(dsn)
+	return dsn == "" || dsn == ":memory:" || strings.Contains(dsn, "mode=memory")
+}
+
+func applyPragmas(ctx context.Context, db *sql.DB, pragmas map[string]string) error {
+	defaults := map[string]string{
+		"busy_timeout": "5000",
+		"foreign_keys": "ON",
+	}
+	merged := make(map[string]string, len(defaults)+len(pragmas))
+	for k, v := range defaults {
+		merged[k] = v
+	}
+	for k, v := range pragmas {
+		merged[k] = v
+	}
+
+	keys := make([]string, 0, len(merged))
+	for k := range merged {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !isIdent(k) || !isPragmaValue(merged[k]) {
+			return fmt.Errorf("sqldb: invalid sqlite pragma %q=%q", k, merged[k])
+		}
+		if _, err := db.ExecContext(ctx, "PRAGMA "+k+" = "+merged[k]); err != nil {
+			return fmt.Errorf("sqldb: pragma %s: %w", k, err)
+		}
+	}
+	return nil
+}
+
+// Pragmas can't be bound as parameters, so keys and values are restricted to
+// a safe charset before being interpolated.
+func isIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+func isPragmaValue(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r == '_' || r == '-' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,236 @@
+// Package wasmplugin runs plugins (ports.PluginRuntime): WebAssembly modules
+// for WASI preview 1, on the wazero runtime (pure Go, no cgo).
+//
+// A plugin is a WASI command: each request runs it from _start, in a fresh
+// instance. It reads the request as JSON from stdin:
+//
+//	{"method": "POST", "path": "/v1/hooks/pricing", "query": {"q": ["a"]},
+//	 "headers": {"Content-Type": ["application/json"]}, "body": "{...}"}
+//
+// (body_base64 replaces body when the body isn't UTF-8; cloudevent holds
+// the attributes of the CloudEvent the request carries, if any) and writes
+// the response as JSON to stdout, every field optional:
+//
+//	{"status": 201, "headers": {"Location": "/x/1"}, "body": "..."}
+//
+// (or body_base64 for binary bodies). Exiting with a code other than 0 fails
+// the request; what the plugin wrote to stderr is in the error. Plugins get
+// no files, environment or network, only clocks and random numbers, and run
+// with at most MemoryLimit of memory for at most Timeout. A runtime runs a
+// bounded number of instances at once; requests past it fail with
+// webhook.ErrPluginBusy.
+package wasmplugin
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+
+	"webhookd/internal/application/ports"
+	"webhookd/internal/domain/webhook"
+)
+
+const (
+	// MemoryLimit bounds the memory of a plugin instance.
+	MemoryLimit = 64 << 20
+	// Timeout bounds the run time of a plugin, per request.
+	Timeout = time.Second
+	// DefaultMaxInstances is how many instances run at once unless
+	// NewRuntime is told otherwise.
+	DefaultMaxInstances = 16
+)
+
+const (
+	pageSize = 64 << 10
+	// maxStdout bounds the response a plugin writes.
+	maxStdout = 4 << 20
+	// maxStderr is how much of stderr is kept for errors.
+	maxStderr = 1 << 10
+)
+
+// Runtime compiles and runs plugins; Close it to release them all.
+type Runtime struct {
+	rt wazero.Runtime
+	// slots holds a token per running instance.
+	slots chan struct{}
+}
+
+// NewRuntime returns a runtime running at most maxInstances plugin
+// instances at once (DefaultMaxInstances when <= 0).
+func NewRuntime(ctx context.Context, maxInstances int) (*Runtime, error) {
+	if maxInstances <= 0 {
+		maxInstances = DefaultMaxInstances
+	}
+	rt := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(MemoryLimit/pageSize).
+		WithCloseOnContextDone(true))
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, rt); err != nil {
+		_ = rt.Close(ctx)
+		return nil, err
+	}
+	return &Runtime{rt: rt, slots: make(chan struct{}, maxInstances)}, nil
+}
+
+func (r *Runtime) Close(ctx context.Context) error {
+	return r.rt.Close(ctx)
+}
+
+var _ ports.PluginRuntime = (*Runtime)(nil)
+
+// Compile checks that wasm is a WASI command importing nothing but WASI.
+func (r *Runtime) Compile(ctx context.Context, wasm []byte) (ports.PluginModule, error) {
+	cm, err := r.rt.CompileModule(ctx, wasm)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := cm.ExportedFunctions()["_start"]; !ok {
+		_ = cm.Close(ctx)
+		return nil, errors.New("not a WASI command: no _start function exported")
+	}
+	for _, f := range cm.ImportedFunctions() {
+		if mod, name, _ := f.Import(); mod != wasi_snapshot_preview1.ModuleName {
+			_ = cm.Close(ctx)
+			return nil, fmt.Errorf("imports %s.%s; plugins can only import %s", mod, name, wasi_snapshot_preview1.ModuleName)
+		}
+	}
+	return module{rt: r.rt, cm: cm, slots: r.slots}, nil
+}
+
+type module struct {
+	rt    wazero.Runtime
+	cm    wazero.CompiledModule
+	slots chan struct{}
+}
+
+func (m module) Close(ctx context.Context) error {
+	return m.cm.Close(ctx)
+}
+
+// request is the JSON a plugin reads from stdin.
+type request struct {
+	Method     string              `json:"method"`
+	Path       string              `json:"path"`
+	Query      map[string][]string `json:"query"`
+	Headers    map[string][]string `json:"headers"`
+	Body       string              `json:"body,omitempty"`
+	BodyBase64 []byte              `json:"body_base64,omitempty"`
+	CloudEvent *webhook.CloudEvent `json:"cloudevent,omitempty"`
+}
+
+// response is the JSON a plugin writes to stdout.
+type response struct {
+	Status     int               `json:"status"`
+	Headers    map[string]string `json:"headers"`
+	Body       *string           `json:"body"`
+	BodyBase64 []byte            `json:"body_base64"`
+}
+
+func (m module) Run(ctx context.Context, req webhook.TemplateData) (webhook.ScriptResult, error) {
+	select {
+	case m.slots <- struct{}{}:
+		defer func() { <-m.slots }()
+	default:
+		return webhook.ScriptResult{}, fmt.Errorf("%w: %d instances running", webhook.ErrPluginBusy, cap(m.slots))
+	}
+
+	in := request{Method: req.Method, Path: req.Path, Query: req.Query, Headers: req.Header, CloudEvent: req.CloudEvent}
+	if utf8.ValidString(req.Body) {
+		in.Body = req.Body
+	} else {
+		in.BodyBase64 = []byte(req.Body)
+	}
+	stdin, err := json.Marshal(in)
+	if err != nil {
+		return webhook.ScriptResult{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+	stdout := &limitedBuffer{n: maxStdout}
+	stderr := &limitedBuffer{n: maxStderr, truncate: true}
+	cfg := wazero.NewModuleConfig().
+		WithName(""). // anonymous, so that requests run side by side
+		WithArgs("plugin").
+		WithStdin(bytes.NewReader(stdin)).
+		WithStdout(stdout).
+		WithStderr(stderr).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithSysNanosleep().
+		WithRandSource(rand.Reader)
+	mod, err := m.rt.InstantiateModule(ctx, m.cm, cfg)
+	if mod != nil {
+		_ = mod.Close(ctx)
+	}
+	if err != nil {
+		return webhook.ScriptResult{}, runError(ctx, err, stdout, stderr)
+	}
+	return result(stdout.buf.Bytes())
+}
+
+// runError explains why a plugin failed.
+func runError(ctx context.Context, err error, stdout, stderr *limitedBuffer) error {
+	var exit *sys.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		err = fmt.Errorf("took longer than %v", Timeout)
+	case stdout.full:
+		err = fmt.Errorf("wrote more than %d bytes to stdout", maxStdout)
+	case errors.As(err, &exit):
+		err = fmt.Errorf("exited with code %d", exit.ExitCode())
+	}
+	if msg := strings.TrimSpace(stderr.buf.String()); msg != "" {
+		return fmt.Errorf("%w; stderr: %s", err, msg)
+	}
+	return err
+}
+
+func result(stdout []byte) (webhook.ScriptResult, error) {
+	var res webhook.ScriptResult
+	if len(bytes.TrimSpace(stdout)) == 0 {
+		return res, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(stdout))
+	dec.DisallowUnknownFields()
+	var out response
+	if err := dec.Decode(&out); err != nil {
+		return res, fmt.Errorf("response on stdout: %v", err)
+	}
+	res.Status, res.Headers, res.Body = out.Status, out.Headers, out.Body
+	if out.BodyBase64 != nil {
+		body := string(out.BodyBase64)
+		res.Body, res.ContentType = &body, "application/octet-stream"
+	}
+	return res, nil
+}
+
+// limitedBuffer keeps up to n bytes. Past that, writes fail, or are dropped
+// with truncate.
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	n        int
+	truncate bool
+	full     bool
+}
+
+func (l *limitedBuffer) Write(p []byte) (int, error) {
+	if room := l.n - l.buf.Len(); len(p) > room {
+		l.full = true
+		if !l.truncate {
+			return 0, errors.New("output too large")
+		}
+		l.buf.Write(p[:max(room, 0)])
+		return len(p), nil
+	}
+	return l.buf.Write(p)
+}
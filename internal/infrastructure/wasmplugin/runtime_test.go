@@ -0,0 +1,17 @@
+package wasmplugin
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"webhookd/internal/domain/webhook"
+)
+
+func TestRunRefusesPastMaxInstances(t *testing.T) {
+	m := module{slots: make(chan struct{}, 1)}
+	m.slots <- struct{}{} // one instance running
+	if _, err := m.Run(context.Background(), webhook.TemplateData{}); !errors.Is(err, webhook.ErrPluginBusy) {
+		t.Fatalf("Run: %v, want ErrPluginBusy", err)
+	}
+}
@@ -0,0 +1,104 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// exportStatus tracks the result of the most recent export per signal. Like
+// the OTel providers themselves it is process-global; it is set by Setup.
+var exportStatus atomic.Pointer[exporterStatus]
+
+type exporterStatus struct {
+	mu   sync.Mutex
+	last map[string]error // signal -> last export error (nil = ok)
+}
+
+func (s *exporterStatus) record(signal string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last[signal] = err
+}
+
+func (s *exporterStatus) check() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	signals := make([]string, 0, len(s.last))
+	for signal, err := range s.last {
+		if err != nil {
+			signals = append(signals, signal)
+		}
+	}
+	sort.Strings(signals)
+	var errs []error
+	for _, signal := range signals {
+		errs = append(errs, fmt.Errorf("%s export: %w", signal, s.last[signal]))
+	}
+	return errors.Join(errs...)
+}
+
+// ExporterHealthCheck returns a readiness check that fails while the most
+// recent export of any signal failed, or nil when OpenTelemetry is disabled.
+func ExporterHealthCheck() func(context.Context) error {
+	s := exportStatus.Load()
+	if s == nil {
+		return nil
+	}
+	return func(context.Context) error { return s.check() }
+}
+
+func trackExporters(exps exporters) exporters {
+	s := &exporterStatus{last: map[string]error{}}
+	exportStatus.Store(s)
+
+	exps.traces = statusSpanExporter{exps.traces, s}
+	if exps.metrics != nil {
+		exps.metrics = statusMetricExporter{exps.metrics, s}
+	}
+	if exps.logs != nil {
+		exps.logs = statusLogExporter{exps.logs, s}
+	}
+	return exps
+}
+
+type statusSpanExporter struct {
+	sdktrace.SpanExporter
+	status *exporterStatus
+}
+
+func (e statusSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	e.status.record("traces", err)
+	return err
+}
+
+type statusMetricExporter struct {
+	sdkmetric.Exporter
+	status *exporterStatus
+}
+
+func (e statusMetricExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	err := e.Exporter.Export(ctx, rm)
+	e.status.record("metrics", err)
+	return err
+}
+
+type statusLogExporter struct {
+	sdklog.Exporter
+	status *exporterStatus
+}
+
+func (e statusLogExporter) Export(ctx context.Context, records []sdklog.Record) error {
+	err := e.Exporter.Export(ctx, records)
+	e.status.record("logs", err)
+	return err
+}
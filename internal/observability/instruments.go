@@ -0,0 +1,87 @@
+package observability
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const instrumentationName = "webhookd"
+
+// Instruments are the OTel metric instruments exported through the OTLP metrics
+// pipeline. They are created from the global MeterProvider, so they are no-ops
+// until Setup installs one (the global delegates once it is set).
+type Instruments struct {
+	invocations        metric.Int64Counter
+	invocationDuration metric.Float64Histogram
+	repoDuration       metric.Float64Histogram
+}
+
+func NewInstruments() (*Instruments, error) {
+	meter := otel.Meter(instrumentationName)
+
+	invocations, err := meter.Int64Counter("webhookd.hook.invocations",
+		metric.WithDescription("Hook invocations by hook id, request method and served status."),
+		metric.WithUnit("{invocation}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	invocationDuration, err := meter.Float64Histogram("webhookd.hook.invocation.duration",
+		metric.WithDescription("Time spent serving hook invocations."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	repoDuration, err := meter.Float64Histogram("webhookd.repository.operation.duration",
+		metric.WithDescription("Duration of webhook repository operations."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Instruments{
+		invocations:        invocations,
+		invocationDuration: invocationDuration,
+		repoDuration:       repoDuration,
+	}, nil
+}
+
+func (i *Instruments) HookInvoked(ctx context.Context, hookID, method string, status int, fault string, d time.Duration) {
+	if i == nil {
+		return
+	}
+	kvs := []attribute.KeyValue{
+		attribute.String("webhookd.hook.id", hookID),
+		attribute.String("http.request.method", method),
+	}
+	if status != 0 {
+		kvs = append(kvs, attribute.String("http.response.status_code", strconv.Itoa(status)))
+	}
+	if fault != "" {
+		kvs = append(kvs, attribute.String("webhookd.hook.fault", fault))
+	}
+	attrs := metric.WithAttributes(kvs...)
+	i.invocations.Add(ctx, 1, attrs)
+	i.invocationDuration.Record(ctx, d.Seconds(), attrs)
+}
+
+func (i *Instruments) RepositoryOp(ctx context.Context, op string, d time.Duration, err error) {
+	if i == nil {
+		return
+	}
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	i.repoDuration.Record(ctx, d.Seconds(), metric.WithAttributes(
+		attribute.String("webhookd.repository.operation", op),
+		attribute.String("webhookd.repository.outcome", outcome),
+	))
+}
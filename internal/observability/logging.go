@@ -0,0 +1,107 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+type LogOptions struct {
+	Format    string       // text (default) | json
+	Level     slog.Leveler // defaults to info; pass a *slog.LevelVar to change it at runtime
+	AddSource bool
+	// ServiceName names the OTel log bridge scope.
+	ServiceName string
+}
+
+// NewLogger builds the process logger: console output in the chosen format, teed
+// into the OTLP logs pipeline, with trace_id/span_id added from the active span.
+func NewLogger(w io.Writer, opts LogOptions) (*slog.Logger, error) {
+	level := opts.Level
+	if level == nil {
+		level = slog.LevelInfo
+	}
+	hopts := &slog.HandlerOptions{Level: level, AddSource: opts.AddSource}
+
+	var console slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case "", LogFormatText:
+		console = slog.NewTextHandler(w, hopts)
+	case LogFormatJSON:
+		console = slog.NewJSONHandler(w, hopts)
+	default:
+		return nil, fmt.Errorf("unsupported log format %q (allowed: %s, %s)", opts.Format, LogFormatText, LogFormatJSON)
+	}
+
+	name := opts.ServiceName
+	if name == "" {
+		name = "webhookd"
+	}
+
+	// The bridge already links records to the active span, so only the console
+	// handler needs explicit trace attributes.
+	return slog.New(TeeHandler(
+		traceContextHandler{console},
+		levelGate{LogHandler(name), level},
+	)), nil
+}
+
+// ParseLevel accepts debug|info|warn|error (case-insensitive).
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("unsupported log level %q (allowed: debug, info, warn, error)", s)
+	}
+	return l, nil
+}
+
+// traceContextHandler adds trace_id and span_id to records logged with a
+// context that carries a recording span, so logs can be joined with traces.
+type traceContextHandler struct {
+	slog.Handler
+}
+
+func (h traceContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h traceContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceContextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h traceContextHandler) WithGroup(name string) slog.Handler {
+	return traceContextHandler{h.Handler.WithGroup(name)}
+}
+
+// levelGate applies the configured level to handlers that don't take one (the OTel bridge).
+type levelGate struct {
+	slog.Handler
+	level slog.Leveler
+}
+
+func (h levelGate) Enabled(ctx context.Context, l slog.Level) bool {
+	return l >= h.level.Level() && h.Handler.Enabled(ctx, l)
+}
+
+func (h levelGate) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return levelGate{h.Handler.WithAttrs(attrs), h.level}
+}
+
+func (h levelGate) WithGroup(name string) slog.Handler {
+	return levelGate{h.Handler.WithGroup(name), h.level}
+}
@@ -0,0 +1,175 @@
+package observability
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsNamespace = "webhookd"
+
+// Metrics holds the Prometheus collectors exposed on /metrics.
+//
+// It uses its own registry (not prometheus.DefaultRegisterer) so that tests and
+// multiple app instances in one process don't collide on registration.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests    *prometheus.CounterVec
+	httpDuration    *prometheus.HistogramVec
+	hookInvocations *prometheus.CounterVec
+	hookFaults      *prometheus.CounterVec
+	hookValidations *prometheus.CounterVec
+	jwksRefreshes   *prometheus.CounterVec
+	configReloads   *prometheus.CounterVec
+}
+
+type MetricsOptions struct {
+	// ActiveHooks is sampled on every scrape to report the number of active hooks.
+	// Optional; the gauge is not registered when nil.
+	ActiveHooks func() float64
+}
+
+func NewMetrics(opts MetricsOptions) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests by method, route template and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by method, route template and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		hookInvocations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "hook",
+			Name:      "invocations_total",
+			Help:      "Hook invocations by hook id, request method and outcome (served status, or the fault that cut the response short).",
+		}, []string{"hook_id", "method", "outcome"}),
+		hookFaults: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "hook",
+			Name:      "faults_total",
+			Help:      "Faults injected by hook chaos settings, by hook id and fault (delay|drip|truncate|error|abort).",
+		}, []string{"hook_id", "fault"}),
+		hookValidations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "hook",
+			Name:      "validations_total",
+			Help:      "Request bodies checked against hook schemas, by hook id and result (valid|invalid).",
+		}, []string{"hook_id", "result"}),
+		jwksRefreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "auth",
+			Name:      "jwks_refreshes_total",
+			Help:      "JWKS refresh attempts by result (success|failure).",
+		}, []string{"result"}),
+		configReloads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "config",
+			Name:      "reloads_total",
+			Help:      "Config reload attempts by result (success|failure).",
+		}, []string{"result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.hookInvocations,
+		m.hookFaults,
+		m.hookValidations,
+		m.jwksRefreshes,
+		m.configReloads,
+	)
+
+	if opts.ActiveHooks != nil {
+		m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "hook",
+			Name:      "active",
+			Help:      "Number of active hooks in the repository.",
+		}, opts.ActiveHooks))
+	}
+
+	return m
+}
+
+// Handler serves the registry in the Prometheus text exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+func (m *Metrics) ObserveHTTP(method, route string, status int, d time.Duration) {
+	if m == nil {
+		return
+	}
+	code := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(method, route, code).Inc()
+	m.httpDuration.WithLabelValues(method, route, code).Observe(d.Seconds())
+}
+
+func (m *Metrics) HookInvoked(hookID, method string, status int, fault string) {
+	if m == nil {
+		return
+	}
+	outcome := fault
+	if outcome == "" {
+		outcome = strconv.Itoa(status)
+	}
+	m.hookInvocations.WithLabelValues(hookID, method, outcome).Inc()
+}
+
+// HookFaulted counts a fault injected into an invocation (see webhook.Fault.Kind).
+func (m *Metrics) HookFaulted(hookID, fault string) {
+	if m == nil {
+		return
+	}
+	m.hookFaults.WithLabelValues(hookID, fault).Inc()
+}
+
+// HookValidated counts a request body checked against a hook's schema.
+func (m *Metrics) HookValidated(hookID string, valid bool) {
+	if m == nil {
+		return
+	}
+	result := "invalid"
+	if valid {
+		result = "valid"
+	}
+	m.hookValidations.WithLabelValues(hookID, result).Inc()
+}
+
+// JWKSRefreshed matches jwtmiddleware.Config.OnJWKSRefresh.
+func (m *Metrics) JWKSRefreshed(err error) {
+	if m == nil {
+		return
+	}
+	if err != nil {
+		m.jwksRefreshes.WithLabelValues("failure").Inc()
+		return
+	}
+	m.jwksRefreshes.WithLabelValues("success").Inc()
+}
+
+func (m *Metrics) ConfigReloaded(err error) {
+	if m == nil {
+		return
+	}
+	if err != nil {
+		m.configReloads.WithLabelValues("failure").Inc()
+		return
+	}
+	m.configReloads.WithLabelValues("success").Inc()
+}
@@ -4,21 +4,36 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
-	"strconv"
+	"net/url"
 	"strings"
 	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutlog"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/log/global"
 	"go.opentelemetry.io/otel/propagation"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
 type Config struct {
 	Enabled         bool
+	Exporter        string // otlp (default) | stdout
+	Metrics         bool   // also run the metrics pipeline
+	Logs            bool   // also run the logs pipeline (see LogHandler)
 	ServiceName     string
 	ServiceVersion  string
 	Environment     string
@@ -26,21 +41,11 @@ type Config struct {
 	OTLPHeaders     map[string]string
 	Insecure        bool
 	SampleRatio     float64 // 0..1
+	MetricInterval  time.Duration
 	StartupTimeout  time.Duration
 	ShutdownTimeout time.Duration
 }
 
-func SetupFromEnv(ctx context.Context, serviceName, serviceVersion string) (shutdown func(context.Context) error, _ error) {
-	cfg, err := configFromEnv(serviceName, serviceVersion)
-	if err != nil {
-		return nil, err
-	}
-	if !cfg.Enabled {
-		return func(context.Context) error { return nil }, nil
-	}
-	return Setup(ctx, cfg)
-}
-
 func Setup(parent context.Context, cfg Config) (shutdown func(context.Context) error, _ error) {
 	if cfg.ServiceName == "" {
 		cfg.ServiceName = "webhookd"
@@ -60,23 +65,17 @@ func Setup(parent context.Context, cfg Config) (shutdown func(context.Context) e
 	if cfg.SampleRatio > 1 {
 		cfg.SampleRatio = 1
 	}
+	if cfg.MetricInterval <= 0 {
+		cfg.MetricInterval = 60 * time.Second
+	}
+	if cfg.Exporter == "" {
+		cfg.Exporter = ExporterOTLP
+	}
 
 	if cfg.OTLPEndpoint == "" {
 		// Safe local default if the user explicitly enables OTel.
 		cfg.OTLPEndpoint = "http://localhost:4318"
 	}
-	endpointURL := normalizeOTLPEndpoint(cfg.OTLPEndpoint, cfg.Insecure)
-
-	setupCtx, cancel := context.WithTimeout(context.Background(), cfg.StartupTimeout)
-	defer cancel()
-
-	exp, err := otlptracehttp.New(setupCtx,
-		otlptracehttp.WithEndpointURL(endpointURL),
-		otlptracehttp.WithHeaders(cfg.OTLPHeaders),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("create otlp trace exporter: %w", err)
-	}
 
 	res, err := resource.New(parent,
 		resource.WithFromEnv(),
@@ -94,79 +93,119 @@ func Setup(parent context.Context, cfg Config) (shutdown func(context.Context) e
 		return nil, fmt.Errorf("create resource: %w", err)
 	}
 
+	setupCtx, cancel := context.WithTimeout(context.Background(), cfg.StartupTimeout)
+	defer cancel()
+
+	exps, err := newExporters(setupCtx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	exps = trackExporters(exps)
+
+	// Collected in creation order, shut down in reverse.
+	var shutdowns []func(context.Context) error
+
 	tp := sdktrace.NewTracerProvider(
 		sdktrace.WithResource(res),
-		sdktrace.WithBatcher(exp),
+		sdktrace.WithBatcher(exps.traces),
 		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
 	)
-
+	shutdowns = append(shutdowns, tp.Shutdown)
 	otel.SetTracerProvider(tp)
 	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
 		propagation.TraceContext{},
 		propagation.Baggage{},
 	))
 
+	if exps.metrics != nil {
+		mp := sdkmetric.NewMeterProvider(
+			sdkmetric.WithResource(res),
+			sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exps.metrics, sdkmetric.WithInterval(cfg.MetricInterval))),
+		)
+		shutdowns = append(shutdowns, mp.Shutdown)
+		otel.SetMeterProvider(mp)
+	}
+
+	if exps.logs != nil {
+		lp := sdklog.NewLoggerProvider(
+			sdklog.WithResource(res),
+			sdklog.WithProcessor(sdklog.NewBatchProcessor(exps.logs)),
+		)
+		shutdowns = append(shutdowns, lp.Shutdown)
+		global.SetLoggerProvider(lp)
+	}
+
 	return func(ctx context.Context) error {
 		if ctx == nil {
 			ctx = context.Background()
 		}
 		shutdownCtx, cancel := context.WithTimeout(ctx, cfg.ShutdownTimeout)
 		defer cancel()
-		return tp.Shutdown(shutdownCtx)
+		var errs []error
+		for i := len(shutdowns) - 1; i >= 0; i-- {
+			errs = append(errs, shutdowns[i](shutdownCtx))
+		}
+		return errors.Join(errs...)
 	}, nil
 }
 
-func configFromEnv(serviceName, serviceVersion string) (Config, error) {
-	enabled, err := getenvBool("WEBHOOKD_OTEL_ENABLED", false)
-	if err != nil {
-		return Config{}, err
-	}
+type exporters struct {
+	traces  sdktrace.SpanExporter
+	metrics sdkmetric.Exporter // nil when the metrics pipeline is disabled
+	logs    sdklog.Exporter    // nil when the logs pipeline is disabled
+}
 
-	// If the user set standard OTEL endpoints, implicitly enable.
-	otelEndpoint := firstNonEmpty(
-		os.Getenv("WEBHOOKD_OTEL_EXPORTER_OTLP_ENDPOINT"),
-		os.Getenv("WEBHOOKD_OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"),
-		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"),
-		os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+func newExporters(ctx context.Context, cfg Config) (exporters, error) {
+	var (
+		out exporters
+		err error
 	)
-	if !enabled && otelEndpoint != "" {
-		enabled = true
-	}
 
-	insecure, err := getenvBool("WEBHOOKD_OTEL_EXPORTER_OTLP_INSECURE", false)
-	if err != nil {
-		return Config{}, err
-	}
-	if !insecure {
-		// Standard env name.
-		insecure, _ = getenvBool("OTEL_EXPORTER_OTLP_INSECURE", false)
-	}
-
-	sampleRatio, err := getenvFloat("WEBHOOKD_OTEL_TRACES_SAMPLER_RATIO", 1)
-	if err != nil {
-		return Config{}, err
-	}
-
-	headers, err := parseHeaders(firstNonEmpty(
-		os.Getenv("WEBHOOKD_OTEL_EXPORTER_OTLP_HEADERS"),
-		os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"),
-	))
-	if err != nil {
-		return Config{}, err
+	switch cfg.Exporter {
+	case ExporterStdout:
+		// Offline debugging: everything goes to stdout, nothing leaves the process.
+		if out.traces, err = stdouttrace.New(stdouttrace.WithPrettyPrint()); err != nil {
+			return exporters{}, fmt.Errorf("create stdout trace exporter: %w", err)
+		}
+		if cfg.Metrics {
+			if out.metrics, err = stdoutmetric.New(stdoutmetric.WithPrettyPrint()); err != nil {
+				return exporters{}, fmt.Errorf("create stdout metric exporter: %w", err)
+			}
+		}
+		if cfg.Logs {
+			if out.logs, err = stdoutlog.New(stdoutlog.WithPrettyPrint()); err != nil {
+				return exporters{}, fmt.Errorf("create stdout log exporter: %w", err)
+			}
+		}
+	case ExporterOTLP:
+		endpointURL := normalizeOTLPEndpoint(cfg.OTLPEndpoint, cfg.Insecure)
+		if out.traces, err = otlptracehttp.New(ctx,
+			otlptracehttp.WithEndpointURL(withSignalPath(endpointURL, "/v1/traces")),
+			otlptracehttp.WithHeaders(cfg.OTLPHeaders),
+		); err != nil {
+			return exporters{}, fmt.Errorf("create otlp trace exporter: %w", err)
+		}
+		if cfg.Metrics {
+			if out.metrics, err = otlpmetrichttp.New(ctx,
+				otlpmetrichttp.WithEndpointURL(withSignalPath(endpointURL, "/v1/metrics")),
+				otlpmetrichttp.WithHeaders(cfg.OTLPHeaders),
+			); err != nil {
+				return exporters{}, fmt.Errorf("create otlp metric exporter: %w", err)
+			}
+		}
+		if cfg.Logs {
+			if out.logs, err = otlploghttp.New(ctx,
+				otlploghttp.WithEndpointURL(withSignalPath(endpointURL, "/v1/logs")),
+				otlploghttp.WithHeaders(cfg.OTLPHeaders),
+			); err != nil {
+				return exporters{}, fmt.Errorf("create otlp log exporter: %w", err)
+			}
+		}
+	default:
+		return exporters{}, fmt.Errorf("unsupported exporter %q (allowed: %s, %s)", cfg.Exporter, ExporterOTLP, ExporterStdout)
 	}
 
-	env := firstNonEmpty(os.Getenv("WEBHOOKD_ENV"), os.Getenv("ENV"), os.Getenv("OTEL_ENVIRONMENT"))
-
-	return Config{
-		Enabled:        enabled,
-		ServiceName:    firstNonEmpty(os.Getenv("WEBHOOKD_OTEL_SERVICE_NAME"), os.Getenv("OTEL_SERVICE_NAME"), serviceName),
-		ServiceVersion: serviceVersion,
-		Environment:    env,
-		OTLPEndpoint:   otelEndpoint,
-		OTLPHeaders:    headers,
-		Insecure:       insecure,
-		SampleRatio:    sampleRatio,
-	}, nil
+	return out, nil
 }
 
 func normalizeOTLPEndpoint(v string, insecure bool) string {
@@ -183,61 +222,13 @@ func normalizeOTLPEndpoint(v string, insecure bool) string {
 	return "https://" + v
 }
 
-func parseHeaders(v string) (map[string]string, error) {
-	v = strings.TrimSpace(v)
-	if v == "" {
-		return nil, nil
-	}
-	parts := strings.Split(v, ",")
-	out := make(map[string]string, 0)
-	for _, p := range parts {
-		p = strings.TrimSpace(p)
-		if p == "" {
-			continue
-		}
-		k, val, ok := strings.Cut(p, "=")
-		if !ok {
-			return nil, fmt.Errorf("invalid header %q (expected key=value)", p)
-		}
-		k = strings.TrimSpace(k)
-		val = strings.TrimSpace(val)
-		if k == "" {
-			return nil, errors.New("invalid header with empty key")
-		}
-		out[k] = val
-	}
-	return out, nil
-}
-
-func getenvBool(name string, def bool) (bool, error) {
-	v := strings.TrimSpace(os.Getenv(name))
-	if v == "" {
-		return def, nil
-	}
-	b, err := strconv.ParseBool(v)
-	if err != nil {
-		return false, fmt.Errorf("%s: %w", name, err)
-	}
-	return b, nil
-}
-
-func getenvFloat(name string, def float64) (float64, error) {
-	v := strings.TrimSpace(os.Getenv(name))
-	if v == "" {
-		return def, nil
-	}
-	f, err := strconv.ParseFloat(v, 64)
-	if err != nil {
-		return 0, fmt.Errorf("%s: %w", name, err)
-	}
-	return f, nil
-}
-
-func firstNonEmpty(vs ...string) string {
-	for _, v := range vs {
-		if strings.TrimSpace(v) != "" {
-			return v
-		}
+// withSignalPath appends the per-signal OTLP path (e.g. /v1/traces) when the
+// endpoint is a bare base URL, matching OTEL_EXPORTER_OTLP_ENDPOINT semantics.
+func withSignalPath(endpoint, signalPath string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Path != "" && u.Path != "/") {
+		return endpoint
 	}
-	return ""
+	u.Path = signalPath
+	return u.String()
 }
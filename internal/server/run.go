@@ -1,88 +0,0 @@
-package server
-
-import (
-	"context"
-	"fmt"
-	"log"
-	"net"
-	"os"
-	"os/signal"
-	"syscall"
-	"time"
-
-	"github.com/joho/godotenv"
-
-	"webhookd/internal/application/webhooks"
-	"webhookd/internal/infrastructure/configfile"
-	"webhookd/internal/infrastructure/repository/memory"
-	"webhookd/internal/observability"
-	"webhookd/internal/transport/httpapi"
-)
-
-type Options struct {
-	Addr       string
-	ConfigPath string
-	Debug      bool
-	Verbose    bool
-	Version    string
-}
-
-func Run(ctx context.Context, opts Options) error {
-	// Optional .env load for local dev.
-	_ = godotenv.Load()
-
-	otelShutdown, err := observability.SetupFromEnv(ctx, "webhookd", opts.Version)
-	if err != nil {
-		return fmt.Errorf("opentelemetry setup: %w", err)
-	}
-
-	cfg, err := configfile.ParseFile(opts.ConfigPath)
-	if err != nil {
-		return fmt.Errorf("parse config: %w", err)
-	}
-
-	repo := memory.NewWebhooksRepo()
-	svc := webhooks.NewService(repo)
-
-	app, err := httpapi.NewApp(httpapi.Deps{
-		Version:  opts.Version,
-		Config:   cfg,
-		Webhooks: svc,
-	})
-	if err != nil {
-		return err
-	}
-
-	// Fiber wants an addr string, but we validate it to fail early with a good error.
-	if _, _, err := net.SplitHostPort(opts.Addr); err != nil {
-		return fmt.Errorf("invalid addr %q: %w", opts.Addr, err)
-	}
-
-	errCh := make(chan error, 1)
-	go func() {
-		errCh <- app.Listen(opts.Addr)
-	}()
-
-	log.Printf("listening on %s (version=%s)", opts.Addr, opts.Version)
-
-	// Shutdown on signals or parent context cancellation.
-	sigs := make(chan os.Signal, 2)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-	defer signal.Stop(sigs)
-
-	select {
-	case <-ctx.Done():
-	case <-sigs:
-	case err := <-errCh:
-		return err
-	}
-
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	appErr := app.ShutdownWithContext(shutdownCtx)
-	otelErr := otelShutdown(shutdownCtx)
-	if appErr != nil {
-		return appErr
-	}
-	return otelErr
-}
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"webhookd/internal/infrastructure/configfile"
+)
+
+const defaultServerURL = "http://localhost:1337"
+
+// ClientOptions configure how the client subcommands reach a running server.
+// Precedence: flags > env (WEBHOOKD_SERVER_URL, WEBHOOKD_TOKEN) > config file "client" section > defaults.
+type ClientOptions struct {
+	ServerURL string
+	Token     string
+	Output    string // table | json
+	Timeout   time.Duration
+}
+
+// addClientFlags registers the connection flags shared by client subcommands.
+func addClientFlags(fs *pflag.FlagSet, opts *ClientOptions) {
+	fs.StringVar(&opts.ServerURL, "server", "", "Server base URL (env WEBHOOKD_SERVER_URL, config client.server_url; default "+defaultServerURL+")")
+	fs.StringVar(&opts.Token, "token", "", "Bearer token (env WEBHOOKD_TOKEN, config client.token)")
+	fs.StringVarP(&opts.Output, "output", "o", opts.Output, "Output format: table or json")
+}
+
+type apiClient struct {
+	base  string
+	token string
+	http  *http.Client
+}
+
+// newAPIClient resolves the server URL and token; root supplies the --config path.
+func newAPIClient(root *RootOptions, opts *ClientOptions) (*apiClient, error) {
+	serverURL, token := opts.ServerURL, opts.Token
+
+	if serverURL == "" {
+		serverURL = os.Getenv("WEBHOOKD_SERVER_URL")
+	}
+	if token == "" {
+		token = os.Getenv("WEBHOOKD_TOKEN")
+	}
+	if serverURL == "" || token == "" {
+		// No config file is fine for the client (Load then returns defaults).
+		loaded, err := configfile.Load(configfile.LoadOptions{Path: root.Config})
+		if err != nil {
+			return nil, fmt.Errorf("parse config: %w", err)
+		}
+		if serverURL == "" {
+			serverURL = loaded.Config.Client.ServerURL
+		}
+		if token == "" {
+			token = loaded.Config.Client.Token
+		}
+	}
+	if serverURL == "" {
+		serverURL = defaultServerURL
+	}
+	if _, err := url.Parse(serverURL); err != nil {
+		return nil, fmt.Errorf("invalid server url %q: %w", serverURL, err)
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &apiClient{
+		base:  strings.TrimRight(serverURL, "/"),
+		token: token,
+		http:  &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// apiError mirrors Huma's RFC 9457 error model.
+type apiError struct {
+	Status int    `json:"status"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+	Errors []struct {
+		Message  string `json:"message"`
+		Location string `json:"location"`
+	} `json:"errors"`
+}
+
+func (e *apiError) Error() string {
+	msg := fmt.Sprintf("%d %s", e.Status, e.Title)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	for _, d := range e.Errors {
+		msg += fmt.Sprintf("\n  %s: %s", d.Location, d.Message)
+	}
+	return msg
+}
+
+func decodeAPIError(resp *http.Response) *apiError {
+	apiErr := &apiError{Status: resp.StatusCode, Title: http.StatusText(resp.StatusCode)}
+	_ = json.NewDecoder(resp.Body).Decode(apiErr)
+	return apiErr
+}
+
+func (c *apiClient) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+// do calls a management endpoint: in is sent as JSON (when non-nil) and the
+// response is decoded into out (when non-nil).
+func (c *apiClient) do(ctx context.Context, method, path string, in, out any) error {
+	if in == nil {
+		return c.send(ctx, method, path, "", nil, out)
+	}
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return c.send(ctx, method, path, "application/json", bytes.NewReader(b), out)
+}
+
+// send is do with a body of the given content type.
+func (c *apiClient) send(ctx context.Context, method, path, contentType string, body io.Reader, out any) error {
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return decodeAPIError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/spf13/cobra"
+
+	"webhookd/internal/infrastructure/configfile"
+)
+
+func newConfigCmd(root *RootOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect and validate the server configuration",
+	}
+	cmd.AddCommand(
+		newConfigValidateCmd(root),
+		newConfigShowCmd(root),
+		newConfigSchemaCmd(),
+	)
+	return cmd
+}
+
+func newConfigValidateCmd(root *RootOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate [path]",
+		Short: "Check a config file (defaults to --config)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := root.Config
+			if len(args) == 1 {
+				path = args[0]
+			}
+			loaded, err := loadConfig(cmd, root, path)
+			if err != nil {
+				return err
+			}
+			w := cmd.OutOrStdout()
+			switch {
+			case loaded.Path == "":
+				fmt.Fprintf(w, "no config file found (looked for %s); the server would start with defaults\n", strings.Join(configfile.DefaultPaths, ", "))
+			case loaded.Legacy:
+				fmt.Fprintf(w, "%s: ok (legacy file name, deprecated; rename it to %s)\n", loaded.Path, configfile.DefaultPath)
+			default:
+				fmt.Fprintf(w, "%s: ok\n", loaded.Path)
+			}
+			return nil
+		},
+	}
+}
+
+func newConfigShowCmd(root *RootOptions) *cobra.Command {
+	output := "table"
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Print the effective config with the source of each value: default, file, env or flag (secrets redacted)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			loaded, err := loadConfig(cmd, root, root.Config)
+			if err != nil {
+				return err
+			}
+			cfg := loaded.Config.Redacted()
+			w := cmd.OutOrStdout()
+
+			if output == "json" {
+				return writeJSON(w, struct {
+					Path    string            `json:"path,omitempty"`
+					Config  configfile.Config `json:"config"`
+					Sources map[string]string `json:"sources"`
+				}{loaded.Path, cfg, loaded.Sources})
+			}
+
+			if loaded.Path != "" {
+				fmt.Fprintf(w, "# %s\n", loaded.Path)
+			} else {
+				fmt.Fprintln(w, "# no config file found")
+			}
+			fields := cfg.Fields()
+			rows := make([][]string, 0, len(fields))
+			for _, k := range configfile.SortedKeys(fields) {
+				rows = append(rows, []string{k, formatConfigValue(fields[k]), loaded.Sources[k]})
+			}
+			return writeTable(w, []string{"KEY", "VALUE", "SOURCE"}, rows)
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", output, "Output format: table or json")
+	return cmd
+}
+
+// loadConfig assembles the config exactly as serve would: file, environment
+// and the root flags given on this command line.
+// Warnings go to stderr.
+func loadConfig(cmd *cobra.Command, root *RootOptions, path string) (configfile.Loaded, error) {
+	loaded, err := configfile.Load(configfile.LoadOptions{
+		Path:      path,
+		EnvPrefix: configfile.EnvPrefix,
+		Overrides: flagOverrides(cmd, root),
+	})
+	for _, w := range loaded.Warnings {
+		fmt.Fprintf(cmd.ErrOrStderr(), "warning: %v\n", w)
+	}
+	return loaded, err
+}
+
+func formatConfigValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "-"
+	case string:
+		if v == "" {
+			return `""`
+		}
+		return v
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
+
+func newConfigSchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print a JSON Schema of the config file, for editor validation and completion",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return writeConfigSchema(cmd.OutOrStdout())
+		},
+	}
+}
+
+// writeConfigSchema derives the schema from configfile.Config using Huma's
+// schema generator, so struct tags (doc, enum, default, minimum) are the
+// single source of truth.
+func writeConfigSchema(w io.Writer) error {
+	registry := huma.NewMapRegistry("#/$defs/", huma.DefaultSchemaNamer)
+	top := registry.Schema(reflect.TypeOf(configfile.Config{}), false, "")
+
+	// Every key is optional in a config file; Huma marks fields without
+	// omitempty as required.
+	top.Required = nil
+	defs := map[string]*huma.Schema{}
+	for name, s := range registry.Map() {
+		s.Required = nil
+		if name != "Config" { // inlined as the root
+			defs[name] = s
+		}
+	}
+
+	out := map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   "webhookd configuration",
+	}
+	b, err := json.Marshal(top)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return err
+	}
+	// Objects are closed (additionalProperties: false), so allow the "$schema"
+	// key editors use to associate a file with this schema.
+	if props, ok := out["properties"].(map[string]any); ok {
+		props["$schema"] = map[string]any{"type": "string", "description": "URL or path of this schema"}
+	}
+	if len(defs) > 0 {
+		out["$defs"] = defs
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(out)
+}
@@ -0,0 +1,705 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+
+	"webhookd/internal/domain/webhook"
+	"webhookd/internal/transport/httpapi"
+)
+
+func newHooksCmd(root *RootOptions) *cobra.Command {
+	opts := &ClientOptions{Output: "table"}
+
+	cmd := &cobra.Command{
+		Use:   "hooks",
+		Short: "Manage webhooks on a running server",
+	}
+	addClientFlags(cmd.PersistentFlags(), opts)
+	cmd.PersistentFlags().DurationVar(&opts.Timeout, "timeout", 30*time.Second, "Request timeout")
+
+	cmd.AddCommand(
+		newHooksCreateCmd(root, opts),
+		newHooksListCmd(root, opts),
+		newHooksGetCmd(root, opts),
+		newHooksUpdateCmd(root, opts),
+		newHooksDeleteCmd(root, opts),
+		newHooksInvokeCmd(root, opts),
+		newHooksRequestsCmd(root, opts),
+		newHooksImportCmd(root, opts),
+		newHooksStateCmd(root, opts),
+	)
+	return cmd
+}
+
+func newHooksCreateCmd(root *RootOptions, opts *ClientOptions) *cobra.Command {
+	var (
+		id          string
+		method      string
+		body        string
+		bodyFile    string
+		contentType string
+		chaos       string
+		proxy       webhook.Proxy
+		schema      string
+		schemaMode  string
+		template    bool
+		script      string
+		plugin      string
+		headers     []string
+		status      int
+	)
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a webhook",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			hdrs, err := parseHeaderFlags(headers)
+			if err != nil {
+				return err
+			}
+			c, err := newAPIClient(root, opts)
+			if err != nil {
+				return err
+			}
+			var out struct {
+				ID   string `json:"id"`
+				Path string `json:"path"`
+			}
+			in := map[string]any{"method": method, "headers": hdrs, "status": status}
+			if err := setBody(in, body); err != nil {
+				return err
+			}
+			if id != "" {
+				in["id"] = id
+			}
+			if bodyFile != "" {
+				in["body_file"] = bodyFile
+			}
+			if contentType != "" {
+				in["content_type"] = contentType
+			}
+			if template {
+				in["template"] = true
+			}
+			if script != "" {
+				if in["script"], err = readScript(script); err != nil {
+					return err
+				}
+			}
+			if plugin != "" {
+				in["plugin"] = plugin
+			}
+			if chaos != "" {
+				if in["chaos"], err = webhook.ParseChaos(chaos); err != nil {
+					return err
+				}
+			}
+			if proxy.Upstream != "" {
+				proxy.Mode = webhook.ProxyRecord
+				in["proxy"] = proxy
+			} else if proxy != (webhook.Proxy{}) {
+				return fmt.Errorf("--proxy-cloudevents, --proxy-event-type and --proxy-dedupe require --proxy")
+			}
+			if schema != "" {
+				v := webhook.RequestValidation{Mode: schemaMode}
+				if v.Schema, err = readSchema(schema); err != nil {
+					return err
+				}
+				in["validation"] = v
+			}
+			if err := c.do(cmd.Context(), http.MethodPost, "/v1/webhooks", in, &out); err != nil {
+				return err
+			}
+			if opts.Output == "json" {
+				return writeJSON(cmd.OutOrStdout(), out)
+			}
+			return writeTable(cmd.OutOrStdout(), []string{"ID", "URL"}, [][]string{{out.ID, c.base + out.Path}})
+		},
+	}
+	cmd.Flags().StringVar(&id, "id", "", `Custom id, e.g. "github/org-events" (default: a random UUID)`)
+	cmd.Flags().StringVarP(&method, "method", "X", "GET", "HTTP method the webhook answers (or ANY)")
+	cmd.Flags().StringVarP(&body, "body", "d", "", "Response body (@file reads it from a local file)")
+	cmd.Flags().StringVar(&bodyFile, "body-file", "", "Serve this file from the server's body directory")
+	cmd.Flags().StringVar(&contentType, "content-type", "", "Content-Type of responses (overrides a Content-Type header)")
+	cmd.Flags().BoolVar(&template, "template", false, "Render the body and header values as Go templates")
+	cmd.Flags().StringVar(&script, "script", "", "CEL expression computing responses from the request (@file reads it from a local file)")
+	cmd.Flags().StringVar(&plugin, "plugin", "", "WebAssembly plugin computing responses (see webhookd plugins)")
+	cmd.Flags().StringVar(&chaos, "chaos", "", `Chaos settings, e.g. "delay_ms=200,delay_max_ms=800,error_rate=0.1,error_status=503"`)
+	cmd.Flags().StringVar(&proxy.Upstream, "proxy", "", "Record responses of this upstream URL below the webhook")
+	addProxyEventFlags(cmd, &proxy)
+	cmd.Flags().StringVar(&schema, "schema", "", "Local JSON Schema file request bodies must match")
+	cmd.Flags().StringVar(&schemaMode, "schema-mode", "", "reject answers mismatching requests with 422 (default); flag only records the result")
+	cmd.Flags().StringArrayVarP(&headers, "header", "H", nil, `Response header as "Key: Value" (repeatable)`)
+	cmd.Flags().IntVar(&status, "status", http.StatusOK, "Response status code")
+	return cmd
+}
+
+func newHooksListCmd(root *RootOptions, opts *ClientOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List webhooks",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := newAPIClient(root, opts)
+			if err != nil {
+				return err
+			}
+			var out struct {
+				Hooks []*webhook.Hook `json:"hooks"`
+			}
+			if err := c.do(cmd.Context(), http.MethodGet, "/v1/webhooks", nil, &out); err != nil {
+				return err
+			}
+			if opts.Output == "json" {
+				return writeJSON(cmd.OutOrStdout(), out.Hooks)
+			}
+			return writeHooksTable(cmd.OutOrStdout(), out.Hooks)
+		},
+	}
+}
+
+func newHooksGetCmd(root *RootOptions, opts *ClientOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <id>",
+		Short: "Show a webhook",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newAPIClient(root, opts)
+			if err != nil {
+				return err
+			}
+			var h webhook.Hook
+			if err := c.do(cmd.Context(), http.MethodGet, "/v1/webhooks/"+url.PathEscape(args[0]), nil, &h); err != nil {
+				return err
+			}
+			if opts.Output == "json" {
+				return writeJSON(cmd.OutOrStdout(), h)
+			}
+			return writeHooksTable(cmd.OutOrStdout(), []*webhook.Hook{&h})
+		},
+	}
+}
+
+func newHooksUpdateCmd(root *RootOptions, opts *ClientOptions) *cobra.Command {
+	var (
+		method      string
+		body        string
+		bodyFile    string
+		contentType string
+		chaos       string
+		proxy       webhook.Proxy
+		schema      string
+		schemaMode  string
+		template    bool
+		script      string
+		plugin      string
+		headers     []string
+		status      int
+	)
+	cmd := &cobra.Command{
+		Use:   "update <id>",
+		Short: "Update a webhook (only the flags given are changed)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in := map[string]any{}
+			if cmd.Flags().Changed("method") {
+				in["method"] = method
+			}
+			if cmd.Flags().Changed("body") {
+				if err := setBody(in, body); err != nil {
+					return err
+				}
+			}
+			if cmd.Flags().Changed("body-file") {
+				in["body_file"] = bodyFile
+			}
+			if cmd.Flags().Changed("content-type") {
+				in["content_type"] = contentType
+			}
+			if cmd.Flags().Changed("template") {
+				in["template"] = template
+			}
+			if cmd.Flags().Changed("script") {
+				s, err := readScript(script)
+				if err != nil {
+					return err
+				}
+				in["script"] = s
+			}
+			if cmd.Flags().Changed("plugin") {
+				in["plugin"] = plugin
+			}
+			if cmd.Flags().Changed("chaos") {
+				c, err := webhook.ParseChaos(chaos)
+				if err != nil {
+					return err
+				}
+				in["chaos"] = c // "" gives {}, which removes the settings
+			}
+			if cmd.Flags().Changed("header") {
+				hdrs, err := parseHeaderFlags(headers)
+				if err != nil {
+					return err
+				}
+				in["headers"] = hdrs
+			}
+			if cmd.Flags().Changed("status") {
+				in["status"] = status
+			}
+			proxyChanged := slices.ContainsFunc(proxyFlags, cmd.Flags().Changed)
+			schemaChanged := cmd.Flags().Changed("schema") || cmd.Flags().Changed("schema-mode")
+			if len(in) == 0 && !proxyChanged && !schemaChanged {
+				return fmt.Errorf("nothing to update: pass --method, --body, --body-file, --content-type, --template, --script, --plugin, --chaos, --proxy, --proxy-mode, --proxy-cloudevents, --proxy-event-type, --proxy-dedupe, --schema, --schema-mode, --header or --status")
+			}
+			c, err := newAPIClient(root, opts)
+			if err != nil {
+				return err
+			}
+			path := "/v1/webhooks/" + url.PathEscape(args[0])
+			if proxyChanged {
+				// The API replaces the proxy settings as a whole; keep what
+				// isn't changed.
+				p, err := proxySettings(cmd, c, path, proxy)
+				if err != nil {
+					return err
+				}
+				in["proxy"] = p
+			}
+			if schemaChanged {
+				v, err := validationSettings(cmd, c, path, schema, schemaMode)
+				if err != nil {
+					return err
+				}
+				in["validation"] = v
+			}
+			var out map[string]any
+			if err := c.do(cmd.Context(), http.MethodPatch, path, in, &out); err != nil {
+				return err
+			}
+			return writeMessage(cmd.OutOrStdout(), opts, out)
+		},
+	}
+	cmd.Flags().StringVarP(&method, "method", "X", "", "HTTP method the webhook answers (or ANY)")
+	cmd.Flags().StringVarP(&body, "body", "d", "", "Response body (@file reads it from a local file)")
+	cmd.Flags().StringVar(&bodyFile, "body-file", "", "Serve this file from the server's body directory")
+	cmd.Flags().StringVar(&contentType, "content-type", "", `Content-Type of responses ("" removes it)`)
+	cmd.Flags().BoolVar(&template, "template", false, "Render the body and header values as Go templates (--template=false stops)")
+	cmd.Flags().StringVar(&script, "script", "", `CEL expression computing responses (@file reads it from a local file; "" removes it)`)
+	cmd.Flags().StringVar(&plugin, "plugin", "", `WebAssembly plugin computing responses ("" removes it)`)
+	cmd.Flags().StringVar(&chaos, "chaos", "", `Chaos settings, e.g. "abort_rate=0.2" ("" removes them)`)
+	cmd.Flags().StringVar(&proxy.Upstream, "proxy", "", `Upstream URL to record responses from ("" stops proxying)`)
+	cmd.Flags().StringVar(&proxy.Mode, "proxy-mode", "", "record forwards and saves responses; replay serves the saved ones")
+	addProxyEventFlags(cmd, &proxy)
+	cmd.Flags().StringVar(&schema, "schema", "", `Local JSON Schema file request bodies must match ("" stops validating)`)
+	cmd.Flags().StringVar(&schemaMode, "schema-mode", "", "reject answers mismatching requests with 422; flag only records the result")
+	cmd.Flags().StringArrayVarP(&headers, "header", "H", nil, `Response header as "Key: Value" (repeatable; replaces all headers)`)
+	cmd.Flags().IntVar(&status, "status", 0, "Response status code")
+	return cmd
+}
+
+// proxyFlags set the proxy settings of hooks.
+var proxyFlags = []string{"proxy", "proxy-mode", "proxy-cloudevents", "proxy-event-type", "proxy-dedupe"}
+
+// addProxyEventFlags adds the flags of the CloudEvents proxy settings.
+func addProxyEventFlags(cmd *cobra.Command, p *webhook.Proxy) {
+	cmd.Flags().StringVar(&p.CloudEvents, "proxy-cloudevents", "", `Forward requests that aren't CloudEvents wrapped in one: binary or structured ("" stops)`)
+	cmd.Flags().StringVar(&p.EventType, "proxy-event-type", "", "Type of the events requests are wrapped in (default webhookd.request)")
+	cmd.Flags().BoolVar(&p.Dedupe, "proxy-dedupe", false, "Forward each CloudEvent (by source and id) once")
+}
+
+// proxySettings merges the proxy flags, set in flags, into the proxy
+// settings of the hook at path. --proxy "" removes them.
+func proxySettings(cmd *cobra.Command, c *apiClient, path string, flags webhook.Proxy) (webhook.Proxy, error) {
+	if cmd.Flags().Changed("proxy") && flags.Upstream == "" {
+		return webhook.Proxy{}, nil
+	}
+	var h webhook.Hook
+	if err := c.do(cmd.Context(), http.MethodGet, path, nil, &h); err != nil {
+		return webhook.Proxy{}, err
+	}
+	var p webhook.Proxy
+	if h.Proxy != nil {
+		p = *h.Proxy
+	}
+	if cmd.Flags().Changed("proxy") {
+		p.Upstream = flags.Upstream
+	}
+	if cmd.Flags().Changed("proxy-mode") {
+		p.Mode = flags.Mode
+	}
+	if cmd.Flags().Changed("proxy-cloudevents") {
+		p.CloudEvents = flags.CloudEvents
+		if p.CloudEvents == "" {
+			p.EventType = ""
+		}
+	}
+	if cmd.Flags().Changed("proxy-event-type") {
+		p.EventType = flags.EventType
+	}
+	if cmd.Flags().Changed("proxy-dedupe") {
+		p.Dedupe = flags.Dedupe
+	}
+	return p, nil
+}
+
+// validationSettings merges the --schema and --schema-mode flags into the
+// request validation of the hook at path. --schema "" removes it.
+func validationSettings(cmd *cobra.Command, c *apiClient, path, schemaFile, mode string) (webhook.RequestValidation, error) {
+	if cmd.Flags().Changed("schema") && schemaFile == "" {
+		return webhook.RequestValidation{}, nil
+	}
+	var v webhook.RequestValidation
+	if cmd.Flags().Changed("schema") {
+		schema, err := readSchema(schemaFile)
+		if err != nil {
+			return v, err
+		}
+		v.Schema = schema
+	} else {
+		var h webhook.Hook
+		if err := c.do(cmd.Context(), http.MethodGet, path, nil, &h); err != nil {
+			return v, err
+		}
+		if h.Validation == nil {
+			return v, fmt.Errorf("webhook has no schema; pass --schema")
+		}
+		v = *h.Validation
+	}
+	if cmd.Flags().Changed("schema-mode") {
+		v.Mode = mode
+	}
+	return v, nil
+}
+
+// readSchema reads a local JSON Schema file.
+func readSchema(file string) (json.RawMessage, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(b) {
+		return nil, fmt.Errorf("%s: not valid JSON", file)
+	}
+	return b, nil
+}
+
+// readScript returns a script flag; "@file" reads a local file.
+func readScript(script string) (string, error) {
+	path, ok := strings.CutPrefix(script, "@")
+	if !ok {
+		return script, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// setBody sets the body of a create/update request. "@file" reads a local
+// file; content that isn't UTF-8 text is sent as body_base64.
+func setBody(in map[string]any, body string) error {
+	path, ok := strings.CutPrefix(body, "@")
+	if !ok {
+		in["body"] = body
+		return nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if utf8.Valid(b) {
+		in["body"] = string(b)
+	} else {
+		in["body_base64"] = b // encoding/json writes []byte as base64
+	}
+	return nil
+}
+
+func newHooksDeleteCmd(root *RootOptions, opts *ClientOptions) *cobra.Command {
+	var hard bool
+	cmd := &cobra.Command{
+		Use:   "delete <id>",
+		Short: "Deactivate a webhook (or delete it with --hard)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newAPIClient(root, opts)
+			if err != nil {
+				return err
+			}
+			path := "/v1/webhooks/" + url.PathEscape(args[0])
+			if hard {
+				path += "?hard=true"
+			}
+			var out map[string]any
+			if err := c.do(cmd.Context(), http.MethodDelete, path, nil, &out); err != nil {
+				return err
+			}
+			return writeMessage(cmd.OutOrStdout(), opts, out)
+		},
+	}
+	cmd.Flags().BoolVar(&hard, "hard", false, "Delete permanently instead of deactivating")
+	return cmd
+}
+
+func newHooksInvokeCmd(root *RootOptions, opts *ClientOptions) *cobra.Command {
+	var (
+		method  string
+		data    string
+		headers []string
+	)
+	cmd := &cobra.Command{
+		Use:   "invoke <id>",
+		Short: "Call a webhook and print the response",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hdrs, err := parseHeaderFlags(headers)
+			if err != nil {
+				return err
+			}
+			c, err := newAPIClient(root, opts)
+			if err != nil {
+				return err
+			}
+
+			var body io.Reader
+			if data != "" {
+				if strings.HasPrefix(data, "@") {
+					b, err := os.ReadFile(strings.TrimPrefix(data, "@"))
+					if err != nil {
+						return err
+					}
+					body = strings.NewReader(string(b))
+				} else {
+					body = strings.NewReader(data)
+				}
+			}
+			req, err := c.newRequest(cmd.Context(), strings.ToUpper(method), "/v1/hooks/"+url.PathEscape(args[0]), body)
+			if err != nil {
+				return err
+			}
+			for k, v := range hdrs {
+				req.Header.Set(k, v)
+			}
+
+			start := time.Now()
+			resp, err := c.http.Do(req)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+			respBody, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return err
+			}
+			took := time.Since(start)
+
+			w := cmd.OutOrStdout()
+			if opts.Output == "json" {
+				return writeJSON(w, map[string]any{
+					"status":      resp.StatusCode,
+					"headers":     resp.Header,
+					"body":        string(respBody),
+					"duration_ms": float64(took.Microseconds()) / 1000,
+				})
+			}
+			fmt.Fprintf(w, "%s %s (%s)\n", resp.Proto, resp.Status, took.Round(time.Microsecond))
+			writeHeaders(w, resp.Header)
+			fmt.Fprintln(w)
+			_, err = w.Write(respBody)
+			if len(respBody) > 0 && respBody[len(respBody)-1] != '\n' {
+				fmt.Fprintln(w)
+			}
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&method, "method", "X", "GET", "HTTP method")
+	cmd.Flags().StringVarP(&data, "data", "d", "", "Request body (@file reads it from a file)")
+	cmd.Flags().StringArrayVarP(&headers, "header", "H", nil, `Request header as "Key: Value" (repeatable)`)
+	return cmd
+}
+
+func newHooksStateCmd(root *RootOptions, opts *ClientOptions) *cobra.Command {
+	var reset bool
+	cmd := &cobra.Command{
+		Use:   "state <id>",
+		Short: "Show the key-value store of a webhook (or empty it with --reset)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newAPIClient(root, opts)
+			if err != nil {
+				return err
+			}
+			path := "/v1/webhooks/" + url.PathEscape(args[0]) + "/state"
+			if reset {
+				var out map[string]any
+				if err := c.do(cmd.Context(), http.MethodDelete, path, nil, &out); err != nil {
+					return err
+				}
+				return writeMessage(cmd.OutOrStdout(), opts, out)
+			}
+			var out struct {
+				State map[string]string `json:"state"`
+			}
+			if err := c.do(cmd.Context(), http.MethodGet, path, nil, &out); err != nil {
+				return err
+			}
+			if opts.Output == "json" {
+				return writeJSON(cmd.OutOrStdout(), out.State)
+			}
+			keys := make([]string, 0, len(out.State))
+			for k := range out.State {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			rows := make([][]string, len(keys))
+			for i, k := range keys {
+				rows[i] = []string{k, out.State[k]}
+			}
+			return writeTable(cmd.OutOrStdout(), []string{"KEY", "VALUE"}, rows)
+		},
+	}
+	cmd.Flags().BoolVar(&reset, "reset", false, "Remove all keys")
+	return cmd
+}
+
+func newHooksRequestsCmd(root *RootOptions, opts *ClientOptions) *cobra.Command {
+	var limit int
+	cmd := &cobra.Command{
+		Use:   "requests <id>",
+		Short: "List captured invocations of a webhook",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newAPIClient(root, opts)
+			if err != nil {
+				return err
+			}
+			path := "/v1/webhooks/" + url.PathEscape(args[0]) + "/requests"
+			if limit > 0 {
+				path += "?limit=" + strconv.Itoa(limit)
+			}
+			var out struct {
+				Requests []httpapi.CapturedRequest `json:"requests"`
+			}
+			if err := c.do(cmd.Context(), http.MethodGet, path, nil, &out); err != nil {
+				return err
+			}
+			if opts.Output == "json" {
+				return writeJSON(cmd.OutOrStdout(), out.Requests)
+			}
+			rows := make([][]string, len(out.Requests))
+			for i, r := range out.Requests {
+				size := strconv.Itoa(len(r.Body) + len(r.BodyBase64))
+				if r.BodyTruncated {
+					size += "+"
+				}
+				rows[i] = []string{
+					r.Received.Local().Format(time.DateTime),
+					r.Method,
+					strconv.Itoa(r.Status),
+					r.RemoteAddr,
+					size,
+					r.ID,
+				}
+			}
+			return writeTable(cmd.OutOrStdout(), []string{"RECEIVED", "METHOD", "STATUS", "FROM", "BYTES", "ID"}, rows)
+		},
+	}
+	cmd.Flags().IntVarP(&limit, "limit", "n", 0, "Only the most recent N requests")
+	return cmd
+}
+
+// parseHeaderFlags accepts "Key: Value" (curl style) and "Key=Value".
+func parseHeaderFlags(in []string) (map[string]string, error) {
+	out := make(map[string]string, len(in))
+	for _, h := range in {
+		k, v, ok := strings.Cut(h, ":")
+		if !ok {
+			k, v, ok = strings.Cut(h, "=")
+		}
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid header %q (expected \"Key: Value\")", h)
+		}
+		out[k] = strings.TrimSpace(v)
+	}
+	return out, nil
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func writeTable(w io.Writer, header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, r := range rows {
+		fmt.Fprintln(tw, strings.Join(r, "\t"))
+	}
+	return tw.Flush()
+}
+
+func writeHooksTable(w io.Writer, hooks []*webhook.Hook) error {
+	rows := make([][]string, len(hooks))
+	for i, h := range hooks {
+		lastCall := "-"
+		if h.Counter > 0 {
+			lastCall = h.LastCall.Local().Format(time.DateTime)
+		}
+		rows[i] = []string{
+			string(h.ID),
+			hookMethods(h),
+			strconv.FormatBool(h.Active),
+			strconv.FormatInt(h.Counter, 10),
+			lastCall,
+			h.Created.Local().Format(time.DateTime),
+		}
+	}
+	return writeTable(w, []string{"ID", "METHOD", "ACTIVE", "CALLS", "LAST CALL", "CREATED"}, rows)
+}
+
+// hookMethods is the METHOD column: the accepted methods, or ANY.
+func hookMethods(h *webhook.Hook) string {
+	if _, any := h.Responses[webhook.MethodAny]; any || h.Method == webhook.MethodAny {
+		return webhook.MethodAny
+	}
+	return strings.Join(h.Allow(), ",")
+}
+
+func writeHeaders(w io.Writer, h http.Header) {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range h[k] {
+			fmt.Fprintf(w, "%s: %s\n", k, v)
+		}
+	}
+}
+
+func writeMessage(w io.Writer, opts *ClientOptions, out map[string]any) error {
+	delete(out, "$schema")
+	if opts.Output == "json" {
+		return writeJSON(w, out)
+	}
+	_, err := fmt.Fprintf(w, "%v %v\n", out["id"], out["message"])
+	return err
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"webhookd/internal/infrastructure/openapiimport"
+	"webhookd/internal/transport/httpapi"
+)
+
+func newHooksImportCmd(root *RootOptions, opts *ClientOptions) *cobra.Command {
+	var (
+		prefix  string
+		replace bool
+		dryRun  bool
+	)
+	cmd := &cobra.Command{
+		Use:   "import <openapi-file>",
+		Short: "Create mock webhooks from an OpenAPI 3.x document (- reads stdin)",
+		Long: "Creates a webhook for each path of an OpenAPI 3.x document (JSON or YAML), below --prefix,\n" +
+			"answering its operations with their example responses, or ones generated from their schemas.",
+		Example: "  webhookd hooks import partner.yaml --prefix partner\n" +
+			"  webhookd hooks import partner.yaml --prefix partner --replace --dry-run",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				doc []byte
+				err error
+			)
+			if args[0] == "-" {
+				doc, err = io.ReadAll(cmd.InOrStdin())
+			} else {
+				doc, err = os.ReadFile(args[0])
+			}
+			if err != nil {
+				return err
+			}
+			c, err := newAPIClient(root, opts)
+			if err != nil {
+				return err
+			}
+
+			q := url.Values{}
+			if prefix != "" {
+				q.Set("prefix", prefix)
+			}
+			if replace {
+				q.Set("replace", "true")
+			}
+			if dryRun {
+				q.Set("dry_run", "true")
+			}
+			path := "/v1/imports/openapi"
+			if len(q) > 0 {
+				path += "?" + q.Encode()
+			}
+			var out struct {
+				Title   string                  `json:"title,omitempty"`
+				Prefix  string                  `json:"prefix"`
+				DryRun  bool                    `json:"dry_run,omitempty"`
+				Hooks   []httpapi.ImportedHook  `json:"hooks"`
+				Skipped []openapiimport.Skipped `json:"skipped,omitempty"`
+			}
+			if err := c.send(cmd.Context(), http.MethodPost, path, "application/yaml", bytes.NewReader(doc), &out); err != nil {
+				return err
+			}
+			if opts.Output == "json" {
+				return writeJSON(cmd.OutOrStdout(), out)
+			}
+
+			w := cmd.OutOrStdout()
+			rows := make([][]string, len(out.Hooks))
+			failed := 0
+			for i, h := range out.Hooks {
+				status := h.Status
+				if h.Error != "" {
+					status += ": " + h.Error
+					failed++
+				}
+				rows[i] = []string{h.ID, strings.Join(h.Methods, ","), h.Path, status}
+			}
+			if err := writeTable(w, []string{"ID", "METHOD", "PATH", "STATUS"}, rows); err != nil {
+				return err
+			}
+			for _, s := range out.Skipped {
+				fmt.Fprintf(w, "skipped %s %s: %s\n", s.Method, s.Path, s.Reason)
+			}
+			if out.DryRun {
+				fmt.Fprintln(w, "dry run: nothing changed")
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d webhooks failed", failed, len(out.Hooks))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&prefix, "prefix", "", "Id prefix of the webhooks (default: derived from the document title)")
+	cmd.Flags().BoolVar(&replace, "replace", false, "Replace the responses of webhooks whose id is taken")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be imported without changing anything")
+	return cmd
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"webhookd/internal/domain/plugin"
+)
+
+func newPluginsCmd(root *RootOptions) *cobra.Command {
+	opts := &ClientOptions{Output: "table"}
+
+	cmd := &cobra.Command{
+		Use:   "plugins",
+		Short: "Manage the WebAssembly plugins of a running server",
+	}
+	addClientFlags(cmd.PersistentFlags(), opts)
+	cmd.PersistentFlags().DurationVar(&opts.Timeout, "timeout", 30*time.Second, "Request timeout")
+
+	cmd.AddCommand(
+		newPluginsUploadCmd(root, opts),
+		newPluginsListCmd(root, opts),
+		newPluginsDeleteCmd(root, opts),
+	)
+	return cmd
+}
+
+func newPluginsUploadCmd(root *RootOptions, opts *ClientOptions) *cobra.Command {
+	var name string
+	cmd := &cobra.Command{
+		Use:   "upload <file.wasm>",
+		Short: "Upload a WASI module as a plugin, replacing the plugin of that name (- reads stdin)",
+		Example: "  GOOS=wasip1 GOARCH=wasm go build -o pricing.wasm ./pricing\n" +
+			"  webhookd plugins upload pricing.wasm\n" +
+			"  webhookd hooks create --id quote -X POST --plugin pricing",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				module []byte
+				err    error
+			)
+			if args[0] == "-" {
+				module, err = io.ReadAll(cmd.InOrStdin())
+			} else {
+				module, err = os.ReadFile(args[0])
+			}
+			if err != nil {
+				return err
+			}
+			if name == "" {
+				if args[0] == "-" {
+					return fmt.Errorf("--name is required when reading stdin")
+				}
+				name = strings.TrimSuffix(filepath.Base(args[0]), ".wasm")
+			}
+			c, err := newAPIClient(root, opts)
+			if err != nil {
+				return err
+			}
+			var p plugin.Plugin
+			path := "/v1/plugins/" + url.PathEscape(name)
+			if err := c.send(cmd.Context(), http.MethodPut, path, "application/wasm", bytes.NewReader(module), &p); err != nil {
+				return err
+			}
+			if opts.Output == "json" {
+				return writeJSON(cmd.OutOrStdout(), p)
+			}
+			return writePluginsTable(cmd.OutOrStdout(), []plugin.Plugin{p})
+		},
+	}
+	cmd.Flags().StringVar(&name, "name", "", "Plugin name (default: the file name without .wasm)")
+	return cmd
+}
+
+func newPluginsListCmd(root *RootOptions, opts *ClientOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List plugins",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := newAPIClient(root, opts)
+			if err != nil {
+				return err
+			}
+			var out struct {
+				Plugins []plugin.Plugin `json:"plugins"`
+			}
+			if err := c.do(cmd.Context(), http.MethodGet, "/v1/plugins", nil, &out); err != nil {
+				return err
+			}
+			if opts.Output == "json" {
+				return writeJSON(cmd.OutOrStdout(), out.Plugins)
+			}
+			return writePluginsTable(cmd.OutOrStdout(), out.Plugins)
+		},
+	}
+}
+
+func newPluginsDeleteCmd(root *RootOptions, opts *ClientOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <name>",
+		Short: "Delete a plugin no webhook uses",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newAPIClient(root, opts)
+			if err != nil {
+				return err
+			}
+			var out map[string]any
+			if err := c.do(cmd.Context(), http.MethodDelete, "/v1/plugins/"+url.PathEscape(args[0]), nil, &out); err != nil {
+				return err
+			}
+			delete(out, "$schema")
+			if opts.Output == "json" {
+				return writeJSON(cmd.OutOrStdout(), out)
+			}
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "%v %v\n", out["name"], out["message"])
+			return err
+		},
+	}
+}
+
+func writePluginsTable(w io.Writer, plugins []plugin.Plugin) error {
+	rows := make([][]string, len(plugins))
+	for i, p := range plugins {
+		rows[i] = []string{p.Name, strconv.Itoa(p.Size), p.SHA256[:min(12, len(p.SHA256))], p.UploadedAt.Format(time.RFC3339)}
+	}
+	return writeTable(w, []string{"NAME", "SIZE", "SHA256", "UPLOADED"}, rows)
+}
@@ -1,28 +1,43 @@
 package cli
 
 import (
-	"context"
-	"fmt"
+	"net"
+	"strconv"
 
+	"github.com/joho/godotenv"
+	"github.com/logrusorgru/aurora"
 	"github.com/spf13/cobra"
 
 	"webhookd/internal/buildinfo"
+	"webhookd/internal/infrastructure/configfile"
 	"webhookd/internal/transport/runtime"
 )
 
+const (
+	banner = `
+              ___.   .__                   __       .___
+__  _  __ ____\_ |__ |  |__   ____   ____ |  | __ __| _/
+\ \/ \/ // __ \| __ \|  |  \ /  _ \ /  _ \|  |/ // __ |
+ \     /\  ___/| \_\ \   Y  (  <_> |  <_> )    </ /_/ |
+  \/\_/  \___  >___  /___|  /\____/ \____/|__|_ \____ |
+             \/    \/     \/                   \/    \/`
+)
+
 type RootOptions struct {
-	Host    string
-	Port    int
-	Config  string
-	Debug   bool
-	Verbose bool
+	Host      string
+	Port      int
+	Config    string
+	Debug     bool
+	Verbose   bool
+	LogFormat string
+	LogLevel  string
 }
 
 func NewRoot() *cobra.Command {
 	opts := &RootOptions{
-		Host:   "0.0.0.0",
-		Port:   1337,
-		Config: "webhookd.json",
+		Host:      "0.0.0.0",
+		Port:      1337,
+		LogFormat: "text",
 	}
 
 	cmd := &cobra.Command{
@@ -30,9 +45,13 @@ func NewRoot() *cobra.Command {
 		Short:   "Self-hosted webhook service",
 		Long:    "webhookd is a small daemon that lets you generate and serve simple webhooks.",
 		Version: buildinfo.Version,
+		PersistentPreRun: func(*cobra.Command, []string) {
+			// Optional .env for local dev; real environment variables win.
+			_ = godotenv.Load()
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Default behavior: serve
-			return runServe(cmd.Context(), opts)
+			return runServe(cmd, opts)
 		},
 	}
 
@@ -40,11 +59,17 @@ func NewRoot() *cobra.Command {
 
 	cmd.PersistentFlags().StringVar(&opts.Host, "host", opts.Host, "Host to bind to")
 	cmd.PersistentFlags().IntVar(&opts.Port, "port", opts.Port, "Port to listen on")
-	cmd.PersistentFlags().StringVar(&opts.Config, "config", opts.Config, "Path to config file")
+	cmd.PersistentFlags().StringVar(&opts.Config, "config", "", "Path to config file: .json, .yaml/.yml or .toml (default: webhookd.{json,yaml,yml,toml} in the working directory)")
 	cmd.PersistentFlags().BoolVar(&opts.Debug, "debug", false, "Enable debug output")
 	cmd.PersistentFlags().BoolVar(&opts.Verbose, "verbose", false, "Enable verbose logging")
+	cmd.PersistentFlags().StringVar(&opts.LogFormat, "log-format", opts.LogFormat, "Log format: text or json")
+	cmd.PersistentFlags().StringVar(&opts.LogLevel, "log-level", "info", "Log level: debug, info, warn or error")
 
 	cmd.AddCommand(newServeCmd(opts))
+	cmd.AddCommand(newHooksCmd(opts))
+	cmd.AddCommand(newPluginsCmd(opts))
+	cmd.AddCommand(newTailCmd(opts))
+	cmd.AddCommand(newConfigCmd(opts))
 
 	return cmd
 }
@@ -54,18 +79,88 @@ func newServeCmd(opts *RootOptions) *cobra.Command {
 		Use:   "serve",
 		Short: "Start the HTTP server",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runServe(cmd.Context(), opts)
+			return runServe(cmd, opts)
 		},
 	}
 }
 
-func runServe(ctx context.Context, opts *RootOptions) error {
-	addr := fmt.Sprintf("%s:%d", opts.Host, opts.Port)
-	return runtime.Run(ctx, runtime.Options{
-		Addr:       addr,
+func runServe(cmd *cobra.Command, opts *RootOptions) error {
+	return runtime.Run(cmd.Context(), runtime.Options{
 		ConfigPath: opts.Config,
-		Debug:      opts.Debug,
-		Verbose:    opts.Verbose,
+		Overrides:  flagOverrides(cmd, opts),
 		Version:    buildinfo.Version,
+		// The banner would corrupt structured log streams; Run skips it for JSON logs.
+		Banner: aurora.Magenta(banner).String(),
 	})
 }
+
+// flagOverrides turns the flags the user actually set into config overrides;
+// unset flags leave the config file and environment alone.
+func flagOverrides(cmd *cobra.Command, opts *RootOptions) []configfile.Override {
+	changed := cmd.Flags().Changed
+	var out []configfile.Override
+
+	if changed("host") || changed("port") {
+		out = append(out, configfile.Override{
+			Flag: hostPortFlag(changed("host"), changed("port")),
+			Key:  "server.addr",
+			Apply: func(c *configfile.Config) error {
+				host, port, err := net.SplitHostPort(c.Server.Addr)
+				if err != nil {
+					host, port = "0.0.0.0", "1337"
+				}
+				if changed("host") {
+					host = opts.Host
+				}
+				if changed("port") {
+					port = strconv.Itoa(opts.Port)
+				}
+				c.Server.Addr = net.JoinHostPort(host, port)
+				return nil
+			},
+		})
+	}
+	if changed("log-level") {
+		out = append(out, configfile.Override{Flag: "--log-level", Key: "log.level", Apply: func(c *configfile.Config) error {
+			c.Log.Level = opts.LogLevel
+			return nil
+		}})
+	}
+	// --verbose and --debug both enable debug logs; --debug also adds source locations.
+	if changed("verbose") && opts.Verbose {
+		out = append(out, configfile.Override{Flag: "--verbose", Key: "log.level", Apply: func(c *configfile.Config) error {
+			c.Log.Level = "debug"
+			return nil
+		}})
+	}
+	if changed("debug") && opts.Debug {
+		out = append(out,
+			configfile.Override{Flag: "--debug", Key: "log.level", Apply: func(c *configfile.Config) error {
+				c.Log.Level = "debug"
+				return nil
+			}},
+			configfile.Override{Flag: "--debug", Key: "log.add_source", Apply: func(c *configfile.Config) error {
+				c.Log.AddSource = true
+				return nil
+			}},
+		)
+	}
+	if changed("log-format") {
+		out = append(out, configfile.Override{Flag: "--log-format", Key: "log.format", Apply: func(c *configfile.Config) error {
+			c.Log.Format = opts.LogFormat
+			return nil
+		}})
+	}
+	return out
+}
+
+func hostPortFlag(host, port bool) string {
+	switch {
+	case host && port:
+		return "--host/--port"
+	case host:
+		return "--host"
+	default:
+		return "--port"
+	}
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"webhookd/internal/infrastructure/configfile"
+)
+
+func TestHostPortFlagsMergeIntoAddr(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "webhookd.yaml")
+	if err := os.WriteFile(path, []byte("server:\n  addr: 127.0.0.1:9000\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		args       []string
+		env        map[string]string
+		wantAddr   string
+		wantSource string
+	}{
+		{name: "no flags", wantAddr: "127.0.0.1:9000", wantSource: configfile.SourceFile},
+		{name: "port keeps host", args: []string{"--port", "8080"}, wantAddr: "127.0.0.1:8080", wantSource: "flag:--port"},
+		{name: "host keeps port", args: []string{"--host", "10.0.0.1"}, wantAddr: "10.0.0.1:9000", wantSource: "flag:--host"},
+		{name: "both", args: []string{"--host", "::1", "--port", "1"}, wantAddr: "[::1]:1", wantSource: "flag:--host/--port"},
+		{
+			name: "port keeps host from env", args: []string{"--port", "8080"},
+			env:      map[string]string{"WEBHOOKD_ADDR": "192.0.2.1:7000"},
+			wantAddr: "192.0.2.1:8080", wantSource: "flag:--port",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := NewRoot()
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatalf("ParseFlags: %v", err)
+			}
+			opts := &RootOptions{}
+			opts.Host, _ = cmd.Flags().GetString("host")
+			opts.Port, _ = cmd.Flags().GetInt("port")
+
+			loaded, err := configfile.Load(configfile.LoadOptions{
+				Path:      path,
+				EnvPrefix: configfile.EnvPrefix,
+				LookupEnv: func(name string) (string, bool) {
+					v, ok := tt.env[name]
+					return v, ok
+				},
+				Overrides: flagOverrides(cmd, opts),
+			})
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if got := loaded.Config.Server.Addr; got != tt.wantAddr {
+				t.Errorf("server.addr = %q, want %q", got, tt.wantAddr)
+			}
+			if got := loaded.Sources["server.addr"]; got != tt.wantSource {
+				t.Errorf("source of server.addr = %q, want %q", got, tt.wantSource)
+			}
+		})
+	}
+}
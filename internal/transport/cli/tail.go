@@ -0,0 +1,342 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/spf13/cobra"
+
+	"webhookd/internal/transport/httpapi"
+)
+
+// maxEventSize bounds a single server-sent event (one captured request).
+const maxEventSize = 16 << 20
+
+type tailOptions struct {
+	Methods     []string
+	Headers     []string
+	EventTypes  []string
+	Sources     []string
+	SaveDir     string
+	NoColor     bool
+	NoReconnect bool
+}
+
+func newTailCmd(root *RootOptions) *cobra.Command {
+	copts := &ClientOptions{Output: "table"}
+	topts := &tailOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "tail <id>",
+		Short: "Stream invocations of a webhook as they arrive",
+		Long: `Connects to the server's live event stream for a webhook and prints every
+request it receives: method, path, headers, body (pretty-printed when it is
+JSON) and timing, plus the attributes of CloudEvents. With -o json each
+request is printed as one JSON line.`,
+		Example: `  webhookd tail <id>
+  webhookd tail <id> --method POST --header 'X-GitHub-Event: push'
+  webhookd tail <id> --ce-type com.example.order.created
+  webhookd tail <id> --save ./captured`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTail(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), root, copts, topts, args[0])
+		},
+	}
+	addClientFlags(cmd.Flags(), copts)
+	cmd.Flags().StringArrayVarP(&topts.Methods, "method", "X", nil, "Only show requests with this method (repeatable)")
+	cmd.Flags().StringArrayVarP(&topts.Headers, "header", "H", nil, `Only show requests carrying this header; "Key" or "Key: Value" (repeatable, all must match)`)
+	cmd.Flags().StringArrayVar(&topts.EventTypes, "ce-type", nil, "Only show CloudEvents of this type (repeatable)")
+	cmd.Flags().StringArrayVar(&topts.Sources, "ce-source", nil, "Only show CloudEvents from this source (repeatable)")
+	cmd.Flags().StringVar(&topts.SaveDir, "save", "", "Also write each shown request as JSON to a file in this directory")
+	cmd.Flags().BoolVar(&topts.NoColor, "no-color", false, "Disable colored output")
+	cmd.Flags().BoolVar(&topts.NoReconnect, "no-reconnect", false, "Exit when the stream drops instead of reconnecting")
+	return cmd
+}
+
+func runTail(ctx context.Context, stdout, stderr io.Writer, root *RootOptions, copts *ClientOptions, topts *tailOptions, id string) error {
+	filter, err := newTailFilter(topts)
+	if err != nil {
+		return err
+	}
+	c, err := newAPIClient(root, copts)
+	if err != nil {
+		return err
+	}
+	// The stream stays open indefinitely.
+	c.http = &http.Client{}
+
+	if topts.SaveDir != "" {
+		if err := os.MkdirAll(topts.SaveDir, 0o755); err != nil {
+			return err
+		}
+	}
+	au := aurora.NewAurora(!topts.NoColor && copts.Output != "json" && isTerminal(stdout))
+
+	handle := func(r httpapi.CapturedRequest) error {
+		if !filter(r) {
+			return nil
+		}
+		if topts.SaveDir != "" {
+			if err := saveCapturedRequest(topts.SaveDir, r); err != nil {
+				return err
+			}
+		}
+		if copts.Output == "json" {
+			return json.NewEncoder(stdout).Encode(r)
+		}
+		printCapturedRequest(stdout, au, r)
+		return nil
+	}
+
+	backoff := time.Second
+	everConnected := false
+	for {
+		connected, err := streamEvents(ctx, c, id, stderr, handle)
+		var apiErr *apiError
+		switch {
+		case errors.Is(err, errHookDeleted):
+			fmt.Fprintln(stderr, "webhook was deleted")
+			return nil
+		case ctx.Err() != nil:
+			return nil
+		case errors.As(err, &apiErr), topts.NoReconnect, !connected && !everConnected:
+			// Only reconnect a stream that worked before; a wrong URL,
+			// token or id should fail right away.
+			return err
+		}
+		if connected {
+			everConnected = true
+			backoff = time.Second
+		}
+		fmt.Fprintf(stderr, "stream interrupted (%v); reconnecting in %s\n", err, backoff)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+var errHookDeleted = errors.New("hook deleted")
+
+// streamEvents reads the server-sent event stream until it ends. connected
+// reports whether the stream was established at all.
+func streamEvents(ctx context.Context, c *apiClient, id string, stderr io.Writer, handle func(httpapi.CapturedRequest) error) (connected bool, err error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/v1/webhooks/"+url.PathEscape(id)+"/events", nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return false, decodeAPIError(resp)
+	}
+	fmt.Fprintf(stderr, "tailing %s (Ctrl-C to stop)\n", id)
+
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 0, 64<<10), maxEventSize)
+	var event string
+	var data bytes.Buffer
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "":
+			// Blank line: dispatch the event.
+			if err := dispatchEvent(event, data.Bytes(), handle); err != nil {
+				return true, err
+			}
+			event = ""
+			data.Reset()
+		case strings.HasPrefix(line, ":"):
+			// Comment (keep-alive).
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return true, err
+	}
+	return true, io.ErrUnexpectedEOF
+}
+
+func dispatchEvent(event string, data []byte, handle func(httpapi.CapturedRequest) error) error {
+	switch event {
+	case "request":
+		var r httpapi.CapturedRequest
+		if err := json.Unmarshal(data, &r); err != nil {
+			return fmt.Errorf("decode event: %w", err)
+		}
+		return handle(r)
+	case "deleted":
+		return errHookDeleted
+	}
+	return nil
+}
+
+func newTailFilter(o *tailOptions) (func(httpapi.CapturedRequest) bool, error) {
+	type headerMatch struct {
+		key, value string
+		anyValue   bool
+	}
+	var headers []headerMatch
+	for _, h := range o.Headers {
+		k, v, ok := strings.Cut(h, ":")
+		k = strings.TrimSpace(k)
+		if k == "" {
+			return nil, fmt.Errorf("invalid header filter %q", h)
+		}
+		headers = append(headers, headerMatch{key: k, value: strings.TrimSpace(v), anyValue: !ok})
+	}
+
+	return func(r httpapi.CapturedRequest) bool {
+		if len(o.Methods) > 0 {
+			found := false
+			for _, m := range o.Methods {
+				if strings.EqualFold(m, r.Method) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		for _, want := range headers {
+			if !headerMatches(r.Headers, want.key, want.value, want.anyValue) {
+				return false
+			}
+		}
+		if len(o.EventTypes) > 0 && (r.CloudEvent == nil || !slices.Contains(o.EventTypes, r.CloudEvent.Type)) {
+			return false
+		}
+		if len(o.Sources) > 0 && (r.CloudEvent == nil || !slices.Contains(o.Sources, r.CloudEvent.Source)) {
+			return false
+		}
+		return true
+	}, nil
+}
+
+func headerMatches(h map[string][]string, key, value string, anyValue bool) bool {
+	for k, vs := range h {
+		if !strings.EqualFold(k, key) {
+			continue
+		}
+		if anyValue {
+			return true
+		}
+		for _, v := range vs {
+			if v == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func printCapturedRequest(w io.Writer, au aurora.Aurora, r httpapi.CapturedRequest) {
+	target := r.Path
+	if r.Query != "" {
+		target += "?" + r.Query
+	}
+	fmt.Fprintf(w, "%s %s %s  %s  %s  %s\n",
+		au.Gray(12, r.Received.Local().Format("15:04:05.000")),
+		au.Bold(au.Cyan(r.Method)),
+		target,
+		statusColor(au, r.Status),
+		au.Gray(12, fmt.Sprintf("%.2fms", r.DurationMS)),
+		au.Gray(12, "from "+r.RemoteAddr),
+	)
+
+	if e := r.CloudEvent; e != nil {
+		fmt.Fprintf(w, "  %s %s from %s, id %s\n", au.Magenta("cloudevent:"), au.Bold(e.Type), e.Source, e.ID)
+	}
+
+	keys := make([]string, 0, len(r.Headers))
+	for k := range r.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range r.Headers[k] {
+			fmt.Fprintf(w, "  %s: %s\n", au.Yellow(k), v)
+		}
+	}
+
+	switch {
+	case len(r.BodyBase64) > 0:
+		fmt.Fprintf(w, "\n  %s\n", au.Gray(12, fmt.Sprintf("<%d bytes of binary data>", len(r.BodyBase64))))
+	case r.Body != "":
+		body := r.Body
+		var pretty bytes.Buffer
+		if json.Valid([]byte(body)) && json.Indent(&pretty, []byte(body), "  ", "  ") == nil {
+			body = pretty.String()
+		}
+		fmt.Fprintf(w, "\n  %s\n", body)
+	}
+	if v := r.Validation; v != nil && !v.Valid {
+		fmt.Fprintf(w, "\n  %s\n", au.Red("schema mismatch:"))
+		for _, e := range v.Errors {
+			loc := e.Location
+			if loc == "" {
+				loc = "/"
+			}
+			fmt.Fprintf(w, "  %s %s\n", au.Yellow(loc), e.Message)
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+func statusColor(au aurora.Aurora, status int) aurora.Value {
+	s := fmt.Sprintf("%d", status)
+	switch {
+	case status >= 500:
+		return au.Red(s)
+	case status >= 400:
+		return au.Yellow(s)
+	default:
+		return au.Green(s)
+	}
+}
+
+// saveCapturedRequest writes r to <dir>/<received>-<id>.json.
+func saveCapturedRequest(dir string, r httpapi.CapturedRequest) error {
+	name := r.Received.UTC().Format("20060102T150405.000000000Z") + "-" + r.ID + ".json"
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, name), append(b, '\n'), 0o644)
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
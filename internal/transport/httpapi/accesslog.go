@@ -0,0 +1,54 @@
+package httpapi
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// hookIDLocal is the fiber.Ctx local the invoke handler sets so the access log
+// can report which hook served the request.
+const hookIDLocal = "webhookd.hook_id"
+
+// accessLogMiddleware must run inside otelfiber so UserContext carries the
+// request span (the logger adds trace_id/span_id from it).
+func accessLogMiddleware(logger *slog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			if fe, ok := err.(*fiber.Error); ok {
+				status = fe.Code
+			}
+		}
+
+		level := slog.LevelInfo
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		// Copied out of Fiber's buffers: log processors (OTel batching) keep
+		// records after the request is done.
+		attrs := []slog.Attr{
+			slog.String("method", strings.Clone(c.Method())),
+			slog.String("route", strings.Clone(c.Route().Path)),
+			slog.String("path", strings.Clone(c.Path())),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("remote_addr", strings.Clone(c.IP())),
+		}
+		if id, ok := c.Locals(hookIDLocal).(string); ok && id != "" {
+			attrs = append(attrs, slog.String("hook_id", id))
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		logger.LogAttrs(c.UserContext(), level, "request", attrs...)
+		return err
+	}
+}
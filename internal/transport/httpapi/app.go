@@ -1,41 +1,90 @@
 package httpapi
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/danielgtaylor/huma/v2/adapters/humafiber"
 	"github.com/gofiber/contrib/otelfiber/v2"
 	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
+	"webhookd/internal/application/health"
 	"webhookd/internal/application/webhooks"
 	"webhookd/internal/domain/webhook"
 	"webhookd/internal/infrastructure/auth/jwtmiddleware"
 	"webhookd/internal/infrastructure/configfile"
+	"webhookd/internal/observability"
 )
 
 type Deps struct {
 	Version  string
 	Config   configfile.Config
 	Webhooks *webhooks.Service
+
+	// Metrics is optional; nil disables Prometheus instrumentation and /metrics.
+	Metrics *observability.Metrics
+	// Instruments is optional; nil disables OTel invocation metrics.
+	Instruments *observability.Instruments
+	// Logger receives access logs; nil uses slog.Default().
+	Logger *slog.Logger
+	// Health backs /readyz; nil reports ready with no checks.
+	Health *health.Registry
+	// Auth is optional; pass one to keep a handle for config reloads
+	// (Update). Nil builds it from Config.
+	Auth *jwtmiddleware.Middleware
+}
+
+func (d Deps) logger() *slog.Logger {
+	if d.Logger != nil {
+		return d.Logger
+	}
+	return slog.Default()
 }
 
 type fiberCtxKey struct{}
 
 func NewApp(d Deps) (*fiber.App, error) {
+	bodies, err := openBodyDir(d.Config.Server.BodyDir)
+	if err != nil {
+		return nil, fmt.Errorf("server.body_dir: %w", err)
+	}
+
 	app := fiber.New(fiber.Config{
 		DisableStartupMessage: true,
 	})
+	// Room for plugin uploads and imports, only on their routes.
+	limitBodies(app)
+	upstreams := newProxyUpstreams(d.Config.Server.Upstreams())
+	if bodies != nil {
+		app.Hooks().OnShutdown(bodies.Close)
+	}
 
 	// Request spans are no-ops unless a TracerProvider is configured (see internal/observability).
 	app.Use(otelfiber.Middleware())
 
-	// Non-API health check.
-	app.Get("/healthz", func(c *fiber.Ctx) error {
-		return c.SendStatus(fiber.StatusOK)
-	})
+	app.Use(accessLogMiddleware(d.logger()))
+
+	if d.Metrics != nil {
+		app.Use(metricsMiddleware(d.Metrics))
+		// With metrics.addr set, /metrics lives on the admin listener instead (see NewAdminApp).
+		if d.Config.Metrics.Addr == "" {
+			mountMetrics(app, d.Metrics, d.Config.Metrics.Path)
+		}
+	}
+
+	// Non-API health checks.
+	mountHealth(app, d.Health)
 
 	api := humafiber.New(app, huma.DefaultConfig("webhookd", d.Version))
 
@@ -46,13 +95,19 @@ func NewApp(d Deps) (*fiber.App, error) {
 		next(huma.WithValue(hctx, fiberCtxKey{}, fc))
 	})
 
-	auth := jwtmiddleware.New(jwtmiddleware.Config{
-		EnableAuthOnOptions: d.Config.EnableAuthOnOptions,
-		TokenExtractors:     d.Config.TokenExtractors,
-		JWKSURL:             d.Config.OAuthJsonWebKeySetsURL,
-		Issuer:              d.Config.OAuthIssuer,
-		Audience:            d.Config.OAuthAudience,
-	}).Huma(api)
+	authMW := d.Auth
+	if authMW == nil {
+		authMW = jwtmiddleware.New(AuthConfig(d.Config, d.Metrics))
+	}
+	if d.Health != nil {
+		// Passes while auth is off; registered regardless since a reload can enable it.
+		d.Health.Register("jwks", authMW.Ping)
+	}
+	auth := authMW.Huma(api)
+	// Management operations, the audit log included, require a token once
+	// auth is configured, so the audit log knows who did what.
+	mgmt := huma.NewGroup(api)
+	mgmt.UseMiddleware(authMW.IfConfigured(api))
 
 	// Debug: list known routes + hooks.
 	huma.Get(api, "/v1/debug/routes", func(ctx context.Context, _ *struct{}) (*struct {
@@ -64,6 +119,7 @@ func NewApp(d Deps) (*fiber.App, error) {
 			Hooks map[string]struct {
 				ID      string            `json:"id"`
 				Method  string            `json:"method"`
+				Methods []string          `json:"methods"`
 				Active  bool              `json:"active"`
 				Counter int64             `json:"counter"`
 				Headers map[string]string `json:"headers"`
@@ -79,6 +135,7 @@ func NewApp(d Deps) (*fiber.App, error) {
 				Hooks map[string]struct {
 					ID      string            `json:"id"`
 					Method  string            `json:"method"`
+					Methods []string          `json:"methods"`
 					Active  bool              `json:"active"`
 					Counter int64             `json:"counter"`
 					Headers map[string]string `json:"headers"`
@@ -91,7 +148,21 @@ func NewApp(d Deps) (*fiber.App, error) {
 			Path   string `json:"path"`
 		}{
 			{Method: http.MethodPost, Path: "/v1/webhooks"},
+			{Method: http.MethodGet, Path: "/v1/webhooks"},
+			{Method: http.MethodGet, Path: "/v1/webhooks/{id}"},
+			{Method: http.MethodGet, Path: "/v1/webhooks/{id}/requests"},
+			{Method: http.MethodGet, Path: "/v1/webhooks/{id}/events"},
+			{Method: http.MethodGet, Path: "/v1/webhooks/{id}/state"},
+			{Method: http.MethodDelete, Path: "/v1/webhooks/{id}/state"},
+			{Method: http.MethodPatch, Path: "/v1/webhooks/{id}"},
 			{Method: http.MethodDelete, Path: "/v1/webhooks/{id}"},
+			{Method: http.MethodGet, Path: "/v1/audit"},
+			{Method: http.MethodGet, Path: "/v1/audit/export"},
+			{Method: http.MethodPost, Path: "/v1/imports/openapi"},
+			{Method: http.MethodGet, Path: "/v1/plugins"},
+			{Method: http.MethodPut, Path: "/v1/plugins/{name}"},
+			{Method: http.MethodGet, Path: "/v1/plugins/{name}"},
+			{Method: http.MethodDelete, Path: "/v1/plugins/{name}"},
 			{Method: http.MethodGet, Path: "/v1/hooks/{id}"},
 			{Method: http.MethodPost, Path: "/v1/hooks/{id}"},
 			{Method: http.MethodPut, Path: "/v1/hooks/{id}"},
@@ -109,6 +180,7 @@ func NewApp(d Deps) (*fiber.App, error) {
 		resp.Body.Hooks = make(map[string]struct {
 			ID      string            `json:"id"`
 			Method  string            `json:"method"`
+			Methods []string          `json:"methods"`
 			Active  bool              `json:"active"`
 			Counter int64             `json:"counter"`
 			Headers map[string]string `json:"headers"`
@@ -117,12 +189,14 @@ func NewApp(d Deps) (*fiber.App, error) {
 			resp.Body.Hooks[string(id)] = struct {
 				ID      string            `json:"id"`
 				Method  string            `json:"method"`
+				Methods []string          `json:"methods"`
 				Active  bool              `json:"active"`
 				Counter int64             `json:"counter"`
 				Headers map[string]string `json:"headers"`
 			}{
 				ID:      string(h.ID),
 				Method:  h.Method,
+				Methods: h.Allow(),
 				Active:  h.Active,
 				Counter: h.Counter,
 				Headers: h.Headers,
@@ -151,11 +225,25 @@ func NewApp(d Deps) (*fiber.App, error) {
 	})
 
 	// Webhook management: create
-	huma.Post(api, "/v1/webhooks", func(ctx context.Context, input *struct {
+	huma.Post(mgmt, "/v1/webhooks", func(ctx context.Context, input *struct {
 		Body struct {
+			ID      string            `json:"id,omitempty" maxLength:"200" pattern:"^[A-Za-z0-9][A-Za-z0-9._~-]*(/[A-Za-z0-9][A-Za-z0-9._~-]*)*$" doc:"Custom id (slug); '/' makes a multi-segment path. A UUID is generated when omitted" example:"github/org-events"`
 			Method  string            `json:"method" doc:"HTTP method for invoking the webhook" example:"GET"`
-			Body    string            `json:"body" doc:"JSON string body returned by the webhook" example:"hello"`
+			Body    string            `json:"body" required:"false" doc:"Body returned verbatim by the webhook" example:"hello"`
 			Headers map[string]string `json:"headers" doc:"Headers to include in webhook responses"`
+			Status  int               `json:"status,omitempty" minimum:"100" maximum:"599" default:"200" doc:"Status code of webhook responses"`
+			// Raw bodies; at most one of body, body_base64 and body_file.
+			BodyBase64  []byte                     `json:"body_base64,omitempty" doc:"Binary body, base64-encoded"`
+			BodyFile    string                     `json:"body_file,omitempty" doc:"Return this file from the server's body directory (server.body_dir)" example:"invoice.xml"`
+			ContentType string                     `json:"content_type,omitempty" doc:"Content-Type of webhook responses, overriding headers" example:"application/xml"`
+			Chaos       *webhook.Chaos             `json:"chaos,omitempty" doc:"Latency and faults injected into webhook responses"`
+			Proxy       *webhook.Proxy             `json:"proxy,omitempty" doc:"Record the responses of an upstream API below this webhook, or replay them"`
+			Validation  *webhook.RequestValidation `json:"validation,omitempty" doc:"Check request bodies against a JSON Schema"`
+			Template    bool                       `json:"template,omitempty" doc:"Render body and header values as Go templates, with the request and the webhook's key-value store"`
+			Script      string                     `json:"script,omitempty" maxLength:"65536" doc:"CEL expression computing the response from the request; the other fields are its defaults" example:"{\"status\": 201, \"body\": {\"id\": request.json.id}}"`
+			Plugin      string                     `json:"plugin,omitempty" doc:"WebAssembly plugin computing the response (see /v1/plugins); the other fields are its defaults" example:"pricing"`
+			// A map of structs is validated by Huma; method keys are checked by the domain.
+			Responses map[string]webhook.Response `json:"responses,omitempty" doc:"Further methods the webhook accepts (GET, POST, ... or ANY), each with its own response"`
 		}
 	}) (*struct {
 		Body struct {
@@ -163,13 +251,31 @@ func NewApp(d Deps) (*fiber.App, error) {
 			Path string `json:"path"`
 		}
 	}, error) {
-		h, err := d.Webhooks.Create(ctx, webhooks.CreateParams{
-			Method:  input.Body.Method,
-			Body:    input.Body.Body,
-			Headers: input.Body.Headers,
+		if bodies == nil && usesBodyFile(input.Body.BodyFile, input.Body.Responses) {
+			return nil, huma.Error422UnprocessableEntity(errNoBodyDir.Error())
+		}
+		if err := upstreams.check(input.Body.Proxy); err != nil {
+			return nil, mapDomainErr(err)
+		}
+		h, err := d.Webhooks.Create(withActor(ctx), webhooks.CreateParams{
+			ID:          webhook.ID(input.Body.ID),
+			Method:      input.Body.Method,
+			Body:        input.Body.Body,
+			BodyBase64:  input.Body.BodyBase64,
+			BodyFile:    input.Body.BodyFile,
+			ContentType: input.Body.ContentType,
+			Headers:     input.Body.Headers,
+			Status:      input.Body.Status,
+			Template:    input.Body.Template,
+			Script:      input.Body.Script,
+			Plugin:      input.Body.Plugin,
+			Responses:   input.Body.Responses,
+			Chaos:       input.Body.Chaos,
+			Proxy:       input.Body.Proxy,
+			Validation:  input.Body.Validation,
 		})
 		if err != nil {
-			return nil, err
+			return nil, mapDomainErr(err)
 		}
 		resp := &struct {
 			Body struct {
@@ -182,18 +288,57 @@ func NewApp(d Deps) (*fiber.App, error) {
 		return resp, nil
 	})
 
-	// Webhook management: deactivate
-	huma.Delete(api, "/v1/webhooks/{id}", func(ctx context.Context, input *struct {
-		ID string `path:"id" doc:"Webhook id"`
+	// Webhook management: update
+	huma.Patch(mgmt, "/v1/webhooks/{id}", func(ctx context.Context, input *struct {
+		ID   string `path:"id" doc:"Webhook id"`
+		Body struct {
+			Method    *string                     `json:"method,omitempty" doc:"HTTP method for invoking the webhook" example:"POST"`
+			Body      *string                     `json:"body,omitempty" doc:"Body returned verbatim by the webhook"`
+			Headers   map[string]string           `json:"headers,omitempty" doc:"Replaces the headers included in webhook responses"`
+			Status    *int                        `json:"status,omitempty" minimum:"100" maximum:"599" doc:"Status code of webhook responses"`
+			Responses map[string]webhook.Response `json:"responses,omitempty" doc:"Replaces the per-method responses; {} removes them"`
+			// Setting one of body, body_base64 and body_file replaces the others.
+			BodyBase64  []byte                     `json:"body_base64,omitempty" doc:"Binary body, base64-encoded"`
+			BodyFile    *string                    `json:"body_file,omitempty" doc:"Return this file from the server's body directory (server.body_dir)"`
+			ContentType *string                    `json:"content_type,omitempty" doc:"Content-Type of webhook responses; \"\" removes it"`
+			Chaos       *webhook.Chaos             `json:"chaos,omitempty" doc:"Replaces the chaos settings; {} removes them"`
+			Proxy       *webhook.Proxy             `json:"proxy,omitempty" doc:"Replaces the proxy settings, e.g. {\"mode\":\"replay\"}; {} removes them"`
+			Validation  *webhook.RequestValidation `json:"validation,omitempty" doc:"Replaces the request validation; {} removes it"`
+			Template    *bool                      `json:"template,omitempty" doc:"Render body and header values as Go templates"`
+			Script      *string                    `json:"script,omitempty" maxLength:"65536" doc:"CEL expression computing the response; \"\" removes it"`
+			Plugin      *string                    `json:"plugin,omitempty" doc:"WebAssembly plugin computing the response; \"\" removes it"`
+		}
 	}) (*struct {
 		Body struct {
 			Message string `json:"message"`
 			ID      string `json:"id"`
 		}
 	}, error) {
-		_, ok, err := d.Webhooks.Deactivate(ctx, webhook.ID(input.ID))
+		id := hookIDParam(input.ID)
+		if bodies == nil && usesBodyFile(ptrValue(input.Body.BodyFile), input.Body.Responses) {
+			return nil, huma.Error422UnprocessableEntity(errNoBodyDir.Error())
+		}
+		if err := upstreams.check(input.Body.Proxy); err != nil {
+			return nil, mapDomainErr(err)
+		}
+		_, ok, err := d.Webhooks.Update(withActor(ctx), id, webhooks.UpdateParams{
+			Method:      input.Body.Method,
+			Body:        input.Body.Body,
+			BodyBase64:  input.Body.BodyBase64,
+			BodyFile:    input.Body.BodyFile,
+			ContentType: input.Body.ContentType,
+			Headers:     input.Body.Headers,
+			Status:      input.Body.Status,
+			Template:    input.Body.Template,
+			Script:      input.Body.Script,
+			Plugin:      input.Body.Plugin,
+			Responses:   input.Body.Responses,
+			Chaos:       input.Body.Chaos,
+			Proxy:       input.Body.Proxy,
+			Validation:  input.Body.Validation,
+		})
 		if err != nil {
-			return nil, err
+			return nil, mapDomainErr(err)
 		}
 		if !ok {
 			return nil, huma.Error404NotFound("not found")
@@ -204,54 +349,237 @@ func NewApp(d Deps) (*fiber.App, error) {
 				ID      string `json:"id"`
 			}
 		}{}
-		resp.Body.Message = "deactivated"
-		resp.Body.ID = input.ID
+		resp.Body.Message = "updated"
+		resp.Body.ID = string(id)
 		return resp, nil
 	})
 
-	// Webhook execution for common methods.
-	registerHookInvoke(api, d, http.MethodGet)
-	registerHookInvoke(api, d, http.MethodPost)
-	registerHookInvoke(api, d, http.MethodPut)
-	registerHookInvoke(api, d, http.MethodPatch)
-	registerHookInvoke(api, d, http.MethodDelete)
-	registerHookInvoke(api, d, http.MethodOptions)
+	// Webhook management: deactivate (or delete with ?hard=true)
+	huma.Delete(mgmt, "/v1/webhooks/{id}", func(ctx context.Context, input *struct {
+		ID   string `path:"id" doc:"Webhook id"`
+		Hard bool   `query:"hard" doc:"Delete the webhook permanently instead of deactivating it"`
+	}) (*struct {
+		Body struct {
+			Message string `json:"message"`
+			ID      string `json:"id"`
+		}
+	}, error) {
+		id := hookIDParam(input.ID)
+		op, message := d.Webhooks.Deactivate, "deactivated"
+		if input.Hard {
+			op, message = d.Webhooks.Delete, "deleted"
+		}
+		_, ok, err := op(withActor(ctx), id)
+		if err != nil {
+			return nil, mapDomainErr(err)
+		}
+		if !ok {
+			return nil, huma.Error404NotFound("not found")
+		}
+		resp := &struct {
+			Body struct {
+				Message string `json:"message"`
+				ID      string `json:"id"`
+			}
+		}{}
+		resp.Body.Message = message
+		resp.Body.ID = string(id)
+		return resp, nil
+	})
 
-	_ = d.Config // will be used in auth todo
+	registerHookQueries(mgmt, d)
+	registerHookEvents(mgmt, d)
+	registerHookState(mgmt, d)
+	registerAudit(mgmt, d)
+	registerImports(mgmt, d)
+	registerPlugins(mgmt, d)
+
+	// Webhook execution for common methods. Hook IDs may span several path
+	// segments.
+	hooksAPI := restParamAPI{API: api, param: "id"}
+	registerHookInvoke(hooksAPI, d, bodies, upstreams, http.MethodGet)
+	registerHookInvoke(hooksAPI, d, bodies, upstreams, http.MethodPost)
+	registerHookInvoke(hooksAPI, d, bodies, upstreams, http.MethodPut)
+	registerHookInvoke(hooksAPI, d, bodies, upstreams, http.MethodPatch)
+	registerHookInvoke(hooksAPI, d, bodies, upstreams, http.MethodDelete)
+	registerHookInvoke(hooksAPI, d, bodies, upstreams, http.MethodOptions)
 
 	return app, nil
 }
 
-func registerHookInvoke(api huma.API, d Deps, method string) {
+// registerHookInvoke serves a hook for method. Bodies are written verbatim,
+// except plain ones with server.quoted_bodies set, which are encoded as a
+// JSON string; bodies is the body_file directory, nil when unset, and
+// upstreams forwards the requests of recording proxies. The handler writes
+// the response itself so that chaos settings can delay, drip, cut short or
+// abort it.
+func registerHookInvoke(api huma.API, d Deps, bodies *os.Root, upstreams *proxyUpstreams, method string) {
 	huma.Register(api, huma.Operation{
 		OperationID: "invoke-hook-" + strings.ToLower(method),
 		Method:      method,
 		Path:        "/v1/hooks/{id}",
 		Summary:     "Invoke a webhook",
-		Errors:      []int{404, 405},
+		Errors:      []int{400, 404, 405, 413, 422},
 	}, func(ctx context.Context, input *struct {
-		ID string `path:"id" doc:"Webhook id"`
-	}) (*struct {
-		Body string
-	}, error) {
-		h, ok, err := d.Webhooks.Get(ctx, webhook.ID(input.ID))
+		ID    string `path:"id" doc:"Webhook id; may span several path segments"`
+		Chaos string `header:"X-Webhookd-Chaos" doc:"on applies header_only chaos settings, off disables chaos; with server.chaos_header, settings such as delay_ms=200,error_rate=0.5 replace the hook's"`
+	}) (_ *invokeOutput, err error) {
+		start := time.Now()
+		// Copied out of Fiber's buffers; the id outlives the request in spans and metrics.
+		hookID := string(hookIDParam(input.ID))
+		span := trace.SpanFromContext(ctx)
+		span.SetAttributes(attribute.String("webhookd.hook.id", hookID))
+		fc, _ := ctx.Value(fiberCtxKey{}).(*fiber.Ctx)
+		// validation is captured with the request, once checked.
+		var validation *webhook.ValidationResult
+		// hook is the hook invoked, once found, and recorded whether the
+		// outcome was.
+		var hook *webhook.Hook
+		var recorded bool
+		// record records the outcome (span, metrics) and captures the request
+		// when it targeted an existing hook. fault names the fault that cut
+		// the response short, if any; status is 0 when none was sent.
+		record := func(h *webhook.Hook, status int, fault string) {
+			recorded = true
+			labelID := unknownHookID
+			if h != nil {
+				labelID = hookID
+			}
+			span.SetAttributes(attribute.String("webhookd.hook.outcome", invocationOutcome(status, fault)))
+			if status != 0 {
+				span.SetAttributes(attribute.Int("webhookd.hook.status", status))
+			}
+			d.Metrics.HookInvoked(labelID, method, status, fault)
+			d.Instruments.HookInvoked(ctx, labelID, method, status, fault, time.Since(start))
+
+			if h != nil && fc != nil {
+				req := captureRequest(fc, h.ID, status, time.Since(start))
+				req.Validation, req.Fault = validation, fault
+				if err := d.Webhooks.RecordRequest(ctx, req); err != nil {
+					d.logger().WarnContext(ctx, "capture request", "hook_id", hookID, "error", err)
+				}
+			}
+		}
+
+		served := func(h *webhook.Hook, status int) { record(h, status, "") }
+		// Failures that return before an outcome is recorded (repository
+		// errors, cancelled requests) are recorded with the status of err.
+		defer func() {
+			if err != nil && !recorded {
+				served(hook, errorStatus(err))
+			}
+		}()
+
+		if fc != nil {
+			fc.Locals(hookIDLocal, hookID)
+			if len(fc.Body()) > maxHookBody {
+				return nil, huma.NewError(http.StatusRequestEntityTooLarge, "request body too large")
+			}
+		}
+
+		proxy, sub, ok, err := d.Webhooks.ProxyFor(ctx, webhook.ID(hookID))
+		if err != nil {
+			return nil, err
+		}
+		if ok && fc != nil {
+			hook = proxy
+			return proxyInvoke(ctx, d, upstreams, fc, served, proxy, sub, method)
+		}
+
+		h, ok, err := d.Webhooks.Get(ctx, webhook.ID(hookID))
 		if err != nil {
 			return nil, err
 		}
+		if ok && h.Active {
+			hook = h
+		}
 		if !ok || !h.Active {
+			served(nil, http.StatusNotFound)
 			return nil, huma.Error404NotFound("not found")
 		}
-		if !h.MatchesMethod(method) {
+		span.SetAttributes(attribute.String("webhookd.hook.method", h.Method))
+		r, ok := h.ResponseFor(method)
+		if !ok {
+			if fc != nil {
+				fc.Set(fiber.HeaderAllow, strings.Join(h.Allow(), ", "))
+			}
+			served(h, http.StatusMethodNotAllowed)
 			return nil, huma.Error405MethodNotAllowed("method not allowed")
 		}
 
-		h, _, err = d.Webhooks.Touch(ctx, webhook.ID(input.ID))
+		if h.Validation != nil && fc != nil {
+			if validation, err = d.Webhooks.ValidateRequest(ctx, h, fc.Body()); err != nil {
+				return nil, err
+			}
+			span.SetAttributes(attribute.Bool("webhookd.hook.request_valid", validation.Valid))
+			d.Metrics.HookValidated(hookID, validation.Valid)
+			if !validation.Valid && h.Validation.Rejects() {
+				served(h, http.StatusUnprocessableEntity)
+				return nil, validationError(validation)
+			}
+		}
+
+		fault, err := rollChaos(h.Chaos, input.Chaos, d.Config.Server.ChaosHeader)
 		if err != nil {
+			served(h, http.StatusBadRequest)
+			return nil, huma.Error400BadRequest(err.Error())
+		}
+		if kind := fault.Kind(); kind != "" {
+			span.SetAttributes(attribute.String("webhookd.hook.fault", kind))
+			d.Metrics.HookFaulted(hookID, kind)
+		}
+		if fault.Delay > 0 {
+			if err := sleepCtx(ctx, fault.Delay); err != nil {
+				return nil, err
+			}
+		}
+
+		if _, _, err := d.Webhooks.Touch(ctx, webhook.ID(hookID)); err != nil {
 			return nil, err
 		}
+		if fault.ErrorStatus != 0 {
+			served(h, fault.ErrorStatus)
+			return nil, huma.NewError(fault.ErrorStatus, "injected fault")
+		}
 
-		fc, _ := ctx.Value(fiberCtxKey{}).(*fiber.Ctx)
-		for k, v := range h.Headers {
+		if r.Template && fc != nil {
+			if r, err = d.Webhooks.Render(ctx, h, r, templateData(fc)); err != nil {
+				d.logger().WarnContext(ctx, "render hook template", "hook_id", hookID, "error", err)
+				served(h, http.StatusInternalServerError)
+				return nil, huma.Error500InternalServerError("template failed: " + err.Error())
+			}
+		}
+		if r.Script != "" && fc != nil {
+			if r, err = d.Webhooks.RunScript(ctx, h, r, templateData(fc)); err != nil {
+				d.logger().WarnContext(ctx, "run hook script", "hook_id", hookID, "error", err)
+				served(h, http.StatusInternalServerError)
+				return nil, huma.Error500InternalServerError(err.Error())
+			}
+		}
+		if name := r.Plugin; name != "" && fc != nil {
+			if r, err = d.Webhooks.RunPlugin(ctx, h, r, templateData(fc)); err != nil {
+				d.logger().WarnContext(ctx, "run hook plugin", "hook_id", hookID, "plugin", name, "error", err)
+				if errors.Is(err, webhook.ErrPluginBusy) {
+					served(h, http.StatusServiceUnavailable)
+					return nil, huma.Error503ServiceUnavailable(err.Error())
+				}
+				served(h, http.StatusInternalServerError)
+				return nil, huma.Error500InternalServerError(err.Error())
+			}
+		}
+
+		quoted := d.Config.Server.QuotedBodies && !r.Raw()
+		var raw []byte
+		if !quoted {
+			raw, err = rawBody(bodies, r)
+			if err != nil {
+				d.logger().ErrorContext(ctx, "read hook body", "hook_id", hookID, "body_file", r.BodyFile, "error", err)
+				served(h, http.StatusInternalServerError)
+				return nil, huma.Error500InternalServerError("hook body unavailable")
+			}
+		}
+
+		for k, v := range r.Headers {
 			if strings.EqualFold(k, "Content-Length") {
 				continue
 			}
@@ -259,11 +587,141 @@ func registerHookInvoke(api huma.API, d Deps, method string) {
 				fc.Set(k, v)
 			}
 		}
+		if fc != nil {
+			addHeaderValues(fc, r)
+		}
 
-		resp := &struct {
-			Body string
-		}{}
-		resp.Body = h.Body
+		switch {
+		case fc == nil:
+			served(h, r.Status)
+		case fault.Abort:
+			record(h, 0, fault.Kind())
+		case fault.Truncate:
+			record(h, r.Status, fault.Kind())
+		default:
+			served(h, r.Status)
+		}
+
+		resp := &invokeOutput{}
+		resp.Body = func(hctx huma.Context) {
+			body := raw
+			if quoted {
+				// As Huma would encode it, in the negotiated format.
+				ct, err := api.Negotiate(hctx.Header("Accept"))
+				if err != nil {
+					ct = "application/json"
+				}
+				var buf bytes.Buffer
+				if err := api.Marshal(&buf, ct, r.Body); err != nil {
+					ct, buf = "application/json", bytes.Buffer{}
+					_ = json.NewEncoder(&buf).Encode(r.Body)
+				}
+				hctx.SetHeader("Content-Type", ct)
+				body = buf.Bytes()
+			} else {
+				hctx.SetHeader("Content-Type", rawContentType(r, raw))
+			}
+			hctx.SetStatus(r.Status)
+			writeFaulty(ctx, hctx, fc, body, fault)
+		}
 		return resp, nil
 	})
 }
+
+// invokeOutput is the output of hook invocations. A Body func leaves writing
+// the response, status included, to the handler.
+type invokeOutput struct {
+	Body func(huma.Context)
+}
+
+// AuthConfig derives the token middleware settings from the config.
+func AuthConfig(c configfile.Config, m *observability.Metrics) jwtmiddleware.Config {
+	return jwtmiddleware.Config{
+		EnableAuthOnOptions: c.EnableAuthOnOptions,
+		TokenExtractors:     c.TokenExtractors,
+		JWKSURL:             c.OAuthJsonWebKeySetsURL,
+		Issuer:              c.OAuthIssuer,
+		Audience:            c.OAuthAudience,
+		OnJWKSRefresh:       m.JWKSRefreshed,
+	}
+}
+
+// withActor attaches the caller identity for the audit log: the token subject
+// when the request was authenticated, and the client IP.
+func withActor(ctx context.Context) context.Context {
+	var a webhooks.Actor
+	if tok, ok := ctx.Value(jwtmiddleware.TokenContextKey{}).(jwtmiddleware.TokenInfo); ok {
+		a.Subject, _ = tok.Claims["sub"].(string)
+	}
+	if fc, ok := ctx.Value(fiberCtxKey{}).(*fiber.Ctx); ok {
+		a.SourceIP = strings.Clone(fc.IP())
+	}
+	return webhooks.WithActor(ctx, a)
+}
+
+// mapDomainErr turns validation errors from the domain into 422s, and
+// conflicts (taken IDs, changes to managed hooks) into 409s.
+func mapDomainErr(err error) error {
+	switch {
+	case errors.Is(err, webhook.ErrUnsupportedMethod), errors.Is(err, webhook.ErrInvalidStatus), errors.Is(err, webhook.ErrInvalidID),
+		errors.Is(err, webhook.ErrInvalidBody), errors.Is(err, webhook.ErrInvalidChaos), errors.Is(err, webhook.ErrInvalidProxy),
+		errors.Is(err, webhook.ErrInvalidValidation), errors.Is(err, webhook.ErrInvalidTemplate),
+		errors.Is(err, webhook.ErrInvalidScript), errors.Is(err, webhook.ErrInvalidPlugin), errors.Is(err, webhook.ErrUpstreamNotAllowed):
+		return huma.Error422UnprocessableEntity(err.Error())
+	case errors.Is(err, webhook.ErrManaged):
+		return huma.Error409Conflict(err.Error() + "; change it there")
+	case errors.Is(err, webhook.ErrIDExists):
+		return huma.Error409Conflict(err.Error())
+	}
+	return err
+}
+
+// usesBodyFile reports whether a hook's response or any of responses is
+// served from a file.
+func usesBodyFile(bodyFile string, responses map[string]webhook.Response) bool {
+	if bodyFile != "" {
+		return true
+	}
+	for _, r := range responses {
+		if r.BodyFile != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func ptrValue[T any](p *T) T {
+	var v T
+	if p != nil {
+		v = *p
+	}
+	return v
+}
+
+// errorStatus is the status Huma answers err with.
+func errorStatus(err error) int {
+	var se huma.StatusError
+	if errors.As(err, &se) {
+		return se.GetStatus()
+	}
+	return http.StatusInternalServerError
+}
+
+// invocationOutcome is the webhookd.hook.outcome span attribute for a served
+// status, or for the fault that cut the response short.
+func invocationOutcome(status int, fault string) string {
+	switch {
+	case fault == "abort":
+		return "aborted"
+	case fault == "truncate":
+		return "truncated"
+	}
+	switch status {
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusMethodNotAllowed:
+		return "method_not_allowed"
+	default:
+		return "served"
+	}
+}
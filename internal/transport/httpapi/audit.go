@@ -0,0 +1,79 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"webhookd/internal/domain/audit"
+	"webhookd/internal/domain/webhook"
+)
+
+type auditQuery struct {
+	HookID string    `query:"hook_id" doc:"Only entries for this webhook id"`
+	Actor  string    `query:"actor" doc:"Only entries by this actor (token subject, or anonymous)"`
+	Action string    `query:"action" enum:"create,update,deactivate,delete" doc:"Only entries with this action"`
+	Since  time.Time `query:"since" doc:"Only entries at or after this time (RFC 3339)"`
+	Until  time.Time `query:"until" doc:"Only entries before this time (RFC 3339)"`
+	Limit  int       `query:"limit" minimum:"0" doc:"Keep only the most recent N entries (0 = all)"`
+}
+
+func (q auditQuery) filter() audit.Filter {
+	return audit.Filter{
+		HookID: webhook.ID(q.HookID),
+		Actor:  q.Actor,
+		Action: audit.Action(q.Action),
+		Since:  q.Since,
+		Until:  q.Until,
+		Limit:  q.Limit,
+	}
+}
+
+func registerAudit(api huma.API, d Deps) {
+	huma.Get(api, "/v1/audit", func(ctx context.Context, input *auditQuery) (*struct {
+		Body struct {
+			Entries []audit.Entry `json:"entries"`
+		}
+	}, error) {
+		entries, err := d.Webhooks.AuditLog(ctx, input.filter())
+		if err != nil {
+			return nil, err
+		}
+		resp := &struct {
+			Body struct {
+				Entries []audit.Entry `json:"entries"`
+			}
+		}{}
+		resp.Body.Entries = entries
+		return resp, nil
+	}, func(o *huma.Operation) {
+		o.Summary = "Query the audit log of management operations"
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID: "export-audit",
+		Method:      http.MethodGet,
+		Path:        "/v1/audit/export",
+		Summary:     "Export the audit log as JSON Lines",
+	}, func(ctx context.Context, input *auditQuery) (*huma.StreamResponse, error) {
+		entries, err := d.Webhooks.AuditLog(ctx, input.filter())
+		if err != nil {
+			return nil, err
+		}
+		return &huma.StreamResponse{
+			Body: func(hctx huma.Context) {
+				hctx.SetHeader("Content-Type", "application/jsonl")
+				hctx.SetHeader("Content-Disposition", `attachment; filename="webhookd-audit.jsonl"`)
+				enc := json.NewEncoder(hctx.BodyWriter())
+				for _, e := range entries {
+					if err := enc.Encode(e); err != nil {
+						return
+					}
+				}
+			},
+		}, nil
+	})
+}
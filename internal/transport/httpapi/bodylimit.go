@@ -0,0 +1,33 @@
+package httpapi
+
+import (
+	"bytes"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+
+	"webhookd/internal/domain/plugin"
+)
+
+// limitBodies keeps Fiber's default body limit for all requests but plugin
+// uploads and OpenAPI imports, which get the limit of their operation. The
+// server checks it before reading the body, so no other route buffers
+// bodies that large.
+func limitBodies(app *fiber.App) {
+	app.Server().HeaderReceived = func(h *fasthttp.RequestHeader) fasthttp.RequestConfig {
+		return fasthttp.RequestConfig{MaxRequestBodySize: bodyLimit(h.Method(), h.RequestURI())}
+	}
+}
+
+// bodyLimit is how large the body of a request for uri may be.
+func bodyLimit(method, uri []byte) int {
+	path, _, _ := bytes.Cut(uri, []byte("?"))
+	path = bytes.ToLower(path) // Fiber routes ignore case
+	switch {
+	case string(method) == fiber.MethodPut && bytes.HasPrefix(path, []byte("/v1/plugins/")):
+		return plugin.MaxModuleSize
+	case string(method) == fiber.MethodPost && string(bytes.TrimSuffix(path, []byte("/"))) == "/v1/imports/openapi":
+		return maxImportBody
+	}
+	return fiber.DefaultBodyLimit
+}
@@ -0,0 +1,115 @@
+package httpapi
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+
+	"webhookd/internal/domain/webhook"
+)
+
+var errChaosHeaderDisabled = errors.New(webhook.ChaosHeader + " only accepts on and off; set server.chaos_header to pass settings")
+
+// rollChaos decides the faults of one invocation from the hook's settings
+// and the request's X-Webhookd-Chaos header: "off" disables them, "on"
+// applies header_only settings, and with server.chaos_header set, any other
+// value replaces the hook's settings for this request (webhook.ParseChaos).
+func rollChaos(hook *webhook.Chaos, header string, override bool) (webhook.Fault, error) {
+	header = strings.TrimSpace(header)
+	var c webhook.Chaos
+	switch {
+	case strings.EqualFold(header, "off"):
+		return webhook.Fault{}, nil
+	case header == "" || strings.EqualFold(header, "on"):
+		if hook == nil || (hook.HeaderOnly && header == "") {
+			return webhook.Fault{}, nil
+		}
+		c = *hook
+	case override:
+		var err error
+		if c, err = webhook.ParseChaos(header); err != nil {
+			return webhook.Fault{}, err
+		}
+	default:
+		return webhook.Fault{}, errChaosHeaderDisabled
+	}
+	return c.Roll(), nil
+}
+
+// sleepCtx waits for d, or until ctx is done.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// writeFaulty writes body, or breaks the response as f says. Status and
+// headers must be set already. Aborts and truncations take over the
+// connection, which is closed afterwards; dripping stops when ctx is done.
+func writeFaulty(ctx context.Context, hctx huma.Context, fc *fiber.Ctx, body []byte, f webhook.Fault) {
+	if fc == nil {
+		_, _ = hctx.BodyWriter().Write(body)
+		return
+	}
+	rc := fc.Context()
+	switch {
+	case f.Abort:
+		conn := rc.Conn()
+		rc.HijackSetNoResponse(true)
+		rc.Hijack(func(net.Conn) {
+			// With linger 0, closing sends a RST rather than a FIN.
+			if tcp, ok := conn.(interface{ SetLinger(int) error }); ok {
+				_ = tcp.SetLinger(0)
+			}
+			_ = conn.Close()
+		})
+	case f.Truncate:
+		n := f.TruncateBytes
+		if n < 0 {
+			n = len(body) / 2
+		}
+		n = min(n, len(body))
+		// Announce the whole body, send part of it, then hang up.
+		var hdr fasthttp.ResponseHeader
+		rc.Response.Header.CopyTo(&hdr)
+		hdr.SetContentLength(len(body))
+		head, part := hdr.Header(), body[:n]
+		rc.HijackSetNoResponse(true)
+		rc.Hijack(func(c net.Conn) {
+			if _, err := c.Write(head); err == nil {
+				_, _ = c.Write(part)
+			}
+		})
+	case f.DripBytes > 0:
+		rc.SetBodyStreamWriter(func(w *bufio.Writer) {
+			for rest := body; len(rest) > 0; {
+				n := min(f.DripBytes, len(rest))
+				if _, err := w.Write(rest[:n]); err != nil {
+					return
+				}
+				if err := w.Flush(); err != nil {
+					return
+				}
+				if rest = rest[n:]; len(rest) > 0 {
+					if sleepCtx(ctx, f.DripInterval) != nil {
+						return
+					}
+				}
+			}
+		})
+	default:
+		_, _ = hctx.BodyWriter().Write(body)
+	}
+}
@@ -0,0 +1,108 @@
+package httpapi
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humafiber"
+	"github.com/gofiber/fiber/v2"
+
+	"webhookd/internal/domain/webhook"
+)
+
+// serveFaulty serves body with writeFaulty(ctx, ..., f) and returns the
+// address to request it from.
+func serveFaulty(t *testing.T, ctx context.Context, body string, f webhook.Fault) string {
+	t.Helper()
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	api := humafiber.New(app, huma.DefaultConfig("test", "1"))
+	huma.Register(api, huma.Operation{Method: http.MethodGet, Path: "/"}, func(context.Context, *struct{}) (*invokeOutput, error) {
+		return &invokeOutput{Body: func(hctx huma.Context) {
+			hctx.SetHeader("Content-Type", "text/plain")
+			hctx.SetStatus(http.StatusOK)
+			writeFaulty(ctx, hctx, humafiber.Unwrap(hctx), []byte(body), f)
+		}}, nil
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() { _ = app.Shutdown() })
+	return ln.Addr().String()
+}
+
+// getRaw requests / from addr and returns the announced Content-Length and
+// the body bytes that arrived before the connection ended.
+func getRaw(t *testing.T, addr string) (contentLength int64, body string, err error) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.WriteString(conn, "GET / HTTP/1.1\r\nHost: test\r\nConnection: close\r\n\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	return resp.ContentLength, string(b), err
+}
+
+func TestWriteFaulty(t *testing.T) {
+	const body = "0123456789"
+	tests := []struct {
+		name       string
+		fault      webhook.Fault
+		wantLength int64
+		wantBody   string
+		wantCut    bool // the body ends early
+	}{
+		{name: "none", wantLength: 10, wantBody: body},
+		{name: "truncate half", fault: webhook.Fault{Truncate: true, TruncateBytes: -1}, wantLength: 10, wantBody: "01234", wantCut: true},
+		{name: "truncate bytes", fault: webhook.Fault{Truncate: true, TruncateBytes: 3}, wantLength: 10, wantBody: "012", wantCut: true},
+		{name: "truncate none", fault: webhook.Fault{Truncate: true}, wantLength: 10, wantCut: true},
+		{name: "truncate more than the body", fault: webhook.Fault{Truncate: true, TruncateBytes: 100}, wantLength: 10, wantBody: body},
+		{name: "drip", fault: webhook.Fault{DripBytes: 3, DripInterval: time.Millisecond}, wantLength: -1, wantBody: body},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := serveFaulty(t, context.Background(), body, tt.fault)
+			length, got, err := getRaw(t, addr)
+			if (err != nil) != tt.wantCut {
+				t.Fatalf("read body: %v, want cut %v", err, tt.wantCut)
+			}
+			if length != tt.wantLength || got != tt.wantBody {
+				t.Fatalf("got Content-Length %d, body %q; want %d, %q", length, got, tt.wantLength, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestWriteFaultyAbort(t *testing.T) {
+	addr := serveFaulty(t, context.Background(), "body", webhook.Fault{Abort: true})
+	if _, _, err := getRaw(t, addr); err == nil {
+		t.Fatal("got a response, want the connection reset")
+	}
+}
+
+func TestWriteFaultyDripStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	addr := serveFaulty(t, ctx, "0123456789", webhook.Fault{DripBytes: 3, DripInterval: time.Hour})
+	cancel()
+	_, got, _ := getRaw(t, addr)
+	if got != "012" {
+		t.Fatalf("body %q, want only the first chunk", got)
+	}
+}
@@ -0,0 +1,93 @@
+package httpapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humafiber"
+
+	"webhookd/internal/domain/webhook"
+)
+
+// eventsKeepAlive is how often an idle event stream sends a comment line, so
+// proxies keep the connection open and dead clients are noticed.
+const eventsKeepAlive = 15 * time.Second
+
+func registerHookEvents(api huma.API, d Deps) {
+	huma.Register(api, huma.Operation{
+		OperationID: "stream-hook-events",
+		Method:      http.MethodGet,
+		Path:        "/v1/webhooks/{id}/events",
+		Summary:     "Stream invocations of a webhook as server-sent events",
+		Description: "Each captured invocation is sent as a `request` event whose data is a captured request (as in /v1/webhooks/{id}/requests). The stream ends with a `deleted` event when the hook is deleted.",
+		Errors:      []int{404},
+	}, func(ctx context.Context, input *struct {
+		ID string `path:"id" doc:"Webhook id"`
+	}) (*huma.StreamResponse, error) {
+		// The id keys the subscription, which outlives the request buffers.
+		id := hookIDParam(input.ID)
+		if _, ok, err := d.Webhooks.Get(ctx, id); err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, huma.Error404NotFound("not found")
+		}
+
+		return &huma.StreamResponse{
+			Body: func(hctx huma.Context) {
+				hctx.SetHeader("Content-Type", "text/event-stream")
+				hctx.SetHeader("Cache-Control", "no-cache")
+				hctx.SetHeader("X-Accel-Buffering", "no")
+
+				// Subscribe before the handler returns so nothing sent after
+				// the client got its 200 is missed.
+				events, cancel := d.Webhooks.Subscribe(id)
+				fc := humafiber.Unwrap(hctx)
+				// The writer runs after the handler has returned; fiber strings
+				// and the huma context must not be used inside it.
+				done := fc.Context().Done()
+				fc.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+					defer cancel()
+					streamHookEvents(w, events, done)
+				})
+			},
+		}, nil
+	})
+}
+
+func streamHookEvents(w *bufio.Writer, events <-chan webhook.Request, done <-chan struct{}) {
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+
+	fmt.Fprint(w, ": connected\n\n")
+	if w.Flush() != nil {
+		return
+	}
+	for {
+		select {
+		case <-done:
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case r, ok := <-events:
+			if !ok {
+				fmt.Fprint(w, "event: deleted\ndata: {}\n\n")
+				_ = w.Flush()
+				return
+			}
+			data, err := json.Marshal(newCapturedRequest(r))
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "event: request\nid: %s\ndata: %s\n\n", r.ID, data)
+		}
+		// A failed flush means the client went away.
+		if w.Flush() != nil {
+			return
+		}
+	}
+}
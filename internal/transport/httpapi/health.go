@@ -0,0 +1,29 @@
+package httpapi
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"webhookd/internal/application/health"
+)
+
+// mountHealth registers the non-API probes. /healthz is kept as an alias of
+// /livez for existing deployments.
+func mountHealth(app *fiber.App, reg *health.Registry) {
+	live := func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"status": health.StatusOK})
+	}
+	app.Get("/livez", live)
+	app.Get("/healthz", live)
+
+	app.Get("/readyz", func(c *fiber.Ctx) error {
+		if reg == nil {
+			return c.JSON(health.Report{Status: health.StatusOK, Checks: map[string]health.Result{}})
+		}
+		rep := reg.Ready(c.UserContext())
+		status := fiber.StatusOK
+		if !rep.OK() {
+			status = fiber.StatusServiceUnavailable
+		}
+		return c.Status(status).JSON(rep)
+	})
+}
@@ -0,0 +1,96 @@
+package httpapi
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"webhookd/internal/domain/webhook"
+)
+
+// maxHookBody caps the bodies of hook invocations, at what Fiber allows by
+// default.
+const maxHookBody = fiber.DefaultBodyLimit
+
+// errNoBodyDir rejects body_file when server.body_dir isn't set.
+var errNoBodyDir = errors.New("body_file requires server.body_dir")
+
+// openBodyDir opens the directory hooks serve body_file from; nil when it
+// isn't configured. os.Root keeps file names from escaping it (.., symlinks).
+func openBodyDir(dir string) (*os.Root, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	return os.OpenRoot(dir)
+}
+
+// rawBody reads the bytes of a response body.
+func rawBody(bodies *os.Root, r webhook.Response) ([]byte, error) {
+	switch {
+	case r.BodyFile != "":
+		if bodies == nil {
+			return nil, errNoBodyDir
+		}
+		return bodies.ReadFile(r.BodyFile)
+	case len(r.BodyBase64) > 0:
+		return r.BodyBase64, nil
+	default:
+		return []byte(r.Body), nil
+	}
+}
+
+// addHeaderValues sends the headers of r that have several values, once per
+// value.
+func addHeaderValues(fc *fiber.Ctx, r webhook.Response) {
+	for k, vs := range r.HeaderValues {
+		if strings.EqualFold(k, "Content-Length") {
+			continue
+		}
+		fc.Response().Header.Del(k)
+		for _, v := range vs {
+			fc.Response().Header.Add(k, v)
+		}
+	}
+}
+
+// rawContentType picks the Content-Type of a verbatim response: the
+// response's content_type, else a Content-Type header, else the file
+// extension, else one sniffed from the body.
+func rawContentType(r webhook.Response, body []byte) string {
+	if r.ContentType != "" {
+		return r.ContentType
+	}
+	for k, v := range r.Headers {
+		if strings.EqualFold(k, "Content-Type") {
+			return v
+		}
+	}
+	if r.BodyFile != "" {
+		if ct := mime.TypeByExtension(path.Ext(r.BodyFile)); ct != "" {
+			return ct
+		}
+	}
+	return http.DetectContentType(body)
+}
+
+// templateData is the request as templated responses see it. Strings are
+// copied out of Fiber's buffers, since templates may store them.
+func templateData(fc *fiber.Ctx) webhook.TemplateData {
+	query, _ := url.ParseQuery(string(fc.Request().URI().QueryString()))
+	return webhook.NewTemplateData(strings.Clone(fc.Method()), strings.Clone(fc.Path()), query, requestHeader(fc), fc.Body())
+}
+
+// requestHeader copies the request headers out of Fiber's buffers.
+func requestHeader(fc *fiber.Ctx) http.Header {
+	header := http.Header{}
+	fc.Request().Header.VisitAll(func(k, v []byte) {
+		header.Add(string(k), string(v))
+	})
+	return header
+}
@@ -0,0 +1,74 @@
+package httpapi
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"webhookd/internal/domain/webhook"
+)
+
+// hookIDParam turns an {id} path parameter into a hook ID. Multi-segment IDs
+// ("github/org-events") arrive with "/" escaped as %2F on the management
+// routes, where a raw slash would be ambiguous with sub-resources such as
+// /requests. Fiber params point into reused buffers, so the result is copied
+// (PathUnescape returns its input when there is nothing to unescape).
+func hookIDParam(raw string) webhook.ID {
+	id, err := url.PathUnescape(raw)
+	if err != nil {
+		id = raw
+	}
+	return webhook.ID(strings.Clone(id))
+}
+
+// restParamAPI registers operations whose path ends in "/{param}" so that the
+// parameter also matches the rest of the path, slashes included
+// (/v1/hooks/github/org-events). The OpenAPI document keeps the plain path.
+type restParamAPI struct {
+	huma.API
+	param string
+}
+
+func (a restParamAPI) Adapter() huma.Adapter {
+	return restParamAdapter{Adapter: a.API.Adapter(), param: a.param}
+}
+
+type restParamAdapter struct {
+	huma.Adapter
+	param string
+}
+
+func (a restParamAdapter) Handle(op *huma.Operation, handler func(huma.Context)) {
+	suffix := "/{" + a.param + "}"
+	if !strings.HasSuffix(op.Path, suffix) {
+		a.Adapter.Handle(op, handler)
+		return
+	}
+	// Fiber's greedy "+" parameter matches one or more segments.
+	routed := *op
+	routed.Path = strings.TrimSuffix(op.Path, suffix) + "/+"
+	a.Adapter.Handle(&routed, func(ctx huma.Context) {
+		handler(restParamContext{humaContext: ctx, param: a.param})
+	})
+}
+
+// humaContext is embedded under another name, since huma.Context has a
+// Context method.
+type humaContext = huma.Context
+
+// restParamContext answers the parameter from Fiber's "+" wildcard.
+type restParamContext struct {
+	humaContext
+	param string
+}
+
+func (c restParamContext) Param(name string) string {
+	if name == c.param {
+		return c.humaContext.Param("+")
+	}
+	return c.humaContext.Param(name)
+}
+
+// Unwrap lets humafiber.Unwrap reach the Fiber context.
+func (c restParamContext) Unwrap() huma.Context { return c.humaContext }
@@ -0,0 +1,88 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"webhookd/internal/application/webhooks"
+	"webhookd/internal/domain/webhook"
+	"webhookd/internal/infrastructure/openapiimport"
+)
+
+// maxImportBody caps the size of imported documents.
+const maxImportBody = 10 << 20
+
+// ImportedHook is the outcome of importing one hook.
+type ImportedHook struct {
+	ID      string   `json:"id"`
+	Path    string   `json:"path" doc:"Path of the operations in the document"`
+	Methods []string `json:"methods"`
+	Status  string   `json:"status" enum:"created,updated,unchanged,exists,failed" doc:"exists: the id is taken and replace was not set"`
+	Error   string   `json:"error,omitempty"`
+}
+
+type importOutput struct {
+	Body struct {
+		Title   string                  `json:"title,omitempty"`
+		Prefix  string                  `json:"prefix"`
+		DryRun  bool                    `json:"dry_run,omitempty"`
+		Hooks   []ImportedHook          `json:"hooks"`
+		Skipped []openapiimport.Skipped `json:"skipped,omitempty" doc:"Operations no hook answers"`
+	}
+}
+
+func registerImports(api huma.API, d Deps) {
+	huma.Register(api, huma.Operation{
+		OperationID:  "import-openapi",
+		Method:       http.MethodPost,
+		Path:         "/v1/imports/openapi",
+		Summary:      "Create mock hooks from an OpenAPI 3.x document",
+		Description:  "Creates a hook for each path of the document (JSON or YAML) below prefix, answering its operations with their example responses. Path parameters are filled in with their examples.",
+		MaxBodyBytes: maxImportBody,
+		Errors:       []int{422},
+	}, func(ctx context.Context, input *struct {
+		Prefix  string `query:"prefix" doc:"Id prefix of the hooks; defaults to the slug of the document title" example:"partner-api"`
+		Replace bool   `query:"replace" doc:"Replace the responses of hooks whose id is taken"`
+		DryRun  bool   `query:"dry_run" doc:"Report what would be imported without changing anything"`
+		RawBody []byte `contentType:"application/yaml"`
+	}) (*importOutput, error) {
+		res, err := openapiimport.Parse(ctx, input.RawBody, webhook.ID(input.Prefix))
+		if errors.Is(err, openapiimport.ErrInvalidDocument) {
+			return nil, huma.Error422UnprocessableEntity(err.Error())
+		}
+		if err != nil {
+			return nil, mapDomainErr(err)
+		}
+
+		specs := make([]webhooks.HookSpec, len(res.Hooks))
+		for i, h := range res.Hooks {
+			specs[i] = webhooks.HookSpec{ID: h.ID, Method: h.Method, Response: h.Response, Responses: h.Responses}
+		}
+		results, err := d.Webhooks.Import(withActor(ctx), specs, input.Replace, input.DryRun)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := &importOutput{}
+		resp.Body.Title = res.Title
+		resp.Body.Prefix = string(res.Prefix)
+		resp.Body.DryRun = input.DryRun
+		resp.Body.Skipped = res.Skipped
+		resp.Body.Hooks = make([]ImportedHook, len(results))
+		for i, r := range results {
+			resp.Body.Hooks[i] = ImportedHook{
+				ID:      string(r.ID),
+				Path:    res.Hooks[i].Path,
+				Methods: res.Hooks[i].Methods(),
+				Status:  r.Status,
+			}
+			if r.Err != nil {
+				resp.Body.Hooks[i].Error = r.Err.Error()
+			}
+		}
+		return resp, nil
+	})
+}
@@ -0,0 +1,57 @@
+package httpapi
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+
+	"webhookd/internal/observability"
+)
+
+// unknownHookID is used as the hook_id label for invocations of ids that don't
+// exist, so random ids from scanners can't blow up the metric cardinality.
+const unknownHookID = "unknown"
+
+func metricsMiddleware(m *observability.Metrics) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			// The error handler runs after this middleware returns; mirror its status.
+			status = fiber.StatusInternalServerError
+			if fe, ok := err.(*fiber.Error); ok {
+				status = fe.Code
+			}
+		}
+		// Fiber strings point into reused buffers; labels outlive the request.
+		m.ObserveHTTP(strings.Clone(c.Method()), c.Route().Path, status, time.Since(start))
+		return err
+	}
+}
+
+func mountMetrics(app *fiber.App, m *observability.Metrics, path string) {
+	if path == "" {
+		path = "/metrics"
+	}
+	app.Get(path, adaptor.HTTPHandler(m.Handler()))
+}
+
+type AdminDeps struct {
+	Metrics     *observability.Metrics
+	MetricsPath string
+}
+
+// NewAdminApp builds the app served on the optional admin listener (metrics.addr).
+func NewAdminApp(d AdminDeps) *fiber.App {
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+	})
+	if d.Metrics != nil {
+		mountMetrics(app, d.Metrics, d.MetricsPath)
+	}
+	return app
+}
@@ -0,0 +1,126 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"webhookd/internal/domain/plugin"
+)
+
+type pluginOutput struct {
+	Body plugin.Plugin
+}
+
+type pluginsOutput struct {
+	Body struct {
+		Plugins []plugin.Plugin `json:"plugins"`
+	}
+}
+
+// putPluginOutput answers 201 for new plugins, 200 for replaced ones.
+type putPluginOutput struct {
+	Status int
+	Body   plugin.Plugin
+}
+
+// registerPlugins manages the WebAssembly plugins hooks can use.
+func registerPlugins(api huma.API, d Deps) {
+	huma.Register(api, huma.Operation{
+		OperationID:  "put-plugin",
+		Method:       http.MethodPut,
+		Path:         "/v1/plugins/{name}",
+		Summary:      "Upload a WebAssembly plugin",
+		Description:  "Stores a WASI module as plugin name, replacing the plugin of that name. The module is compiled on upload; hooks using the plugin run the new module from then on.",
+		MaxBodyBytes: plugin.MaxModuleSize,
+		Errors:       []int{422},
+	}, func(ctx context.Context, input *struct {
+		Name    string `path:"name" maxLength:"100" doc:"Plugin name" example:"pricing"`
+		RawBody []byte `contentType:"application/wasm"`
+	}) (*putPluginOutput, error) {
+		p, created, err := d.Webhooks.PutPlugin(ctx, pluginNameParam(input.Name), input.RawBody)
+		if err != nil {
+			return nil, mapPluginErr(err)
+		}
+		resp := &putPluginOutput{Status: http.StatusOK, Body: p}
+		if created {
+			resp.Status = http.StatusCreated
+		}
+		return resp, nil
+	})
+
+	huma.Get(api, "/v1/plugins", func(ctx context.Context, _ *struct{}) (*pluginsOutput, error) {
+		plugins, err := d.Webhooks.Plugins(ctx)
+		if err != nil {
+			return nil, err
+		}
+		resp := &pluginsOutput{}
+		resp.Body.Plugins = plugins
+		return resp, nil
+	}, func(o *huma.Operation) {
+		o.Summary = "List the WebAssembly plugins"
+	})
+
+	huma.Get(api, "/v1/plugins/{name}", func(ctx context.Context, input *struct {
+		Name string `path:"name" doc:"Plugin name"`
+	}) (*pluginOutput, error) {
+		p, ok, err := d.Webhooks.Plugin(ctx, pluginNameParam(input.Name))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, huma.Error404NotFound("not found")
+		}
+		return &pluginOutput{Body: p}, nil
+	}, func(o *huma.Operation) {
+		o.Summary = "Get a WebAssembly plugin"
+	})
+
+	huma.Delete(api, "/v1/plugins/{name}", func(ctx context.Context, input *struct {
+		Name string `path:"name" doc:"Plugin name"`
+	}) (*struct {
+		Body struct {
+			Message string `json:"message"`
+			Name    string `json:"name"`
+		}
+	}, error) {
+		name := pluginNameParam(input.Name)
+		ok, err := d.Webhooks.DeletePlugin(ctx, name)
+		if err != nil {
+			return nil, mapPluginErr(err)
+		}
+		if !ok {
+			return nil, huma.Error404NotFound("not found")
+		}
+		resp := &struct {
+			Body struct {
+				Message string `json:"message"`
+				Name    string `json:"name"`
+			}
+		}{}
+		resp.Body.Message = "deleted"
+		resp.Body.Name = name
+		return resp, nil
+	}, func(o *huma.Operation) {
+		o.Summary = "Delete a WebAssembly plugin"
+		o.Description = "Plugins that hooks use can't be deleted; change those hooks first."
+	})
+}
+
+// pluginNameParam unescapes a plugin name path parameter, copied out of
+// Fiber's buffers since names are kept.
+func pluginNameParam(raw string) string {
+	return string(hookIDParam(raw))
+}
+
+func mapPluginErr(err error) error {
+	switch {
+	case errors.Is(err, plugin.ErrInvalidName), errors.Is(err, plugin.ErrInvalidModule):
+		return huma.Error422UnprocessableEntity(err.Error())
+	case errors.Is(err, plugin.ErrInUse):
+		return huma.Error409Conflict(err.Error())
+	}
+	return err
+}
@@ -0,0 +1,271 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"webhookd/internal/application/webhooks"
+	"webhookd/internal/domain/webhook"
+)
+
+// maxProxyBody caps the upstream responses a recording proxy reads.
+const maxProxyBody = 10 << 20
+
+// proxyUpstreams holds the upstreams recording proxies may forward to
+// (server.proxy_upstreams), and the client that forwards their requests.
+type proxyUpstreams struct {
+	allow  webhook.Upstreams
+	client *http.Client
+}
+
+// newProxyUpstreams returns a client that only connects to upstreams in
+// allow. Redirects are passed back to the client (and recorded) rather than
+// followed. Proxy environment variables are ignored, so that the dialer
+// checks the upstream itself.
+func newProxyUpstreams(allow webhook.Upstreams) *proxyUpstreams {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if !allow.AllowsAddr(host, port) {
+			return nil, fmt.Errorf("%w: %s", webhook.ErrUpstreamNotAllowed, addr)
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return &proxyUpstreams{
+		allow: allow,
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: transport,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// check refuses proxy settings whose upstream isn't allowed.
+func (u *proxyUpstreams) check(p *webhook.Proxy) error {
+	if p == nil || p.Upstream == "" {
+		return nil
+	}
+	return u.allow.Allows(p.Upstream)
+}
+
+// hopHeaders only apply to one connection and are not forwarded either way.
+var hopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Connection", "Proxy-Authenticate", "Proxy-Authorization",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade", "Host", "Content-Length",
+}
+
+// duplicateHeader marks the answers to CloudEvents a deduplicating proxy
+// forwarded before.
+const duplicateHeader = "X-Webhookd-Duplicate"
+
+// proxyInvoke forwards a request below a recording proxy hook to its
+// upstream, records the response as a hook (webhooks.Service.Record) and
+// returns it to the client unchanged. The request is captured on the proxy.
+// Requests that aren't CloudEvents are wrapped in one when the proxy asks
+// for it, and CloudEvents it forwarded before aren't forwarded again when it
+// dedupes.
+func proxyInvoke(ctx context.Context, d Deps, upstreams *proxyUpstreams, fc *fiber.Ctx, served func(*webhook.Hook, int), proxy *webhook.Hook, sub, method string) (*invokeOutput, error) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.String("webhookd.proxy.id", string(proxy.ID)))
+
+	event := webhook.ParseCloudEvent(requestHeader(fc), fc.Body())
+	var wrap *webhook.CloudEvent
+	switch {
+	case event != nil:
+		span.SetAttributes(attribute.String("webhookd.cloudevent.id", event.ID), attribute.String("webhookd.cloudevent.type", event.Type))
+		if proxy.Proxy.Dedupe {
+			if !d.Webhooks.ClaimDelivery(proxy.ID, *event) {
+				span.SetAttributes(attribute.Bool("webhookd.cloudevent.duplicate", true))
+				return duplicateDelivery(ctx, d, fc, served, proxy, *event)
+			}
+		}
+	case proxy.Proxy.CloudEvents != "":
+		wrap = wrapEvent(fc, proxy, sub)
+		span.SetAttributes(attribute.String("webhookd.cloudevent.id", wrap.ID), attribute.String("webhookd.cloudevent.type", wrap.Type))
+	}
+	// Failed deliveries are forwarded again when retried.
+	release := func() {
+		if event != nil && proxy.Proxy.Dedupe {
+			d.Webhooks.ReleaseDelivery(proxy.ID, *event)
+		}
+	}
+
+	r, body, err := forward(ctx, upstreams.client, fc, proxy.Proxy.Upstream, sub, method, wrap)
+	if err != nil {
+		release()
+		d.logger().WarnContext(ctx, "proxy upstream", "proxy_id", string(proxy.ID), "path", sub, "error", err)
+		served(proxy, http.StatusBadGateway)
+		return nil, huma.Error502BadGateway("upstream request failed")
+	}
+	switch {
+	case r.Status >= 500:
+		release()
+	case event != nil && proxy.Proxy.Dedupe:
+		d.Webhooks.CompleteDelivery(proxy.ID, *event, r)
+	}
+	if _, _, err := d.Webhooks.Touch(ctx, proxy.ID); err != nil {
+		return nil, err
+	}
+	if err := d.Webhooks.Record(ctx, proxy.ID, sub, method, r); err != nil {
+		level := d.logger().WarnContext
+		if errors.Is(err, webhooks.ErrNotRecorded) {
+			level = d.logger().InfoContext
+		}
+		level(ctx, "not recorded", "proxy_id", string(proxy.ID), "path", sub, "method", method, "error", err)
+	}
+	return proxyResponse(fc, served, proxy, r, body), nil
+}
+
+// duplicateDelivery answers a CloudEvent the proxy forwarded before with
+// the response the upstream gave to it, without contacting the upstream;
+// with 202 and no body when there is none (yet).
+func duplicateDelivery(ctx context.Context, d Deps, fc *fiber.Ctx, served func(*webhook.Hook, int), proxy *webhook.Hook, event webhook.CloudEvent) (*invokeOutput, error) {
+	if _, _, err := d.Webhooks.Touch(ctx, proxy.ID); err != nil {
+		return nil, err
+	}
+	fc.Set(duplicateHeader, "true")
+	r, ok := d.Webhooks.DeliveryResponse(proxy.ID, event)
+	if !ok {
+		r = webhook.Response{Status: http.StatusAccepted}
+	}
+	body := r.BodyBase64
+	if body == nil {
+		body = []byte(r.Body)
+	}
+	return proxyResponse(fc, served, proxy, r, body), nil
+}
+
+// proxyResponse returns r, with body, to the client.
+func proxyResponse(fc *fiber.Ctx, served func(*webhook.Hook, int), proxy *webhook.Hook, r webhook.Response, body []byte) *invokeOutput {
+	for k, v := range r.Headers {
+		fc.Set(k, v)
+	}
+	addHeaderValues(fc, r)
+	served(proxy, r.Status)
+	return &invokeOutput{Body: func(hctx huma.Context) {
+		if r.ContentType != "" {
+			hctx.SetHeader("Content-Type", r.ContentType)
+		}
+		hctx.SetStatus(r.Status)
+		_, _ = hctx.BodyWriter().Write(body)
+	}}
+}
+
+// wrapEvent returns the CloudEvent the proxy wraps the request in: a new
+// id, the proxy as source and the path below it as subject.
+func wrapEvent(fc *fiber.Ctx, proxy *webhook.Hook, sub string) *webhook.CloudEvent {
+	e := &webhook.CloudEvent{
+		Mode:            proxy.Proxy.CloudEvents,
+		SpecVersion:     webhook.CloudEventsSpecVersion,
+		ID:              uuid.NewString(),
+		Source:          "/v1/hooks/" + string(proxy.ID),
+		Type:            proxy.Proxy.EventType,
+		Subject:         "/" + sub,
+		Time:            time.Now().UTC().Format(time.RFC3339Nano),
+		DataContentType: fc.Get(fiber.HeaderContentType),
+	}
+	if e.Type == "" {
+		e.Type = webhook.DefaultEventType
+	}
+	return e
+}
+
+// forward sends the request to upstream/sub (with its query), wrapped in
+// the CloudEvent wrap unless it is nil, and returns the response as a hook
+// response, plus its body.
+func forward(ctx context.Context, client *http.Client, fc *fiber.Ctx, upstream, sub, method string, wrap *webhook.CloudEvent) (webhook.Response, []byte, error) {
+	target := upstream + "/" + sub
+	if q := fc.Request().URI().QueryString(); len(q) > 0 {
+		target += "?" + string(q)
+	}
+	reqBody := bytes.Clone(fc.Body())
+	if wrap != nil && wrap.Mode == webhook.CloudEventsStructured {
+		var err error
+		if reqBody, err = wrap.Structured(reqBody); err != nil {
+			return webhook.Response{}, nil, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(reqBody))
+	if err != nil {
+		return webhook.Response{}, nil, err
+	}
+	fc.Request().Header.VisitAll(func(k, v []byte) {
+		req.Header.Add(string(k), string(v))
+	})
+	for _, h := range hopHeaders {
+		req.Header.Del(h)
+	}
+	switch {
+	case wrap == nil:
+	case wrap.Mode == webhook.CloudEventsStructured:
+		req.Header.Set("Content-Type", webhook.CloudEventsMediaType)
+	default:
+		for k, v := range wrap.Binary() {
+			req.Header.Set(k, v)
+		}
+	}
+	// Set explicitly, so the transport doesn't ask for gzip and decompress
+	// behind our back: compressed responses are recorded as they are.
+	req.Header.Set("Accept-Encoding", fc.Get(fiber.HeaderAcceptEncoding, "identity"))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return webhook.Response{}, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProxyBody+1))
+	if err != nil {
+		return webhook.Response{}, nil, err
+	}
+	if len(body) > maxProxyBody {
+		return webhook.Response{}, nil, fmt.Errorf("response larger than %d bytes", maxProxyBody)
+	}
+
+	for _, h := range hopHeaders {
+		resp.Header.Del(h)
+	}
+	resp.Header.Del("Date")
+	r := webhook.Response{
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Headers:     make(map[string]string, len(resp.Header)),
+	}
+	resp.Header.Del("Content-Type")
+	for k, vs := range resp.Header {
+		if len(vs) == 1 {
+			r.Headers[k] = vs[0]
+			continue
+		}
+		if r.HeaderValues == nil {
+			r.HeaderValues = map[string][]string{}
+		}
+		r.HeaderValues[k] = vs
+	}
+	if utf8.Valid(body) {
+		r.Body = string(body)
+	} else {
+		r.BodyBase64 = body
+	}
+	return r, body, nil
+}
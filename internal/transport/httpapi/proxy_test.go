@@ -0,0 +1,78 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"webhookd/internal/domain/webhook"
+)
+
+func TestProxyUpstreamsDialer(t *testing.T) {
+	var hits atomic.Int32
+	elsewhere := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+	}))
+	defer elsewhere.Close()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, elsewhere.URL+"/", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer upstream.Close()
+	host := mustHost(t, upstream.URL)
+
+	tests := []struct {
+		name       string
+		allow      []string
+		path       string
+		wantStatus int // 0: refused
+	}{
+		{name: "empty allowlist", path: "/"},
+		{name: "host:port", allow: []string{host}, path: "/", wantStatus: http.StatusNoContent},
+		{name: "ip any port", allow: []string{"127.0.0.1"}, path: "/", wantStatus: http.StatusNoContent},
+		{name: "cidr", allow: []string{"127.0.0.0/8"}, path: "/", wantStatus: http.StatusNoContent},
+		{name: "other port", allow: []string{"127.0.0.1:1"}, path: "/"},
+		{name: "name doesn't allow its ip", allow: []string{"localhost"}, path: "/"},
+		{name: "redirect is not followed", allow: []string{host}, path: "/redirect", wantStatus: http.StatusFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allow, err := webhook.ParseUpstreams(tt.allow)
+			if err != nil {
+				t.Fatalf("ParseUpstreams: %v", err)
+			}
+			resp, err := newProxyUpstreams(allow).client.Get(upstream.URL + tt.path)
+			if tt.wantStatus == 0 {
+				if !errors.Is(err, webhook.ErrUpstreamNotAllowed) {
+					t.Fatalf("Get: %v, want ErrUpstreamNotAllowed", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+	if n := hits.Load(); n != 0 {
+		t.Fatalf("the redirect target was reached %d times", n)
+	}
+}
+
+func mustHost(t *testing.T, rawURL string) string {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Host
+}
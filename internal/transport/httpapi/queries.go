@@ -0,0 +1,224 @@
+package httpapi
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/gofiber/fiber/v2"
+
+	"webhookd/internal/domain/webhook"
+)
+
+// CapturedRequest is the API representation of a captured invocation. Bodies
+// that aren't valid UTF-8 are returned base64-encoded in BodyBase64; logged
+// bodies are cut to webhook.MaxLoggedBody bytes.
+type CapturedRequest struct {
+	ID         string              `json:"id"`
+	HookID     string              `json:"hook_id"`
+	Method     string              `json:"method"`
+	Path       string              `json:"path"`
+	Query      string              `json:"query,omitempty"`
+	Headers    map[string][]string `json:"headers"`
+	Body       string              `json:"body,omitempty"`
+	BodyBase64 []byte              `json:"body_base64,omitempty"`
+	// BodyTruncated is set when the body was cut.
+	BodyTruncated bool      `json:"body_truncated,omitempty"`
+	RemoteAddr    string    `json:"remote_addr"`
+	Status        int       `json:"status"`
+	Fault         string    `json:"fault,omitempty"`
+	Received      time.Time `json:"received"`
+	DurationMS    float64   `json:"duration_ms"`
+	// Validation is set for hooks with a request schema.
+	Validation *webhook.ValidationResult `json:"validation,omitempty"`
+	// CloudEvent is set for requests carrying a CloudEvent.
+	CloudEvent *webhook.CloudEvent `json:"cloudevent,omitempty"`
+}
+
+func newCapturedRequest(r webhook.Request) CapturedRequest {
+	out := CapturedRequest{
+		ID:            r.ID,
+		HookID:        string(r.HookID),
+		Method:        r.Method,
+		Path:          r.Path,
+		Query:         r.Query,
+		Headers:       r.Headers,
+		BodyTruncated: r.BodyTruncated,
+		RemoteAddr:    r.RemoteAddr,
+		Status:        r.Status,
+		Fault:         r.Fault,
+		Received:      r.Received,
+		DurationMS:    float64(r.Duration.Microseconds()) / 1000,
+		Validation:    r.Validation,
+		CloudEvent:    r.CloudEvent,
+	}
+	if utf8.Valid(r.Body) {
+		out.Body = string(r.Body)
+	} else {
+		out.BodyBase64 = r.Body
+	}
+	return out
+}
+
+// captureRequest copies what we keep of an invocation out of the fiber
+// context; nothing may reference fasthttp buffers after the handler returns.
+func captureRequest(fc *fiber.Ctx, id webhook.ID, status int, took time.Duration) webhook.Request {
+	headers := map[string][]string{}
+	fc.Request().Header.VisitAll(func(k, v []byte) {
+		key := string(k)
+		headers[key] = append(headers[key], string(v))
+	})
+	body := append([]byte(nil), fc.Body()...)
+	return webhook.Request{
+		HookID:     id,
+		Method:     strings.Clone(fc.Method()),
+		Path:       strings.Clone(fc.Path()),
+		Query:      string(fc.Request().URI().QueryString()),
+		Headers:    headers,
+		Body:       body,
+		RemoteAddr: strings.Clone(fc.IP()),
+		Status:     status,
+		Duration:   took,
+		CloudEvent: webhook.ParseCloudEvent(headers, body),
+	}
+}
+
+// HookView is the API representation of a hook. The fields of its response
+// are inlined, as in webhook.Hook; they are listed here since Huma can't link
+// the schema of a type embedding one with methods.
+type HookView struct {
+	ID           string              `json:"id"`
+	Method       string              `json:"method"`
+	Body         string              `json:"body"`
+	BodyBase64   []byte              `json:"body_base64,omitempty"`
+	BodyFile     string              `json:"body_file,omitempty"`
+	ContentType  string              `json:"content_type,omitempty"`
+	Headers      map[string]string   `json:"headers"`
+	HeaderValues map[string][]string `json:"header_values,omitempty"`
+	Status       int                 `json:"status"`
+	Template     bool                `json:"template,omitempty"`
+	Script       string              `json:"script,omitempty"`
+	Plugin       string              `json:"plugin,omitempty"`
+
+	Responses    map[string]webhook.Response `json:"responses,omitempty"`
+	Chaos        *webhook.Chaos              `json:"chaos,omitempty"`
+	Proxy        *webhook.Proxy              `json:"proxy,omitempty"`
+	RecordedFrom string                      `json:"recorded_from,omitempty"`
+	Validation   *webhook.RequestValidation  `json:"validation,omitempty"`
+	Managed      bool                        `json:"managed,omitempty"`
+
+	Active   bool      `json:"active"`
+	Counter  int64     `json:"counter"`
+	LastCall time.Time `json:"last_call"`
+	Created  time.Time `json:"created"`
+}
+
+func newHookView(h *webhook.Hook) HookView {
+	r := h.Response
+	return HookView{
+		ID:           string(h.ID),
+		Method:       h.Method,
+		Body:         r.Body,
+		BodyBase64:   r.BodyBase64,
+		BodyFile:     r.BodyFile,
+		ContentType:  r.ContentType,
+		Headers:      r.Headers,
+		HeaderValues: r.HeaderValues,
+		Status:       r.Status,
+		Template:     r.Template,
+		Script:       r.Script,
+		Plugin:       r.Plugin,
+		Responses:    h.Responses,
+		Chaos:        h.Chaos,
+		Proxy:        h.Proxy,
+		RecordedFrom: string(h.RecordedFrom),
+		Validation:   h.Validation,
+		Managed:      h.Managed,
+		Active:       h.Active,
+		Counter:      h.Counter,
+		LastCall:     h.LastCall,
+		Created:      h.Created,
+	}
+}
+
+func registerHookQueries(api huma.API, d Deps) {
+	huma.Get(api, "/v1/webhooks", func(ctx context.Context, _ *struct{}) (*struct {
+		Body struct {
+			Hooks []HookView `json:"hooks"`
+		}
+	}, error) {
+		hooks, err := d.Webhooks.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		resp := &struct {
+			Body struct {
+				Hooks []HookView `json:"hooks"`
+			}
+		}{}
+		resp.Body.Hooks = make([]HookView, 0, len(hooks))
+		for _, h := range hooks {
+			resp.Body.Hooks = append(resp.Body.Hooks, newHookView(h))
+		}
+		sort.Slice(resp.Body.Hooks, func(i, j int) bool {
+			return resp.Body.Hooks[i].Created.Before(resp.Body.Hooks[j].Created)
+		})
+		return resp, nil
+	}, func(o *huma.Operation) {
+		o.Summary = "List webhooks"
+	})
+
+	huma.Get(api, "/v1/webhooks/{id}", func(ctx context.Context, input *struct {
+		ID string `path:"id" doc:"Webhook id"`
+	}) (*struct {
+		Body HookView
+	}, error) {
+		h, ok, err := d.Webhooks.Get(ctx, hookIDParam(input.ID))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, huma.Error404NotFound("not found")
+		}
+		return &struct {
+			Body HookView
+		}{Body: newHookView(h)}, nil
+	}, func(o *huma.Operation) {
+		o.Summary = "Get a webhook"
+	})
+
+	huma.Get(api, "/v1/webhooks/{id}/requests", func(ctx context.Context, input *struct {
+		ID    string `path:"id" doc:"Webhook id"`
+		Limit int    `query:"limit" minimum:"0" doc:"Only the most recent N requests (0 = all retained)"`
+	}) (*struct {
+		Body struct {
+			Requests []CapturedRequest `json:"requests"`
+		}
+	}, error) {
+		id := hookIDParam(input.ID)
+		if _, ok, err := d.Webhooks.Get(ctx, id); err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, huma.Error404NotFound("not found")
+		}
+		reqs, err := d.Webhooks.Requests(ctx, id, input.Limit)
+		if err != nil {
+			return nil, err
+		}
+		resp := &struct {
+			Body struct {
+				Requests []CapturedRequest `json:"requests"`
+			}
+		}{}
+		resp.Body.Requests = make([]CapturedRequest, len(reqs))
+		for i, r := range reqs {
+			resp.Body.Requests[i] = newCapturedRequest(r)
+		}
+		return resp, nil
+	}, func(o *huma.Operation) {
+		o.Summary = "List captured invocations of a webhook"
+	})
+}
@@ -0,0 +1,65 @@
+package httpapi
+
+import (
+	"context"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type stateOutput struct {
+	Body struct {
+		ID    string            `json:"id"`
+		State map[string]string `json:"state" doc:"Keys and values templates stored with kvPut"`
+	}
+}
+
+// registerHookState serves the key-value stores of templated hooks.
+func registerHookState(api huma.API, d Deps) {
+	huma.Get(api, "/v1/webhooks/{id}/state", func(ctx context.Context, input *struct {
+		ID string `path:"id" doc:"Webhook id"`
+	}) (*stateOutput, error) {
+		id := hookIDParam(input.ID)
+		state, ok, err := d.Webhooks.State(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, huma.Error404NotFound("not found")
+		}
+		resp := &stateOutput{}
+		resp.Body.ID = string(id)
+		resp.Body.State = state
+		return resp, nil
+	}, func(o *huma.Operation) {
+		o.Summary = "Get the key-value store of a webhook"
+	})
+
+	huma.Delete(api, "/v1/webhooks/{id}/state", func(ctx context.Context, input *struct {
+		ID string `path:"id" doc:"Webhook id"`
+	}) (*struct {
+		Body struct {
+			Message string `json:"message"`
+			ID      string `json:"id"`
+		}
+	}, error) {
+		id := hookIDParam(input.ID)
+		ok, err := d.Webhooks.ResetState(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, huma.Error404NotFound("not found")
+		}
+		resp := &struct {
+			Body struct {
+				Message string `json:"message"`
+				ID      string `json:"id"`
+			}
+		}{}
+		resp.Body.Message = "state reset"
+		resp.Body.ID = string(id)
+		return resp, nil
+	}, func(o *huma.Operation) {
+		o.Summary = "Empty the key-value store of a webhook"
+	})
+}
@@ -0,0 +1,41 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"webhookd/internal/domain/webhook"
+)
+
+// validationError answers a request rejected by its hook's schema, with a
+// detail per mismatch located in the body, e.g. body.items[0].id.
+func validationError(res *webhook.ValidationResult) error {
+	details := make([]error, len(res.Errors))
+	for i, e := range res.Errors {
+		details[i] = &huma.ErrorDetail{Message: e.Message, Location: bodyLocation(e.Location)}
+	}
+	return huma.NewError(http.StatusUnprocessableEntity, "request body does not match the webhook's schema", details...)
+}
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// bodyLocation turns a JSON Pointer into the body into a Huma error
+// location.
+func bodyLocation(pointer string) string {
+	loc := "body"
+	if pointer == "" {
+		return loc
+	}
+	for tok := range strings.SplitSeq(strings.TrimPrefix(pointer, "/"), "/") {
+		tok = pointerUnescaper.Replace(tok)
+		if _, err := strconv.Atoi(tok); err == nil {
+			loc += "[" + tok + "]"
+		} else {
+			loc += "." + tok
+		}
+	}
+	return loc
+}
@@ -0,0 +1,193 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"webhookd/internal/application/webhooks"
+	"webhookd/internal/infrastructure/auth/jwtmiddleware"
+	"webhookd/internal/infrastructure/configfile"
+	"webhookd/internal/observability"
+	"webhookd/internal/transport/httpapi"
+)
+
+// reloadDebounce coalesces the bursts of events editors produce on save
+// (truncate + write, or write to a temp file + rename).
+const reloadDebounce = 200 * time.Millisecond
+
+// reloader re-reads the config on SIGHUP or when the file changes, and
+// applies the settings that can change without a restart (see
+// configfile.Reloadable), including the hooks declared in it. A config that
+// fails to load or validate is logged and ignored; the server keeps running
+// on the previous one.
+type reloader struct {
+	opts    configfile.LoadOptions
+	logger  *slog.Logger
+	level   *slog.LevelVar
+	auth    *jwtmiddleware.Middleware
+	hooks   *webhooks.Service
+	metrics *observability.Metrics
+
+	mu sync.Mutex
+	// started is the config the server was started with; changes to
+	// non-reloadable settings are reported against it until a restart.
+	started configfile.Config
+	// current is the config last applied.
+	current configfile.Config
+}
+
+func newReloader(opts configfile.LoadOptions, cfg configfile.Config, logger *slog.Logger, level *slog.LevelVar, auth *jwtmiddleware.Middleware, hooks *webhooks.Service, metrics *observability.Metrics) *reloader {
+	return &reloader{
+		opts:    opts,
+		logger:  logger,
+		level:   level,
+		auth:    auth,
+		hooks:   hooks,
+		metrics: metrics,
+		started: cfg,
+		current: cfg,
+	}
+}
+
+// reload loads the config again and applies what changed.
+func (r *reloader) reload(trigger string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loaded, err := configfile.Load(r.opts)
+	if err != nil {
+		r.metrics.ConfigReloaded(err)
+		r.logger.Error("config reload failed; keeping the current config", "trigger", trigger, "err", err)
+		return
+	}
+	for _, w := range loaded.Warnings {
+		r.logger.Warn("ignoring legacy config key", "trigger", trigger, "err", w)
+	}
+	cfg := loaded.Config
+	next, err := r.apply(cfg)
+	r.metrics.ConfigReloaded(err)
+	if err != nil {
+		// What failed isn't recorded as applied, so the next reload retries it.
+		r.logger.Error("config reload incomplete", "trigger", trigger, "err", err)
+	}
+
+	var applied, restart []string
+	for _, k := range configfile.Diff(r.current, next) {
+		if configfile.Reloadable(k) {
+			applied = append(applied, k)
+		}
+	}
+	for _, k := range configfile.Diff(r.started, cfg) {
+		if !configfile.Reloadable(k) {
+			restart = append(restart, k)
+		}
+	}
+	r.current = next
+
+	if len(applied) > 0 {
+		r.logger.Info("config reloaded", "trigger", trigger, "path", loaded.Path, "changed", applied)
+	} else {
+		r.logger.Debug("config reloaded; nothing to apply", "trigger", trigger, "path", loaded.Path)
+	}
+	if len(restart) > 0 {
+		r.logger.Warn("config change requires restart", "keys", restart)
+	}
+}
+
+// apply swaps in the reloadable settings of cfg and returns the config now
+// in effect: the current one with the settings that were applied. A setting
+// that fails is reported; the others still take effect.
+func (r *reloader) apply(cfg configfile.Config) (configfile.Config, error) {
+	next := r.current
+	var errs []error
+	if level, err := observability.ParseLevel(cfg.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: %w", err))
+	} else {
+		r.level.Set(level)
+		next.Log.Level = cfg.Log.Level
+	}
+
+	r.auth.Update(httpapi.AuthConfig(cfg, r.metrics))
+	next.EnableAuthOnOptions = cfg.EnableAuthOnOptions
+	next.TokenExtractors = cfg.TokenExtractors
+	next.OAuthJsonWebKeySetsURL = cfg.OAuthJsonWebKeySetsURL
+	next.OAuthIssuer = cfg.OAuthIssuer
+	next.OAuthAudience = cfg.OAuthAudience
+
+	// Hooks are compared with the repository, so the ones that fail are
+	// retried on the next reload.
+	if err := reconcileHooks(context.Background(), r.hooks, cfg.Hooks, r.logger); err != nil {
+		errs = append(errs, err)
+	} else {
+		next.Hooks = cfg.Hooks
+	}
+	next.Client = cfg.Client
+	return next, errors.Join(errs...)
+}
+
+// watch reloads on SIGHUP and, when path is set, on changes to that file
+// until ctx is done. The directory is watched rather than the file so that
+// editors which replace the file on save keep triggering reloads.
+func (r *reloader) watch(ctx context.Context, path string) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	var watcher *fsnotify.Watcher
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	var target string
+	if path != "" {
+		w, err := fsnotify.NewWatcher()
+		if err == nil {
+			target, _ = filepath.Abs(path)
+			if err = w.Add(filepath.Dir(target)); err != nil {
+				_ = w.Close()
+			}
+		}
+		if err != nil {
+			r.logger.Warn("not watching config file; reload with SIGHUP", "path", path, "err", err)
+		} else {
+			watcher, events, errs = w, w.Events, w.Errors
+		}
+	}
+
+	go func() {
+		defer signal.Stop(hup)
+		if watcher != nil {
+			defer watcher.Close()
+		}
+		var debounce *time.Timer
+		for {
+			select {
+			case <-ctx.Done():
+				if debounce != nil {
+					debounce.Stop()
+				}
+				return
+			case <-hup:
+				r.reload("SIGHUP")
+			case ev := <-events:
+				if abs, _ := filepath.Abs(ev.Name); abs != target || ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+				if debounce == nil {
+					debounce = time.AfterFunc(reloadDebounce, func() { r.reload("file") })
+				} else {
+					debounce.Reset(reloadDebounce)
+				}
+			case err := <-errs:
+				r.logger.Warn("config watcher error", "err", err)
+			}
+		}
+	}()
+}
@@ -3,87 +3,200 @@ package runtime
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/gofiber/fiber/v2"
 
+	"webhookd/internal/application/health"
+	"webhookd/internal/application/ports"
 	"webhookd/internal/application/webhooks"
+	"webhookd/internal/domain/webhook"
+	"webhookd/internal/infrastructure/auth/jwtmiddleware"
+	"webhookd/internal/infrastructure/celscript"
 	"webhookd/internal/infrastructure/configfile"
+	"webhookd/internal/infrastructure/jsonschema"
+	"webhookd/internal/infrastructure/repository/instrumented"
 	"webhookd/internal/infrastructure/repository/memory"
+	"webhookd/internal/infrastructure/repository/sqldb"
+	"webhookd/internal/infrastructure/wasmplugin"
 	"webhookd/internal/observability"
 	"webhookd/internal/transport/httpapi"
 )
 
 type Options struct {
-	Addr       string
 	ConfigPath string
-	Debug      bool
-	Verbose    bool
-	Version    string
+	// Overrides are the command-line flags the user set; they take precedence
+	// over the config file and environment.
+	Overrides []configfile.Override
+	Version   string
+	// Banner is printed to stderr at startup unless logs are JSON.
+	Banner string
 }
 
 func Run(ctx context.Context, opts Options) error {
-	_ = godotenv.Load()
+	loadOpts := configfile.LoadOptions{
+		Path:      opts.ConfigPath,
+		EnvPrefix: configfile.EnvPrefix,
+		Overrides: opts.Overrides,
+	}
+	loaded, err := configfile.Load(loadOpts)
+	if err != nil {
+		return fmt.Errorf("parse config: %w", err)
+	}
+	cfg := loaded.Config
+
+	if opts.Banner != "" && cfg.Log.Format != observability.LogFormatJSON {
+		fmt.Fprintln(os.Stderr, opts.Banner)
+	}
 
-	otelShutdown, err := observability.SetupFromEnv(ctx, "webhookd", opts.Version)
+	otelShutdown := func(context.Context) error { return nil }
+	if cfg.OTel.Active() {
+		if otelShutdown, err = observability.Setup(ctx, otelConfig(cfg.OTel, opts.Version)); err != nil {
+			return fmt.Errorf("opentelemetry setup: %w", err)
+		}
+	}
+
+	parsedLevel, err := observability.ParseLevel(cfg.Log.Level)
+	if err != nil {
+		return err
+	}
+	// A LevelVar so config reloads can change it.
+	level := new(slog.LevelVar)
+	level.Set(parsedLevel)
+	logger, err := observability.NewLogger(os.Stderr, observability.LogOptions{
+		Format:      cfg.Log.Format,
+		Level:       level,
+		AddSource:   cfg.Log.AddSource,
+		ServiceName: cfg.OTel.ServiceName,
+	})
 	if err != nil {
-		return fmt.Errorf("opentelemetry setup: %w", err)
+		return err
 	}
+	// Also routes anything still using the stdlib log package through slog.
+	slog.SetDefault(logger)
 
-	cfgPath := opts.ConfigPath
-	cfg, err := configfile.ParseFile(cfgPath)
+	switch {
+	case loaded.Legacy:
+		logger.Warn("config file not found; using legacy config (deprecated)", "path", configfile.DefaultPath, "legacy_path", loaded.Path)
+		for _, w := range loaded.Warnings {
+			logger.Warn("ignoring legacy config key", "err", w)
+		}
+	case loaded.Path == "":
+		logger.Info("config file not found; starting with defaults", "looked_for", configfile.DefaultPaths)
+	default:
+		logger.Debug("config loaded", "path", loaded.Path)
+	}
+
+	instruments, err := observability.NewInstruments()
 	if err != nil {
-		switch {
-		case os.IsNotExist(err) && cfgPath == "webhookd.json":
-			legacyPath := ".webhookdrc.json"
-			if _, statErr := os.Stat(legacyPath); statErr == nil {
-				log.Printf("config file %q not found; using legacy %q (deprecated)", cfgPath, legacyPath)
-				cfg, err = configfile.ParseFile(legacyPath)
+		return fmt.Errorf("opentelemetry instruments: %w", err)
+	}
+
+	checks := health.NewRegistry(0)
+
+	auditRepo, closeAudit, err := openAuditRepo(ctx, cfg.DB, checks)
+	if err != nil {
+		return err
+	}
+	defer closeAudit()
+
+	scripts, err := celscript.NewEngine()
+	if err != nil {
+		return fmt.Errorf("script engine: %w", err)
+	}
+	plugins, err := wasmplugin.NewRuntime(ctx, cfg.Server.MaxPluginInstances)
+	if err != nil {
+		return fmt.Errorf("plugin runtime: %w", err)
+	}
+	defer plugins.Close(context.WithoutCancel(ctx))
+	repo := instrumented.NewWebhooksRepo(memory.NewWebhooksRepo(), instruments)
+	svc := webhooks.NewService(repo,
+		webhooks.WithAuditLog(auditRepo),
+		webhooks.WithRequestLog(memory.NewRequestLog(0)),
+		webhooks.WithSchemaCompiler(jsonschema.NewCompiler()),
+		webhooks.WithStateStore(memory.NewStateStore()),
+		webhooks.WithScriptEngine(scripts),
+		webhooks.WithPlugins(memory.NewPluginRepo(), plugins),
+	)
+
+	if err := loadPlugins(ctx, svc, cfg.Server.PluginDir, logger); err != nil {
+		return fmt.Errorf("server.plugin_dir: %w", err)
+	}
+
+	if err := reconcileHooks(ctx, svc, cfg.Hooks, logger); err != nil {
+		return fmt.Errorf("hooks from config: %w", err)
+	}
+
+	checks.Register("repository", svc.Ping)
+	if otlpCheck := observability.ExporterHealthCheck(); otlpCheck != nil {
+		// A collector outage shouldn't take the service out of rotation.
+		checks.RegisterNonCritical("otlp", otlpCheck)
+	}
+
+	var metrics *observability.Metrics
+	if !cfg.Metrics.Disabled {
+		metrics = observability.NewMetrics(observability.MetricsOptions{
+			ActiveHooks: func() float64 {
+				n, err := svc.CountActive(context.Background())
 				if err != nil {
-					return fmt.Errorf("parse config: %w", err)
+					return 0
 				}
-			} else if os.IsNotExist(statErr) {
-				log.Printf("config file %q not found; starting with defaults", cfgPath)
-				cfg = configfile.Config{}
-			} else {
-				return fmt.Errorf("stat legacy config %q: %w", legacyPath, statErr)
-			}
-		case os.IsNotExist(err) && cfgPath == ".webhookdrc.json":
-			log.Printf("config file %q not found; starting with defaults", cfgPath)
-			cfg = configfile.Config{}
-		default:
-			return fmt.Errorf("parse config: %w", err)
-		}
+				return float64(n)
+			},
+		})
 	}
 
-	repo := memory.NewWebhooksRepo()
-	svc := webhooks.NewService(repo)
+	auth := jwtmiddleware.New(httpapi.AuthConfig(cfg, metrics))
 
 	app, err := httpapi.NewApp(httpapi.Deps{
-		Version:  opts.Version,
-		Config:   cfg,
-		Webhooks: svc,
+		Version:     opts.Version,
+		Config:      cfg,
+		Webhooks:    svc,
+		Metrics:     metrics,
+		Instruments: instruments,
+		Logger:      logger,
+		Health:      checks,
+		Auth:        auth,
 	})
 	if err != nil {
 		return err
 	}
 
 	// Fiber wants an addr string, but we validate it to fail early with a good error.
-	if _, _, err := net.SplitHostPort(opts.Addr); err != nil {
-		return fmt.Errorf("invalid addr %q: %w", opts.Addr, err)
+	addr := cfg.Server.Addr
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return fmt.Errorf("invalid addr %q: %w", addr, err)
 	}
 
-	errCh := make(chan error, 1)
+	errCh := make(chan error, 2)
 	go func() {
-		errCh <- app.Listen(opts.Addr)
+		errCh <- app.Listen(addr)
 	}()
 
-	log.Printf("listening on %s (version=%s)", opts.Addr, opts.Version)
+	logger.Info("listening", "addr", addr, "version", opts.Version)
+
+	var admin *fiber.App
+	if metrics != nil && cfg.Metrics.Addr != "" {
+		admin = httpapi.NewAdminApp(httpapi.AdminDeps{
+			Metrics:     metrics,
+			MetricsPath: cfg.Metrics.Path,
+		})
+		go func() {
+			errCh <- admin.Listen(cfg.Metrics.Addr)
+		}()
+		logger.Info("admin listening", "addr", cfg.Metrics.Addr, "metrics_path", cfg.Metrics.Path)
+	}
+
+	watchCtx, stopWatching := context.WithCancel(ctx)
+	defer stopWatching()
+	newReloader(loadOpts, cfg, logger, level, auth, svc, metrics).watch(watchCtx, loaded.Path)
 
 	// Shutdown on signals or parent context cancellation.
 	sigs := make(chan os.Signal, 2)
@@ -92,17 +205,123 @@ func Run(ctx context.Context, opts Options) error {
 
 	select {
 	case <-ctx.Done():
-	case <-sigs:
+	case sig := <-sigs:
+		logger.Info("shutting down", "signal", sig.String())
 	case err := <-errCh:
 		return err
 	}
 
+	// Fail readiness first and keep serving for a moment so load balancers drain us.
+	checks.SetShuttingDown()
+	if delay := time.Duration(cfg.Server.ShutdownDelaySeconds) * time.Second; delay > 0 {
+		logger.Info("draining before shutdown", "delay", delay)
+		select {
+		case <-time.After(delay):
+		case <-sigs:
+			// Second signal: stop waiting.
+		}
+	}
+
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	appErr := app.ShutdownWithContext(shutdownCtx)
+	var adminErr error
+	if admin != nil {
+		adminErr = admin.ShutdownWithContext(shutdownCtx)
+	}
 	otelErr := otelShutdown(shutdownCtx)
 	if appErr != nil {
 		return appErr
 	}
+	if adminErr != nil {
+		return adminErr
+	}
 	return otelErr
 }
+
+// loadPlugins stores the plugins of dir, one per name.wasm file.
+func loadPlugins(ctx context.Context, svc *webhooks.Service, dir string, logger *slog.Logger) error {
+	if dir == "" {
+		return nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.wasm"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		module, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(filepath.Base(file), ".wasm")
+		if _, _, err := svc.PutPlugin(ctx, name, module); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	logger.Debug("plugins loaded", "dir", dir, "count", len(files))
+	return nil
+}
+
+// reconcileHooks makes the hooks declared in the config file match the
+// repository (see webhooks.Service.Reconcile).
+func reconcileHooks(ctx context.Context, svc *webhooks.Service, hooks []configfile.HookConfig, logger *slog.Logger) error {
+	specs := make([]webhooks.HookSpec, len(hooks))
+	for i, h := range hooks {
+		specs[i] = webhooks.HookSpec{
+			ID:         webhook.ID(h.ID),
+			Method:     h.Method,
+			Response:   h.Response(),
+			Chaos:      h.Chaos,
+			Proxy:      h.Proxy,
+			Validation: h.Validation,
+		}
+		if len(h.Responses) > 0 {
+			specs[i].Responses = make(map[string]webhook.Response, len(h.Responses))
+			for m, r := range h.Responses {
+				specs[i].Responses[m] = r.Response()
+			}
+		}
+	}
+	res, err := svc.Reconcile(ctx, specs)
+	if res.Changed() {
+		logger.Info("hooks reconciled from config", "created", res.Created, "updated", res.Updated, "deactivated", res.Deactivated)
+	}
+	return err
+}
+
+func otelConfig(c configfile.OTelConfig, version string) observability.Config {
+	return observability.Config{
+		Enabled:        true,
+		Exporter:       c.Exporter,
+		Metrics:        !c.MetricsDisabled,
+		Logs:           !c.LogsDisabled,
+		ServiceName:    c.ServiceName,
+		ServiceVersion: version,
+		Environment:    c.Environment,
+		OTLPEndpoint:   c.Endpoint,
+		OTLPHeaders:    c.Headers,
+		Insecure:       c.Insecure,
+		SampleRatio:    c.SampleRatio,
+		MetricInterval: time.Duration(c.MetricExportIntervalSeconds) * time.Second,
+	}
+}
+
+// openAuditRepo picks the audit adapter for db.driver: in-process for
+// "memory", SQL (SQLite/Postgres) otherwise. The SQL connection is also
+// registered as a readiness check.
+func openAuditRepo(ctx context.Context, cfg configfile.DBConfig, checks *health.Registry) (ports.AuditRepository, func(), error) {
+	if strings.EqualFold(cfg.Driver, "memory") {
+		return memory.NewAuditRepo(), func() {}, nil
+	}
+	db, err := sqldb.Open(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	repo, err := sqldb.NewAuditRepo(ctx, db)
+	if err != nil {
+		_ = db.Close()
+		return nil, nil, err
+	}
+	checks.Register("database", db.Ping)
+	return repo, func() { _ = db.Close() }, nil
+}